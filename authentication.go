@@ -112,7 +112,7 @@ func getUnlockingScript(privateKey *ec.PrivateKey) (*p2pkh.P2PKH, error) {
 func createSignature(xPriv *bip32.ExtendedKey, bodyString string) (payload *models.AuthPayload, err error) {
 	// No key?
 	if xPriv == nil {
-		err = ErrMissingXpriv
+		err = WrapError(ErrMissingXpriv)
 		return
 	}
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 
 	bip32 "github.com/bitcoin-sv/go-sdk/compat/bip32"
 	ec "github.com/bitcoin-sv/go-sdk/primitives/ec"
@@ -14,6 +15,71 @@ type configurator interface {
 	Configure(c *WalletClient) error
 }
 
+// Option configures the WalletClient created with New.
+type Option configurator
+
+// WithXPriv sets the extended private key used to sign the requests.
+func WithXPriv(xPriv string) Option {
+	return &xPrivConf{XPrivString: xPriv}
+}
+
+// WithXPub sets the extended public key of the client.
+func WithXPub(xPub string) Option {
+	return &xPubConf{XPubString: xPub}
+}
+
+// WithAdminKey sets the extended private key used for the admin operations.
+func WithAdminKey(adminKey string) Option {
+	return &adminKeyConf{AdminKeyString: adminKey}
+}
+
+// WithAccessKey sets the access key (WIF or hex) used to sign the requests.
+func WithAccessKey(accessKey string) Option {
+	return &accessKeyConf{AccessKeyString: accessKey}
+}
+
+// WithHTTPClient sets the http client used to send the requests, e.g. to customize timeouts or the transport.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return &httpClientConf{HTTPClient: httpClient}
+}
+
+// WithSignRequest turns the signing of the requests on or off; requests are signed by default.
+func WithSignRequest(sign bool) Option {
+	return &signRequest{Sign: sign}
+}
+
+// WithBasePath replaces the default "/v1" base path of the spv-wallet API.
+func WithBasePath(basePath string) Option {
+	return &basePathConf{BasePath: basePath}
+}
+
+// WithDefaultHeaders sets the headers sent with every request; they cannot override the authentication headers.
+func WithDefaultHeaders(headers http.Header) Option {
+	return &headersConf{Headers: headers}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return &headersConf{Headers: http.Header{"User-Agent": []string{userAgent}}}
+}
+
+// WithRetryPolicy sets the policy used to retry requests failing with transient errors.
+func WithRetryPolicy(policy *RetryPolicy) Option {
+	return &retryPolicyConf{Policy: policy}
+}
+
+// WithDraftVerification makes SendToRecipients verify every draft transaction with VerifyDraft before signing it;
+// payments to paymails are refused unless the options set a PaymailResolver.
+func WithDraftVerification(opts *DraftVerificationOptions) Option {
+	return &draftVerificationConf{Options: opts}
+}
+
+// WithMockTransport sends all the requests to the given handler in memory instead of over the network,
+// e.g. to the spv-wallet stand-in of the mock package; the client then reports SPVWalletTransportMock as its transport.
+func WithMockTransport(handler http.Handler) Option {
+	return &mockTransportConf{Handler: handler}
+}
+
 // xPrivConf sets the xPrivString field of a WalletClient
 type xPrivConf struct {
 	XPrivString string
@@ -84,6 +150,9 @@ func (w *adminKeyConf) Configure(c *WalletClient) error {
 	return nil
 }
 
+// defaultBasePath is the path prefix of the spv-wallet API
+const defaultBasePath = "/v1"
+
 // httpConf sets the URL and httpConf client of a WalletClient
 type httpConf struct {
 	ServerURL  string
@@ -97,9 +166,9 @@ func (w *httpConf) Configure(c *WalletClient) error {
 		return ErrInvalidServerURL.Wrap(err)
 	}
 
-	const basePath = "/v1"
-	c.server = fmt.Sprintf("%s%s", baseURL, basePath)
+	c.server = fmt.Sprintf("%s%s", baseURL, defaultBasePath)
 
+	c.transport = SPVWalletTransportHTTP
 	c.httpClient = w.HTTPClient
 	if w.HTTPClient != nil {
 		c.httpClient = w.HTTPClient
@@ -109,6 +178,71 @@ func (w *httpConf) Configure(c *WalletClient) error {
 	return nil
 }
 
+// httpClientConf sets a custom http client on the WalletClient
+type httpClientConf struct {
+	HTTPClient *http.Client
+}
+
+func (w *httpClientConf) Configure(c *WalletClient) error {
+	if w.HTTPClient == nil {
+		return ErrInvalidHTTPClient
+	}
+	c.httpClient = w.HTTPClient
+	c.transport = SPVWalletTransportHTTP
+	return nil
+}
+
+// mockTransportConf routes the requests of a WalletClient to an in-memory handler
+type mockTransportConf struct {
+	Handler http.Handler
+}
+
+func (w *mockTransportConf) Configure(c *WalletClient) error {
+	if w.Handler == nil {
+		return ErrInvalidTransportHandler
+	}
+	c.httpClient = &http.Client{Transport: &handlerTransport{handler: w.Handler}}
+	c.transport = SPVWalletTransportMock
+	return nil
+}
+
+// basePathConf replaces the API base path of the WalletClient server URL
+type basePathConf struct {
+	BasePath string
+}
+
+func (w *basePathConf) Configure(c *WalletClient) error {
+	serverURL, err := url.Parse(c.server)
+	if err != nil {
+		return ErrInvalidServerURL.Wrap(err)
+	}
+
+	serverURL.Path = ""
+	if basePath := strings.Trim(w.BasePath, "/"); basePath != "" {
+		serverURL.Path = "/" + basePath
+	}
+	c.server = serverURL.String()
+	return nil
+}
+
+// headersConf sets the headers sent with every request of a WalletClient
+type headersConf struct {
+	Headers http.Header
+}
+
+func (w *headersConf) Configure(c *WalletClient) error {
+	if c.defaultHeaders == nil {
+		c.defaultHeaders = make(http.Header)
+	}
+	for key, values := range w.Headers {
+		c.defaultHeaders.Del(key)
+		for _, value := range values {
+			c.defaultHeaders.Add(key, value)
+		}
+	}
+	return nil
+}
+
 // signRequest configures whether to sign HTTP requests
 type signRequest struct {
 	Sign bool
@@ -119,6 +253,39 @@ func (w *signRequest) Configure(c *WalletClient) error {
 	return nil
 }
 
+// retryPolicyConf sets the retry policy of a WalletClient
+type retryPolicyConf struct {
+	Policy *RetryPolicy
+}
+
+func (w *retryPolicyConf) Configure(c *WalletClient) error {
+	c.retryPolicy = w.Policy
+	return nil
+}
+
+// draftVerificationConf enables the verification of draft transactions in SendToRecipients
+type draftVerificationConf struct {
+	Options *DraftVerificationOptions
+}
+
+func (w *draftVerificationConf) Configure(c *WalletClient) error {
+	c.draftVerification = w.Options
+	if c.draftVerification == nil {
+		c.draftVerification = &DraftVerificationOptions{}
+	}
+	return nil
+}
+
+// interceptorsConf registers interceptors on a WalletClient
+type interceptorsConf struct {
+	Interceptors []*Interceptor
+}
+
+func (w *interceptorsConf) Configure(c *WalletClient) error {
+	c.Use(w.Interceptors...)
+	return nil
+}
+
 // validateAndCleanURL ensures that the provided URL is valid, and strips it down to just the base URL.
 func validateAndCleanURL(rawURL string) (string, error) {
 	if rawURL == "" {
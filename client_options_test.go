@@ -1,6 +1,17 @@
 package walletclient
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet-go-client/fixtures"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
 
 func TestValidateAndCleanURL(t *testing.T) {
 	tests := []struct {
@@ -31,3 +42,79 @@ func TestValidateAndCleanURL(t *testing.T) {
 		})
 	}
 }
+
+func TestNewWithOptions(t *testing.T) {
+	t.Run("Should apply all options", func(t *testing.T) {
+		// given
+		var received *http.Request
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			_ = json.NewEncoder(w).Encode(fixtures.Xpub)
+		}))
+		defer server.Close()
+		httpClient := &http.Client{Timeout: 5 * time.Second}
+
+		// when
+		client, err := New(
+			server.URL,
+			WithXPriv(fixtures.XPrivString),
+			WithHTTPClient(httpClient),
+			WithBasePath("/api/v2/"),
+			WithDefaultHeaders(http.Header{"X-Trace-Id": []string{"trace"}}),
+			WithUserAgent(SPVWalletUserAgent),
+			WithRetryPolicy(DefaultRetryPolicy()),
+		)
+
+		// then
+		require.NoError(t, err)
+		require.Same(t, httpClient, client.httpClient)
+		require.Equal(t, server.URL+"/api/v2", client.server)
+		require.True(t, client.signRequest)
+		require.NotNil(t, client.retryPolicy)
+
+		_, err = client.GetXPub(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "/api/v2/xpub", received.URL.Path)
+		require.Equal(t, "trace", received.Header.Get("X-Trace-Id"))
+		require.Equal(t, SPVWalletUserAgent, received.Header.Get("User-Agent"))
+		require.NotEmpty(t, received.Header.Get(models.AuthSignature))
+	})
+
+	t.Run("Should use defaults without options", func(t *testing.T) {
+		// when
+		client, err := New("http://example.com/some/path")
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, "http://example.com/v1", client.server)
+		require.Equal(t, http.DefaultClient, client.httpClient)
+		require.True(t, client.signRequest)
+	})
+
+	t.Run("Should allow an empty base path", func(t *testing.T) {
+		// when
+		client, err := New("http://example.com", WithBasePath(""))
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, "http://example.com", client.server)
+	})
+
+	t.Run("Should fail on nil http client", func(t *testing.T) {
+		// when
+		client, err := New("http://example.com", WithHTTPClient(nil))
+
+		// then
+		require.ErrorIs(t, err, ErrInvalidHTTPClient)
+		require.Nil(t, client)
+	})
+
+	t.Run("Should fail on invalid key", func(t *testing.T) {
+		// when
+		client, err := New("http://example.com", WithXPub("invalid_key"))
+
+		// then
+		require.ErrorIs(t, err, ErrInvalidXpub)
+		require.Nil(t, client)
+	})
+}
@@ -0,0 +1,154 @@
+package walletclient
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// CoinSelectionStrategy the strategy used to pick the utxos funding a locally built transaction
+type CoinSelectionStrategy string
+
+const (
+	// CoinSelectionLargestFirst spends the biggest utxos first, minimizing the number of inputs
+	CoinSelectionLargestFirst CoinSelectionStrategy = "largest-first"
+
+	// CoinSelectionSmallestFirst spends the smallest utxos first, reducing the utxo set over time
+	CoinSelectionSmallestFirst CoinSelectionStrategy = "smallest-first"
+
+	// CoinSelectionBranchAndBound looks for a set of utxos matching the amount closely enough to avoid a change output;
+	// it falls back to CoinSelectionLargestFirst when no such set is found
+	CoinSelectionBranchAndBound CoinSelectionStrategy = "branch-and-bound"
+
+	// CoinSelectionConsolidate spends all the provided utxos
+	CoinSelectionConsolidate CoinSelectionStrategy = "consolidate"
+)
+
+// branchAndBoundMaxTries limits the number of combinations evaluated by the branch-and-bound strategy
+const branchAndBoundMaxTries = 100000
+
+// FeeEstimator returns the fee of a transaction spending the given number of inputs, with or without a change output.
+type FeeEstimator func(inputs int, withChange bool) uint64
+
+// CoinSelection is the result of selecting the utxos for a transaction
+type CoinSelection struct {
+	// Utxos are the selected utxos.
+	Utxos []*models.Utxo
+	// Fee is the fee paid by the transaction; without a change output it includes the leftover satoshis.
+	Fee uint64
+	// Change is the amount returned to the change output; zero means no change output.
+	Change uint64
+}
+
+// SelectCoins selects the utxos covering the target amount and the fee with the given strategy.
+// A change output is only created when it would hold at least minChange satoshis (and never less than 1).
+func SelectCoins(strategy CoinSelectionStrategy, utxos []*models.Utxo, target uint64, estimator FeeEstimator, minChange uint64) (*CoinSelection, error) {
+	minChange = max(minChange, 1)
+
+	switch strategy {
+	case CoinSelectionLargestFirst, "":
+		return accumulateCoins(sortUtxos(utxos, true), target, estimator, minChange)
+	case CoinSelectionSmallestFirst:
+		return accumulateCoins(sortUtxos(utxos, false), target, estimator, minChange)
+	case CoinSelectionBranchAndBound:
+		sorted := sortUtxos(utxos, true)
+		if selected := branchAndBound(sorted, target, estimator, minChange); selected != nil {
+			return finalizeSelection(selected, target, estimator, minChange)
+		}
+		return accumulateCoins(sorted, target, estimator, minChange)
+	case CoinSelectionConsolidate:
+		return finalizeSelection(utxos, target, estimator, minChange)
+	default:
+		return nil, ErrUnknownCoinSelectionStrategy
+	}
+}
+
+func sortUtxos(utxos []*models.Utxo, descending bool) []*models.Utxo {
+	sorted := slices.Clone(utxos)
+	slices.SortStableFunc(sorted, func(a, b *models.Utxo) int {
+		if descending {
+			return cmp.Compare(b.Satoshis, a.Satoshis)
+		}
+		return cmp.Compare(a.Satoshis, b.Satoshis)
+	})
+	return sorted
+}
+
+// accumulateCoins adds the utxos in the given order until they cover the target and the fee
+func accumulateCoins(utxos []*models.Utxo, target uint64, estimator FeeEstimator, minChange uint64) (*CoinSelection, error) {
+	var total uint64
+	for i, utxo := range utxos {
+		total += utxo.Satoshis
+		if total >= target+estimator(i+1, false) {
+			return finalizeSelection(utxos[:i+1], target, estimator, minChange)
+		}
+	}
+	return nil, ErrInsufficientFunds
+}
+
+// finalizeSelection computes the fee and change of the selected utxos
+func finalizeSelection(selected []*models.Utxo, target uint64, estimator FeeEstimator, minChange uint64) (*CoinSelection, error) {
+	if len(selected) == 0 {
+		return nil, ErrInsufficientFunds
+	}
+
+	var total uint64
+	for _, utxo := range selected {
+		total += utxo.Satoshis
+	}
+
+	inputs := len(selected)
+	if feeWithChange := estimator(inputs, true); total >= target+feeWithChange+minChange {
+		return &CoinSelection{Utxos: selected, Fee: feeWithChange, Change: total - target - feeWithChange}, nil
+	}
+	if total >= target+estimator(inputs, false) {
+		// the leftover is too small for a change output, so it is paid as a fee
+		return &CoinSelection{Utxos: selected, Fee: total - target}, nil
+	}
+	return nil, ErrInsufficientFunds
+}
+
+// branchAndBound searches (depth first) for the utxos whose total covers the target without creating a change output
+func branchAndBound(sorted []*models.Utxo, target uint64, estimator FeeEstimator, minChange uint64) []*models.Utxo {
+	remaining := make([]uint64, len(sorted)+1)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + sorted[i].Satoshis
+	}
+
+	var best []*models.Utxo
+	tries := 0
+
+	var search func(index int, selected []*models.Utxo, total uint64) bool
+	search = func(index int, selected []*models.Utxo, total uint64) bool {
+		tries++
+		if tries > branchAndBoundMaxTries {
+			return false
+		}
+
+		if inputs := len(selected); inputs > 0 {
+			low := target + estimator(inputs, false)
+			// above this bound it is cheaper to create a change output
+			high := target + estimator(inputs, true) + minChange
+			if total >= low && total < high {
+				best = slices.Clone(selected)
+				return true
+			}
+			if total >= high {
+				return false
+			}
+		}
+
+		if index == len(sorted) || total+remaining[index] < target {
+			return false
+		}
+
+		if search(index+1, append(selected, sorted[index]), total+sorted[index].Satoshis) {
+			return true
+		}
+		return search(index+1, selected, total)
+	}
+
+	search(0, make([]*models.Utxo, 0, len(sorted)), 0)
+	return best
+}
@@ -0,0 +1,89 @@
+package walletclient
+
+import (
+	"testing"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+func utxosWithSatoshis(satoshis ...uint64) []*models.Utxo {
+	utxos := make([]*models.Utxo, 0, len(satoshis))
+	for i, amount := range satoshis {
+		utxos = append(utxos, &models.Utxo{UtxoPointer: models.UtxoPointer{OutputIndex: uint32(i)}, Satoshis: amount})
+	}
+	return utxos
+}
+
+func selectedSatoshis(selection *CoinSelection) []uint64 {
+	satoshis := make([]uint64, 0, len(selection.Utxos))
+	for _, utxo := range selection.Utxos {
+		satoshis = append(satoshis, utxo.Satoshis)
+	}
+	return satoshis
+}
+
+// flatFeeEstimator charges 1 satoshi per input and 1 satoshi for the change output
+func flatFeeEstimator(inputs int, withChange bool) uint64 {
+	fee := uint64(inputs)
+	if withChange {
+		fee++
+	}
+	return fee
+}
+
+func TestSelectCoins(t *testing.T) {
+	utxos := utxosWithSatoshis(50, 500, 20, 200, 1000)
+
+	tests := []struct {
+		name             string
+		strategy         CoinSelectionStrategy
+		target           uint64
+		expectedSatoshis []uint64
+		expectedFee      uint64
+		expectedChange   uint64
+	}{
+		{"Largest first", CoinSelectionLargestFirst, 1200, []uint64{1000, 500}, 3, 297},
+		{"Default strategy is largest first", "", 600, []uint64{1000}, 2, 398},
+		{"Smallest first", CoinSelectionSmallestFirst, 250, []uint64{20, 50, 200}, 4, 16},
+		{"Branch and bound finds a changeless set", CoinSelectionBranchAndBound, 698, []uint64{500, 200}, 2, 0},
+		{"Branch and bound falls back to largest first", CoinSelectionBranchAndBound, 1300, []uint64{1000, 500}, 3, 197},
+		{"Consolidate spends everything", CoinSelectionConsolidate, 100, []uint64{50, 500, 20, 200, 1000}, 6, 1664},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			selection, err := SelectCoins(tt.strategy, utxos, tt.target, flatFeeEstimator, 1)
+
+			// then
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedSatoshis, selectedSatoshis(selection))
+			require.Equal(t, tt.expectedFee, selection.Fee)
+			require.Equal(t, tt.expectedChange, selection.Change)
+		})
+	}
+
+	t.Run("Should pay a leftover below the minimal change as a fee", func(t *testing.T) {
+		// when
+		selection, err := SelectCoins(CoinSelectionLargestFirst, utxos, 990, flatFeeEstimator, 100)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1000}, selectedSatoshis(selection))
+		require.Equal(t, uint64(10), selection.Fee)
+		require.Zero(t, selection.Change)
+	})
+
+	t.Run("Should fail on insufficient funds", func(t *testing.T) {
+		for _, strategy := range []CoinSelectionStrategy{CoinSelectionLargestFirst, CoinSelectionSmallestFirst, CoinSelectionBranchAndBound, CoinSelectionConsolidate} {
+			_, err := SelectCoins(strategy, utxos, 1770, flatFeeEstimator, 1)
+			require.ErrorIs(t, err, ErrInsufficientFunds, strategy)
+		}
+	})
+
+	t.Run("Should fail on unknown strategy", func(t *testing.T) {
+		_, err := SelectCoins("random", utxos, 100, flatFeeEstimator, 1)
+		require.ErrorIs(t, err, ErrUnknownCoinSelectionStrategy)
+	})
+}
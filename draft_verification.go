@@ -0,0 +1,311 @@
+package walletclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bip32 "github.com/bitcoin-sv/go-sdk/compat/bip32"
+	script "github.com/bitcoin-sv/go-sdk/script"
+	trx "github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/template/p2pkh"
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// DraftDiscrepancyType the kind of mismatch found while verifying a draft transaction
+type DraftDiscrepancyType string
+
+const (
+	// DraftDiscrepancyMissingOutput is when a requested recipient has no matching output
+	DraftDiscrepancyMissingOutput DraftDiscrepancyType = "missing-output"
+
+	// DraftDiscrepancyUnexpectedOutput is when an output is neither a recipient nor a change of the xPub
+	DraftDiscrepancyUnexpectedOutput DraftDiscrepancyType = "unexpected-output"
+
+	// DraftDiscrepancyInvalidChange is when a change destination does not derive from the xPub
+	DraftDiscrepancyInvalidChange DraftDiscrepancyType = "invalid-change"
+
+	// DraftDiscrepancyInvalidRecipient is when a recipient cannot be resolved to a locking script
+	DraftDiscrepancyInvalidRecipient DraftDiscrepancyType = "invalid-recipient"
+
+	// DraftDiscrepancyUnverifiedOutput is when an output pays a paymail recipient with a locking script which could not be
+	// verified, because no PaymailResolver is configured
+	DraftDiscrepancyUnverifiedOutput DraftDiscrepancyType = "unverified-output"
+
+	// DraftDiscrepancyFee is when the implied fee is negative or exceeds the ceiling
+	DraftDiscrepancyFee DraftDiscrepancyType = "fee"
+)
+
+// PaymailResolver resolves a paymail recipient to the outputs paying it, independently of the spv-wallet,
+// e.g. through the paymail capabilities of its host
+type PaymailResolver func(ctx context.Context, paymail string, satoshis uint64) ([]*models.ScriptOutput, error)
+
+// DraftVerificationOptions configures the verification of draft transactions
+type DraftVerificationOptions struct {
+	// MaxFee is the highest fee (in satoshis) accepted for a draft; zero disables the check.
+	MaxFee uint64
+	// PaymailResolver resolves the paymail recipients; without it the outputs paying paymails are reported
+	// as DraftDiscrepancyUnverifiedOutput, since the scripts resolved by the spv-wallet cannot be trusted.
+	PaymailResolver PaymailResolver
+}
+
+// DraftDiscrepancy describes a single mismatch between the draft and the requested recipients
+type DraftDiscrepancy struct {
+	// Type is the kind of the discrepancy.
+	Type DraftDiscrepancyType
+	// OutputIndex is the index of the related transaction output, -1 when not applicable.
+	OutputIndex int
+	// Message is a human-readable description of the discrepancy.
+	Message string
+}
+
+// DraftVerificationReport is the result of verifying a draft transaction
+type DraftVerificationReport struct {
+	// Discrepancies lists every mismatch found; an empty list means the draft is valid.
+	Discrepancies []DraftDiscrepancy
+	// InputSatoshis is the total amount of the draft inputs.
+	InputSatoshis uint64
+	// OutputSatoshis is the total amount of the draft outputs.
+	OutputSatoshis uint64
+	// ChangeSatoshis is the amount returned to the xPub.
+	ChangeSatoshis uint64
+	// Fee is the fee implied by the inputs and outputs.
+	Fee uint64
+}
+
+// Valid reports whether no discrepancies were found
+func (r *DraftVerificationReport) Valid() bool {
+	return len(r.Discrepancies) == 0
+}
+
+// Err returns nil for a valid report, otherwise an error wrapping ErrDraftVerificationFailed with all the discrepancies
+func (r *DraftVerificationReport) Err() error {
+	if r.Valid() {
+		return nil
+	}
+
+	messages := make([]string, 0, len(r.Discrepancies))
+	for _, discrepancy := range r.Discrepancies {
+		messages = append(messages, discrepancy.Message)
+	}
+	return ErrDraftVerificationFailed.Wrap(fmt.Errorf("%s", strings.Join(messages, "; ")))
+}
+
+func (r *DraftVerificationReport) add(discrepancyType DraftDiscrepancyType, outputIndex int, format string, args ...any) {
+	r.Discrepancies = append(r.Discrepancies, DraftDiscrepancy{
+		Type:        discrepancyType,
+		OutputIndex: outputIndex,
+		Message:     fmt.Sprintf(format, args...),
+	})
+}
+
+// expectedOutput is an output which the draft must contain for a recipient
+type expectedOutput struct {
+	recipient     string
+	lockingScript string
+	satoshis      uint64
+	// unverified is set for the outputs of paymail recipients resolved by the spv-wallet
+	unverified bool
+}
+
+// VerifyDraft checks the draft transaction returned by the server against the requested recipients before it is signed.
+// It decodes the draft hex and verifies that every recipient is paid, that all other outputs are change outputs
+// deriving from the client xPub and that the implied fee does not exceed the configured ceiling.
+// Paymail recipients are matched with the locking scripts returned by the PaymailResolver of the options.
+func (wc *WalletClient) VerifyDraft(ctx context.Context, draft *models.DraftTransaction, recipients []*Recipients, opts *DraftVerificationOptions) (*DraftVerificationReport, error) {
+	xPub, err := wc.extendedPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	if draft == nil {
+		return nil, WrapError(ErrCouldNotFindDraftTransaction)
+	}
+	if opts == nil {
+		opts = &DraftVerificationOptions{}
+	}
+
+	tx, err := trx.NewTransactionFromHex(draft.Hex)
+	if err != nil {
+		return nil, WrapError(ErrInvalidDraftHex.Wrap(err))
+	}
+
+	report := &DraftVerificationReport{}
+	matched := make([]bool, len(tx.Outputs))
+
+	for _, expected := range expectedDraftOutputs(ctx, draft, recipients, opts.PaymailResolver, report) {
+		index := findOutput(tx, matched, expected.lockingScript, expected.satoshis)
+		if index < 0 {
+			report.add(DraftDiscrepancyMissingOutput, -1, "no output pays %d satoshis to %s", expected.satoshis, expected.recipient)
+			continue
+		}
+		matched[index] = true
+		if expected.unverified {
+			report.add(DraftDiscrepancyUnverifiedOutput, index, "output %d paying %d satoshis to %s cannot be verified without a paymail resolver", index, expected.satoshis, expected.recipient)
+		}
+	}
+
+	changeDestinations := make(map[string]*models.Destination, len(draft.Configuration.ChangeDestinations))
+	for _, destination := range draft.Configuration.ChangeDestinations {
+		changeDestinations[destination.LockingScript] = destination
+	}
+
+	for index, output := range tx.Outputs {
+		report.OutputSatoshis += output.Satoshis
+		if matched[index] {
+			continue
+		}
+
+		lockingScript := output.LockingScript.String()
+		destination, ok := changeDestinations[lockingScript]
+		if !ok {
+			report.add(DraftDiscrepancyUnexpectedOutput, index, "output %d pays %d satoshis to an unknown locking script %s", index, output.Satoshis, lockingScript)
+			continue
+		}
+		if derived, err := derivedLockingScript(xPub, destination); err != nil || derived != lockingScript {
+			report.add(DraftDiscrepancyInvalidChange, index, "change output %d does not derive from the xpub at %d/%d", index, destination.Chain, destination.Num)
+			continue
+		}
+		report.ChangeSatoshis += output.Satoshis
+	}
+
+	for _, input := range draft.Configuration.Inputs {
+		report.InputSatoshis += input.Satoshis
+	}
+	if report.OutputSatoshis > report.InputSatoshis {
+		report.add(DraftDiscrepancyFee, -1, "outputs (%d satoshis) exceed inputs (%d satoshis)", report.OutputSatoshis, report.InputSatoshis)
+	} else {
+		report.Fee = report.InputSatoshis - report.OutputSatoshis
+		if opts.MaxFee > 0 && report.Fee > opts.MaxFee {
+			report.add(DraftDiscrepancyFee, -1, "fee of %d satoshis exceeds the ceiling of %d satoshis", report.Fee, opts.MaxFee)
+		}
+	}
+
+	return report, nil
+}
+
+// expectedDraftOutputs resolves the recipients to the outputs which the draft must contain
+func expectedDraftOutputs(ctx context.Context, draft *models.DraftTransaction, recipients []*Recipients, resolver PaymailResolver, report *DraftVerificationReport) []expectedOutput {
+	expected := make([]expectedOutput, 0, len(recipients))
+	for _, recipient := range recipients {
+		if recipient.OpReturn == nil && strings.Contains(recipient.To, "@") {
+			if resolver == nil {
+				expected = append(expected, paymailDraftOutputs(draft, recipient, report)...)
+			} else {
+				expected = append(expected, resolvedPaymailOutputs(ctx, resolver, recipient, report)...)
+			}
+			continue
+		}
+
+		lockingScript, err := recipientLockingScript(recipient)
+		if err != nil {
+			report.add(DraftDiscrepancyInvalidRecipient, -1, "recipient %s cannot be resolved: %s", recipient.To, err.Error())
+			continue
+		}
+		expected = append(expected, expectedOutput{
+			recipient:     recipientName(recipient),
+			lockingScript: lockingScript.String(),
+			satoshis:      recipient.Satoshis,
+		})
+	}
+	return expected
+}
+
+// resolvedPaymailOutputs returns the outputs resolved by the PaymailResolver for a paymail recipient
+func resolvedPaymailOutputs(ctx context.Context, resolver PaymailResolver, recipient *Recipients, report *DraftVerificationReport) []expectedOutput {
+	scriptOutputs, err := resolver(ctx, recipient.To, recipient.Satoshis)
+	if err != nil {
+		report.add(DraftDiscrepancyInvalidRecipient, -1, "paymail %s cannot be resolved: %s", recipient.To, err.Error())
+		return nil
+	}
+	return scriptDraftOutputs(recipient, scriptOutputs, false, report)
+}
+
+// paymailDraftOutputs returns the outputs resolved by the server for a paymail recipient, marked as unverified
+func paymailDraftOutputs(draft *models.DraftTransaction, recipient *Recipients, report *DraftVerificationReport) []expectedOutput {
+	for _, output := range draft.Configuration.Outputs {
+		if output.To != recipient.To || output.Satoshis != recipient.Satoshis {
+			continue
+		}
+
+		return scriptDraftOutputs(recipient, output.Scripts, true, report)
+	}
+
+	report.add(DraftDiscrepancyMissingOutput, -1, "no output is configured for paymail %s with %d satoshis", recipient.To, recipient.Satoshis)
+	return nil
+}
+
+// scriptDraftOutputs returns the outputs of the scripts resolved for a paymail recipient, which must pay its whole amount
+func scriptDraftOutputs(recipient *Recipients, scriptOutputs []*models.ScriptOutput, unverified bool, report *DraftVerificationReport) []expectedOutput {
+	var expected []expectedOutput
+	var total uint64
+	for _, scriptOutput := range scriptOutputs {
+		total += scriptOutput.Satoshis
+		expected = append(expected, expectedOutput{
+			recipient:     recipient.To,
+			lockingScript: scriptOutput.Script,
+			satoshis:      scriptOutput.Satoshis,
+			unverified:    unverified,
+		})
+	}
+	if total != recipient.Satoshis {
+		report.add(DraftDiscrepancyInvalidRecipient, -1, "scripts resolved for %s pay %d satoshis instead of %d", recipient.To, total, recipient.Satoshis)
+		return nil
+	}
+	return expected
+}
+
+func recipientName(recipient *Recipients) string {
+	if recipient.OpReturn != nil {
+		return "op_return"
+	}
+	return recipient.To
+}
+
+// findOutput returns the index of the first not yet matched output with the given locking script and satoshis, or -1
+func findOutput(tx *trx.Transaction, matched []bool, lockingScript string, satoshis uint64) int {
+	for index, output := range tx.Outputs {
+		if !matched[index] && output.Satoshis == satoshis && output.LockingScript.String() == lockingScript {
+			return index
+		}
+	}
+	return -1
+}
+
+// derivedLockingScript returns the P2PKH locking script of the destination derived from the xPub
+func derivedLockingScript(xPub *bip32.ExtendedKey, destination *models.Destination) (string, error) {
+	key, err := bip32.GetHDKeyByPath(xPub, destination.Chain, destination.Num)
+	if err != nil {
+		return "", err
+	}
+	if destination.PaymailExternalDerivationNum != nil {
+		if key, err = key.Child(*destination.PaymailExternalDerivationNum); err != nil {
+			return "", err
+		}
+	}
+
+	address, err := bip32.GetAddressFromHDKey(key)
+	if err != nil {
+		return "", err
+	}
+	var lockingScript *script.Script
+	if lockingScript, err = p2pkh.Lock(address); err != nil {
+		return "", err
+	}
+	return lockingScript.String(), nil
+}
+
+// extendedPublicKey returns the xPub of the client, derived from the xPriv if needed
+func (wc *WalletClient) extendedPublicKey() (*bip32.ExtendedKey, error) {
+	if wc.xPub != nil {
+		return wc.xPub, nil
+	}
+	if wc.xPriv == nil {
+		return nil, WrapError(ErrMissingXpriv)
+	}
+
+	xPub, err := wc.xPriv.Neuter()
+	if err != nil {
+		return nil, WrapError(err)
+	}
+	return xPub, nil
+}
@@ -0,0 +1,199 @@
+package walletclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	trx "github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/spv-wallet-go-client/fixtures"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+// testDraft creates a draft spending a 5000 satoshis input into the given outputs
+func testDraft(t *testing.T, changeDestinations []*models.Destination, outputs ...*trx.TransactionOutput) *models.DraftTransaction {
+	input := testDestination(t, 0, 0)
+	tx := trx.NewTransaction()
+	require.NoError(t, tx.AddInputFrom(fixtures.Transaction.ID, 0, input.LockingScript, 5000, nil))
+	for _, output := range outputs {
+		tx.AddOutput(output)
+	}
+
+	return &models.DraftTransaction{
+		ID:  "draft-id",
+		Hex: tx.String(),
+		Configuration: models.TransactionConfig{
+			ChangeDestinations: changeDestinations,
+			Inputs: []*models.TransactionInput{
+				{Utxo: models.Utxo{Satoshis: 5000}, Destination: *input},
+			},
+		},
+	}
+}
+
+func testOutput(t *testing.T, destination *models.Destination, satoshis uint64) *trx.TransactionOutput {
+	return &trx.TransactionOutput{Satoshis: satoshis, LockingScript: mustLockingScript(t, destination)}
+}
+
+func TestVerifyDraft(t *testing.T) {
+	client, err := NewWithXPriv(fixtures.ServerURL, fixtures.XPrivString)
+	require.NoError(t, err)
+
+	recipient := testDestination(t, 0, 1)
+	change := testDestination(t, 1, 0)
+	recipients := []*Recipients{{To: recipient.Address, Satoshis: 3000}}
+
+	t.Run("Should accept a valid draft", func(t *testing.T) {
+		// given
+		draft := testDraft(t, []*models.Destination{change}, testOutput(t, recipient, 3000), testOutput(t, change, 1990))
+
+		// when
+		report, err := client.VerifyDraft(context.Background(), draft, recipients, &DraftVerificationOptions{MaxFee: 10})
+
+		// then
+		require.NoError(t, err)
+		require.True(t, report.Valid(), report.Discrepancies)
+		require.NoError(t, report.Err())
+		require.Equal(t, uint64(10), report.Fee)
+		require.Equal(t, uint64(1990), report.ChangeSatoshis)
+	})
+
+	t.Run("Should detect redirected funds", func(t *testing.T) {
+		// given
+		attacker := testDestination(t, 5, 5)
+		draft := testDraft(t, nil, testOutput(t, attacker, 3000))
+
+		// when
+		report, err := client.VerifyDraft(context.Background(), draft, recipients, nil)
+
+		// then
+		require.NoError(t, err)
+		require.False(t, report.Valid())
+		require.Equal(t, DraftDiscrepancyMissingOutput, report.Discrepancies[0].Type)
+		require.Equal(t, DraftDiscrepancyUnexpectedOutput, report.Discrepancies[1].Type)
+		require.Equal(t, 0, report.Discrepancies[1].OutputIndex)
+		require.ErrorIs(t, report.Err(), ErrDraftVerificationFailed)
+	})
+
+	t.Run("Should detect change not deriving from the xpub", func(t *testing.T) {
+		// given
+		fakeChange := *testDestination(t, 1, 7)
+		fakeChange.Num = 0
+		draft := testDraft(t, []*models.Destination{&fakeChange}, testOutput(t, recipient, 3000), testOutput(t, &fakeChange, 1990))
+
+		// when
+		report, err := client.VerifyDraft(context.Background(), draft, recipients, nil)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, report.Discrepancies, 1)
+		require.Equal(t, DraftDiscrepancyInvalidChange, report.Discrepancies[0].Type)
+	})
+
+	t.Run("Should detect fee above the ceiling", func(t *testing.T) {
+		// given
+		draft := testDraft(t, nil, testOutput(t, recipient, 3000))
+
+		// when
+		report, err := client.VerifyDraft(context.Background(), draft, recipients, &DraftVerificationOptions{MaxFee: 100})
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, report.Discrepancies, 1)
+		require.Equal(t, DraftDiscrepancyFee, report.Discrepancies[0].Type)
+		require.Equal(t, uint64(2000), report.Fee)
+	})
+
+	t.Run("Should match paymail recipients with the scripts of the resolver", func(t *testing.T) {
+		// given
+		draft := testDraft(t, nil, testOutput(t, recipient, 3000))
+		resolver := func(_ context.Context, paymail string, satoshis uint64) ([]*models.ScriptOutput, error) {
+			require.Equal(t, fixtures.PaymailAddress, paymail)
+			return []*models.ScriptOutput{{Script: recipient.LockingScript, Satoshis: satoshis}}, nil
+		}
+
+		// when
+		report, err := client.VerifyDraft(context.Background(), draft, []*Recipients{{To: fixtures.PaymailAddress, Satoshis: 3000}}, &DraftVerificationOptions{PaymailResolver: resolver})
+
+		// then
+		require.NoError(t, err)
+		require.True(t, report.Valid(), report.Discrepancies)
+	})
+
+	t.Run("Should detect paymail funds redirected by the server", func(t *testing.T) {
+		// given
+		attacker := testDestination(t, 5, 5)
+		draft := testDraft(t, nil, testOutput(t, attacker, 3000))
+		draft.Configuration.Outputs = []*models.TransactionOutput{{
+			To:       fixtures.PaymailAddress,
+			Satoshis: 3000,
+			Scripts:  []*models.ScriptOutput{{Script: attacker.LockingScript, Satoshis: 3000}},
+		}}
+		resolver := func(_ context.Context, _ string, satoshis uint64) ([]*models.ScriptOutput, error) {
+			return []*models.ScriptOutput{{Script: recipient.LockingScript, Satoshis: satoshis}}, nil
+		}
+
+		// when
+		report, err := client.VerifyDraft(context.Background(), draft, []*Recipients{{To: fixtures.PaymailAddress, Satoshis: 3000}}, &DraftVerificationOptions{PaymailResolver: resolver})
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, DraftDiscrepancyMissingOutput, report.Discrepancies[0].Type)
+		require.Equal(t, DraftDiscrepancyUnexpectedOutput, report.Discrepancies[1].Type)
+	})
+
+	t.Run("Should report paymail outputs as unverified without a resolver", func(t *testing.T) {
+		// given
+		draft := testDraft(t, nil, testOutput(t, recipient, 3000))
+		draft.Configuration.Outputs = []*models.TransactionOutput{{
+			To:       fixtures.PaymailAddress,
+			Satoshis: 3000,
+			Scripts:  []*models.ScriptOutput{{Script: recipient.LockingScript, Satoshis: 3000}},
+		}}
+
+		// when
+		report, err := client.VerifyDraft(context.Background(), draft, []*Recipients{{To: fixtures.PaymailAddress, Satoshis: 3000}}, nil)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, report.Discrepancies, 1)
+		require.Equal(t, DraftDiscrepancyUnverifiedOutput, report.Discrepancies[0].Type)
+		require.Equal(t, 0, report.Discrepancies[0].OutputIndex)
+	})
+
+	t.Run("Should fail on invalid hex", func(t *testing.T) {
+		_, err := client.VerifyDraft(context.Background(), &models.DraftTransaction{Hex: "zz"}, recipients, nil)
+		require.ErrorIs(t, err, ErrInvalidDraftHex)
+	})
+
+	t.Run("SendToRecipients should refuse an invalid draft", func(t *testing.T) {
+		// given
+		attacker := testDestination(t, 5, 5)
+		draft := testDraft(t, nil, testOutput(t, attacker, 3000))
+		recorded := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v1/transaction":
+				w.Write([]byte(fixtures.MarshallForTestHandler(draft)))
+			case "/v1/transaction/record":
+				recorded = true
+				w.Write([]byte(fixtures.MarshallForTestHandler(fixtures.Transaction)))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		client, err := New(server.URL, WithXPriv(fixtures.XPrivString), WithDraftVerification(nil))
+		require.NoError(t, err)
+
+		// when
+		_, err = client.SendToRecipients(context.Background(), recipients, nil)
+
+		// then
+		require.ErrorIs(t, err, ErrDraftVerificationFailed)
+		require.False(t, recorded)
+	})
+}
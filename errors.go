@@ -1,9 +1,14 @@
 package walletclient
 
 import (
+	"context"
 	"encoding/json"
-	"github.com/bitcoin-sv/spv-wallet/models"
+	"errors"
+	"io"
+	"net"
 	"net/http"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
 )
 
 // ErrAdminKey admin key not set
@@ -27,6 +32,30 @@ var ErrInvalidAdminKey = models.SPVError{Message: "admin key is invalid", Status
 // ErrInvalidServerURL is when server url is invalid
 var ErrInvalidServerURL = models.SPVError{Message: "server url is invalid", StatusCode: 401, Code: "error-unauthorized-server-url-invalid"}
 
+// ErrInvalidHTTPClient is when the provided http client is nil
+var ErrInvalidHTTPClient = models.SPVError{Message: "http client is invalid", StatusCode: 500, Code: "error-http-client-invalid"}
+
+// ErrInvalidTransportHandler is when the handler of the mock transport is nil
+var ErrInvalidTransportHandler = models.SPVError{Message: "transport handler is invalid", StatusCode: 500, Code: "error-transport-handler-invalid"}
+
+// ErrMissingAuthHeader is when a request has neither the xpub nor the access key auth header
+var ErrMissingAuthHeader = models.SPVError{Message: "missing auth header", StatusCode: 401, Code: "error-unauthorized-auth-header-missing"}
+
+// ErrMissingSignature is when a request has no auth signature
+var ErrMissingSignature = models.SPVError{Message: "missing signature", StatusCode: 401, Code: "error-unauthorized-signature-missing"}
+
+// ErrAuthHashMismatch is when the auth hash header does not match the hash of the request body
+var ErrAuthHashMismatch = models.SPVError{Message: "auth hash does not match the body", StatusCode: 401, Code: "error-unauthorized-auth-hash-mismatch"}
+
+// ErrSignatureExpired is when the auth time of a request is outside of the allowed window
+var ErrSignatureExpired = models.SPVError{Message: "signature has expired", StatusCode: 401, Code: "error-unauthorized-signature-expired"}
+
+// ErrInvalidSignature is when the auth signature does not match the xpub or the access key of a request
+var ErrInvalidSignature = models.SPVError{Message: "signature is invalid", StatusCode: 401, Code: "error-unauthorized-signature-invalid"}
+
+// ErrNonceReplayed is when the auth nonce of a request has already been used
+var ErrNonceReplayed = models.SPVError{Message: "auth nonce has already been used", StatusCode: 401, Code: "error-unauthorized-nonce-replayed"}
+
 // ErrCreateClient is when client creation fails
 var ErrCreateClient = models.SPVError{Message: "failed to create client", StatusCode: 500, Code: "error-create-client-failed"}
 
@@ -45,28 +74,136 @@ var ErrTotpInvalid = models.SPVError{Message: "totp is invalid", StatusCode: 400
 // ErrContactPubKeyInvalid is when contact's PubKey is invalid
 var ErrContactPubKeyInvalid = models.SPVError{Message: "contact's PubKey is invalid", StatusCode: 400, Code: "error-contact-pubkey-invalid"}
 
+// ErrMissingRecipients is when a transaction is built without any recipients
+var ErrMissingRecipients = models.SPVError{Message: "at least one recipient is required", StatusCode: 400, Code: "error-recipients-missing"}
+
+// ErrInvalidRecipient is when a recipient cannot be converted into a locking script
+var ErrInvalidRecipient = models.SPVError{Message: "recipient is invalid", StatusCode: 400, Code: "error-recipient-invalid"}
+
+// ErrPaymailRecipientNotSupported is when a paymail recipient is used to build a transaction locally
+var ErrPaymailRecipientNotSupported = models.SPVError{Message: "paymail recipients cannot be resolved when building a transaction locally", StatusCode: 400, Code: "error-recipient-paymail-not-supported"}
+
+// ErrInsufficientFunds is when the utxos do not cover the outputs and the fee
+var ErrInsufficientFunds = models.SPVError{Message: "not enough funds to cover the outputs and the fee", StatusCode: 400, Code: "error-insufficient-funds"}
+
+// ErrUnknownCoinSelectionStrategy is when the coin selection strategy is not supported
+var ErrUnknownCoinSelectionStrategy = models.SPVError{Message: "unknown coin selection strategy", StatusCode: 400, Code: "error-coin-selection-strategy-unknown"}
+
+// ErrInvalidDraftHex is when the hex of a draft transaction cannot be decoded
+var ErrInvalidDraftHex = models.SPVError{Message: "draft transaction hex is invalid", StatusCode: 500, Code: "error-draft-transaction-hex-invalid"}
+
+// ErrDraftVerificationFailed is when a draft transaction does not match the requested recipients
+var ErrDraftVerificationFailed = models.SPVError{Message: "draft transaction verification failed", StatusCode: 500, Code: "error-draft-transaction-verification-failed"}
+
+// ErrInvalidBEEF is when a transaction cannot be decoded as BEEF
+var ErrInvalidBEEF = models.SPVError{Message: "BEEF transaction is invalid", StatusCode: 400, Code: "error-beef-invalid"}
+
+// ErrInvalidUnlockingScript is when an input of an unmined transaction does not unlock the output it spends
+var ErrInvalidUnlockingScript = models.SPVError{Message: "unlocking script of the transaction is invalid", StatusCode: 400, Code: "error-unlocking-script-invalid"}
+
+// ErrOutputsExceedInputs is when an unmined transaction spends more satoshis than its inputs hold
+var ErrOutputsExceedInputs = models.SPVError{Message: "transaction outputs exceed its inputs", StatusCode: 400, Code: "error-outputs-exceed-inputs"}
+
+// ErrInvalidTransactionHex is when a raw transaction cannot be decoded
+var ErrInvalidTransactionHex = models.SPVError{Message: "transaction hex is invalid", StatusCode: 400, Code: "error-transaction-hex-invalid"}
+
+// ErrInvalidMerklePath is when a merkle path cannot be decoded or does not contain the transaction
+var ErrInvalidMerklePath = models.SPVError{Message: "merkle path is invalid", StatusCode: 400, Code: "error-merkle-path-invalid"}
+
+// ErrMissingMerklePath is when a transaction and none of its ancestors up to the mined ones come with a merkle path
+var ErrMissingMerklePath = models.SPVError{Message: "transaction has no merkle path to verify", StatusCode: 400, Code: "error-merkle-path-missing"}
+
+// ErrMerkleRootNotFound is when no merkle root is synced at the block height of a merkle path
+var ErrMerkleRootNotFound = models.SPVError{Message: "no merkle root is synced at the block height", StatusCode: 404, Code: "error-merkle-root-not-found"}
+
+// ErrMerkleRootMismatch is when the merkle root computed from a merkle path differs from the synced one at its block height
+var ErrMerkleRootMismatch = models.SPVError{Message: "merkle root of the merkle path does not match the synced one", StatusCode: 400, Code: "error-merkle-root-mismatch"}
+
 // ErrStaleLastEvaluatedKey is when the last evaluated key returned from sync merkleroots is the same as it was in a previous iteration
 // indicating sync issue or a potential loop
 var ErrStaleLastEvaluatedKey = models.SPVError{Message: "The last evaluated key has not changed between requests, indicating a possible loop or synchronization issue.", StatusCode: 500, Code: "error-stale-last-evaluated-key"}
 
-// ErrStaleLastEvaluatedKey is when the last evaluated key returned from sync merkleroots is the same as it was in a previous iteration
-// indicating sync issue or a potential loop
+// ErrSyncMerkleRootsTimeout is when the deadline of the context passed to SyncMerkleRoots is exceeded
 var ErrSyncMerkleRootsTimeout = models.SPVError{Message: "SyncMerkleRoots operation timed out", StatusCode: 500, Code: "error-sync-merkleroots-timeout"}
 
-// WrapError wraps an error into SPVError
+// ErrSyncMerkleRootsCanceled is when the context passed to SyncMerkleRoots is canceled
+var ErrSyncMerkleRootsCanceled = models.SPVError{Message: "SyncMerkleRoots operation was canceled", StatusCode: 500, Code: "error-sync-merkleroots-canceled"}
+
+// ClientError is the base of the typed errors returned by the client for the failed requests. It exposes the code and
+// the status of the SPVError, and unwraps to both the SPVError and the cause, so errors.Is and errors.As match either of them,
+// e.g. errors.Is(err, context.DeadlineExceeded). The category of a failure is told by errors.As with one of the typed errors;
+// a *ClientError itself is a failure of no known category.
+type ClientError struct {
+	models.SPVError
+	// Err is the cause of the error, e.g. the error of the http client; nil for the errors returned by the spv-wallet.
+	Err error
+}
+
+// Unwrap returns the SPVError and the cause of the error
+func (e *ClientError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.SPVError}
+	}
+	return []error{e.SPVError, e.Err}
+}
+
+func (e *ClientError) clientError() *ClientError {
+	return e
+}
+
+// TransportError is when the request could not be sent to the spv-wallet or its response could not be read
+type TransportError struct{ ClientError }
+
+// AuthError is when the spv-wallet or the client rejects the credentials of the request (401 or 403)
+type AuthError struct{ ClientError }
+
+// NotFoundError is when the requested resource does not exist (404)
+type NotFoundError struct{ ClientError }
+
+// ValidationError is when the spv-wallet or the client rejects the request as invalid (the other 4xx statuses)
+type ValidationError struct{ ClientError }
+
+// ServerError is when the spv-wallet fails to handle the request (5xx statuses)
+type ServerError struct{ ClientError }
+
+// TimeoutError is when the deadline of the request is exceeded
+type TimeoutError struct{ ClientError }
+
+// CanceledError is when the context of the request is canceled
+type CanceledError struct{ ClientError }
+
+// WrapError wraps an error into the typed error of its category, keeping the error as its cause;
+// errors which are already typed are returned as they are
 func WrapError(err error) error {
 	if err == nil {
 		return nil
 	}
 
-	return models.SPVError{
+	var typed interface{ clientError() *ClientError }
+	if errors.As(err, &typed) {
+		return err
+	}
+
+	var spvErr models.SPVError
+	if errors.As(err, &spvErr) {
+		if spvErr.StatusCode >= http.StatusInternalServerError {
+			return newCauseError(spvErr, spvErr.Unwrap())
+		}
+		return newStatusError(spvErr, nil)
+	}
+
+	unknown := models.SPVError{
 		StatusCode: http.StatusInternalServerError,
 		Message:    err.Error(),
 		Code:       models.UnknownErrorCode,
 	}
+	if typed := causeTypedError(unknown, err); typed != nil {
+		return typed
+	}
+	return &ClientError{SPVError: unknown, Err: err}
 }
 
-// WrapResponseError wraps a http response into SPVError
+// WrapResponseError wraps a http response into the typed error of its status
 func WrapResponseError(res *http.Response) error {
 	if res == nil {
 		return nil
@@ -76,13 +213,62 @@ func WrapResponseError(res *http.Response) error {
 
 	err := json.NewDecoder(res.Body).Decode(&resError)
 	if err != nil {
-		return WrapError(err)
+		spvErr := models.SPVError{StatusCode: res.StatusCode, Code: models.UnknownErrorCode, Message: res.Status}
+		return newStatusError(spvErr.Wrap(err), nil)
 	}
 
-	return models.SPVError{
+	return newStatusError(models.SPVError{
 		StatusCode: res.StatusCode,
 		Code:       resError.Code,
 		Message:    resError.Message,
+	}, nil)
+}
+
+// newStatusError returns the typed error of the status of the SPVError, with the cause
+func newStatusError(spvErr models.SPVError, cause error) error {
+	base := ClientError{SPVError: spvErr, Err: cause}
+	switch status := spvErr.StatusCode; {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return &AuthError{base}
+	case status == http.StatusNotFound:
+		return &NotFoundError{base}
+	case status >= http.StatusBadRequest && status < http.StatusInternalServerError:
+		return &ValidationError{base}
+	case status >= http.StatusInternalServerError:
+		return &ServerError{base}
+	default:
+		return &base
+	}
+}
+
+// newCauseError returns the typed error of the cause of the SPVError,
+// or the typed error of its status when the cause is of no known category
+func newCauseError(spvErr models.SPVError, cause error) error {
+	if typed := causeTypedError(spvErr, cause); typed != nil {
+		return typed
+	}
+	return newStatusError(spvErr, cause)
+}
+
+// causeTypedError returns the typed error of the cause, nil when the cause is of no known category
+func causeTypedError(spvErr models.SPVError, cause error) error {
+	base := ClientError{SPVError: spvErr, Err: cause}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(cause, context.DeadlineExceeded):
+		return &TimeoutError{base}
+	case errors.Is(cause, context.Canceled):
+		return &CanceledError{base}
+	case errors.As(cause, &netErr):
+		if netErr.Timeout() {
+			return &TimeoutError{base}
+		}
+		return &TransportError{base}
+	case errors.Is(cause, io.ErrUnexpectedEOF):
+		return &TransportError{base}
+	default:
+		return nil
 	}
 }
 
@@ -0,0 +1,187 @@
+package walletclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/bitcoin-sv/spv-wallet-go-client/fixtures"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedErrors(t *testing.T) {
+	t.Run("Should type the errors returned by the spv-wallet by their status", func(t *testing.T) {
+		tcs := map[string]struct {
+			status   int
+			expected func(err error) bool
+		}{
+			"unauthorized": {http.StatusUnauthorized, func(err error) bool { var target *AuthError; return errors.As(err, &target) }},
+			"forbidden":    {http.StatusForbidden, func(err error) bool { var target *AuthError; return errors.As(err, &target) }},
+			"not found":    {http.StatusNotFound, func(err error) bool { var target *NotFoundError; return errors.As(err, &target) }},
+			"bad request":  {http.StatusBadRequest, func(err error) bool { var target *ValidationError; return errors.As(err, &target) }},
+			"unavailable":  {http.StatusServiceUnavailable, func(err error) bool { var target *ServerError; return errors.As(err, &target) }},
+		}
+		for name, tc := range tcs {
+			t.Run(name, func(t *testing.T) {
+				// given
+				server, _ := flakyServer(1, tc.status, nil)
+				defer server.Close()
+				client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+				require.NoError(t, err)
+
+				// when
+				_, err = client.GetAccessKey(context.Background(), "key-id")
+
+				// then
+				require.True(t, tc.expected(err))
+				var spvErr models.SPVError
+				require.ErrorAs(t, err, &spvErr)
+				require.Equal(t, tc.status, spvErr.GetStatusCode())
+				require.Equal(t, "error-unavailable", spvErr.GetCode())
+				require.Equal(t, "unavailable", err.Error())
+			})
+		}
+	})
+
+	t.Run("Should keep the context error as the cause", func(t *testing.T) {
+		// given
+		server, requests := flakyServer(0, http.StatusOK, nil)
+		defer server.Close()
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// when
+		_, err = client.GetAccessKey(ctx, "key-id")
+
+		// then
+		require.ErrorIs(t, err, context.Canceled)
+		var canceledErr *CanceledError
+		require.ErrorAs(t, err, &canceledErr)
+		require.Equal(t, models.UnknownErrorCode, canceledErr.GetCode())
+		require.Empty(t, *requests)
+	})
+
+	t.Run("Should type a failed connection as a transport error", func(t *testing.T) {
+		// given
+		server, _ := flakyServer(0, http.StatusOK, nil)
+		server.Close()
+		client, err := New(server.URL, WithXPriv(fixtures.XPrivString), WithRetryPolicy(&RetryPolicy{MaxAttempts: 1}))
+		require.NoError(t, err)
+
+		// when
+		_, err = client.GetAccessKey(context.Background(), "key-id")
+
+		// then
+		var transportErr *TransportError
+		require.ErrorAs(t, err, &transportErr)
+		require.Error(t, transportErr.Err)
+	})
+
+	t.Run("Should type the errors of the client by their category", func(t *testing.T) {
+		// setup
+		server, requests := flakyServer(0, http.StatusOK, nil)
+		defer server.Close()
+		xPubClient, err := NewWithXPub(server.URL, fixtures.XPubString)
+		require.NoError(t, err)
+		xPrivClient, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		ctx := context.Background()
+
+		tests := map[string]struct {
+			call     func() error
+			sentinel models.SPVError
+			typed    any
+		}{
+			"missing access key": {
+				call: func() error {
+					_, err := xPubClient.GetAccessKey(ctx, "key-id")
+					return err
+				},
+				sentinel: ErrMissingAccessKey,
+				typed:    new(*AuthError),
+			},
+			"missing xpriv": {
+				call: func() error {
+					_, err := xPubClient.BuildTransaction(ctx, &LocalTransactionConfig{Recipients: []*Recipients{{Satoshis: 1}}})
+					return err
+				},
+				sentinel: ErrMissingXpriv,
+				typed:    new(*AuthError),
+			},
+			"missing xpriv for a totp": {
+				call: func() error {
+					_, err := xPubClient.GenerateTotpForContact(&models.Contact{}, 0, 0)
+					return err
+				},
+				sentinel: ErrMissingXpriv,
+				typed:    new(*AuthError),
+			},
+			"missing recipients": {
+				call: func() error {
+					_, err := xPrivClient.BuildTransaction(ctx, &LocalTransactionConfig{})
+					return err
+				},
+				sentinel: ErrMissingRecipients,
+				typed:    new(*ValidationError),
+			},
+			"invalid contact pub key": {
+				call: func() error {
+					_, err := xPrivClient.GenerateTotpForContact(&models.Contact{PubKey: "invalid"}, 0, 0)
+					return err
+				},
+				sentinel: ErrContactPubKeyInvalid,
+				typed:    new(*ValidationError),
+			},
+			"missing draft": {
+				call: func() error {
+					_, err := xPrivClient.VerifyDraft(ctx, nil, nil, nil)
+					return err
+				},
+				sentinel: ErrCouldNotFindDraftTransaction,
+				typed:    new(*NotFoundError),
+			},
+		}
+		for name, tc := range tests {
+			t.Run(name, func(t *testing.T) {
+				// when
+				err := tc.call()
+
+				// then
+				require.ErrorIs(t, err, tc.sentinel)
+				require.ErrorAs(t, err, tc.typed)
+			})
+		}
+		require.Empty(t, *requests)
+	})
+
+	t.Run("Should type a server error sentinel without a cause by its status", func(t *testing.T) {
+		// when
+		err := WrapError(ErrInvalidHTTPClient)
+
+		// then
+		require.ErrorIs(t, err, ErrInvalidHTTPClient)
+		var serverErr *ServerError
+		require.ErrorAs(t, err, &serverErr)
+		require.Nil(t, serverErr.Err)
+	})
+
+	t.Run("Should keep the typed errors and the sentinel errors when wrapped again", func(t *testing.T) {
+		// given
+		typed := WrapError(ErrSyncMerkleRootsTimeout.Wrap(context.DeadlineExceeded))
+
+		// when
+		err := WrapError(typed)
+
+		// then
+		require.Same(t, typed, err)
+		require.ErrorIs(t, err, ErrSyncMerkleRootsTimeout)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		var timeoutErr *TimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+		require.NoError(t, WrapError(nil))
+	})
+}
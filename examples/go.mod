@@ -1,6 +1,6 @@
 module github.com/bitcoin-sv/spv-wallet-go-client/examples
 
-go 1.22.5
+go 1.23.0
 
 replace github.com/bitcoin-sv/spv-wallet-go-client => ../
 
@@ -12,7 +12,12 @@ require (
 require (
 	github.com/bitcoin-sv/go-sdk v1.1.9 // indirect
 	github.com/boombuler/barcode v1.0.2 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pquerna/otp v1.4.0 // indirect
+	go.opentelemetry.io/otel v1.31.0 // indirect
+	go.opentelemetry.io/otel/metric v1.31.0 // indirect
+	go.opentelemetry.io/otel/trace v1.31.0 // indirect
 	golang.org/x/crypto v0.26.0 // indirect
 )
@@ -1,9 +1,15 @@
 package fixtures
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/bitcoin-sv/spv-wallet/models"
@@ -117,3 +123,115 @@ func MockMerkleRootsAPIResponseStale() *httptest.Server {
 
 	return server
 }
+
+// ReorgedMockedSPVWalletData returns MockedSPVWalletData with the roots above forkHeight replaced by roots of another branch,
+// up to the height tip, as the spv-wallet serves them after a chain reorganisation
+func ReorgedMockedSPVWalletData(forkHeight, tip int) []models.MerkleRoot {
+	chain := slices.Clone(MockedSPVWalletData[:forkHeight+1])
+	for height := forkHeight + 1; height <= tip; height++ {
+		hash := sha256.Sum256([]byte(fmt.Sprintf("reorg-%d", height)))
+		chain = append(chain, models.MerkleRoot{MerkleRoot: hex.EncodeToString(hash[:]), BlockHeight: height})
+	}
+	return chain
+}
+
+// MerkleRootsChain is the chain of merkle roots served by MockMerkleRootsAPIResponseChain, which can change while it is served
+type MerkleRootsChain struct {
+	mu        sync.Mutex
+	roots     []models.MerkleRoot
+	failAfter int
+	failures  int
+	requests  int
+}
+
+// NewMerkleRootsChain creates a served chain of the merkle roots
+func NewMerkleRootsChain(merkleRoots []models.MerkleRoot) *MerkleRootsChain {
+	return &MerkleRootsChain{roots: merkleRoots}
+}
+
+// Set replaces the merkle roots of the chain, e.g. to extend or reorganise it
+func (c *MerkleRootsChain) Set(merkleRoots []models.MerkleRoot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roots = merkleRoots
+}
+
+// Fail makes count requests fail with an internal server error, after the next after ones are served
+func (c *MerkleRootsChain) Fail(after, count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failAfter = c.requests + after
+	c.failures = count
+}
+
+// Requests returns the number of requests received
+func (c *MerkleRootsChain) Requests() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requests
+}
+
+func (c *MerkleRootsChain) request() ([]models.MerkleRoot, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests++
+	if c.failures > 0 && c.requests > c.failAfter {
+		c.failures--
+		return nil, false
+	}
+	return c.roots, true
+}
+
+func sendErrorResponse(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(models.ResponseError{Code: code, Message: message})
+}
+
+// MockMerkleRootsAPIResponseChain serves the chain in pages of pageSize, or of the requested batchSize, and rejects the last evaluated keys which are not in the chain
+func MockMerkleRootsAPIResponseChain(served *MerkleRootsChain, pageSize int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/merkleroots" && r.Method == http.MethodGet:
+			chain, ok := served.request()
+			if !ok {
+				sendErrorResponse(w, http.StatusInternalServerError, "error-internal", "internal server error")
+				return
+			}
+
+			start := 0
+			if lastEvaluatedKey := r.URL.Query().Get("lastEvaluatedKey"); lastEvaluatedKey != "" {
+				idx := slices.IndexFunc(chain, func(mr models.MerkleRoot) bool {
+					return mr.MerkleRoot == lastEvaluatedKey
+				})
+				if idx < 0 {
+					sendErrorResponse(w, http.StatusBadRequest, "error-merkle-roots-invalid-last-evaluated-key", "last evaluated key is not a known merkle root")
+					return
+				}
+				start = idx + 1
+			}
+
+			size := pageSize
+			if batchSize, err := strconv.Atoi(r.URL.Query().Get("batchSize")); err == nil && batchSize > 0 {
+				size = batchSize
+			}
+			content := chain[start:min(start+size, len(chain))]
+			lastEvaluatedKey := ""
+			if start+len(content) < len(chain) {
+				lastEvaluatedKey = content[len(content)-1].MerkleRoot
+			}
+			sendJSONResponse(models.ExclusiveStartKeyPage[[]models.MerkleRoot]{
+				Content: content,
+				Page: models.ExclusiveStartKeyPageInfo{
+					LastEvaluatedKey: lastEvaluatedKey,
+					TotalElements:    len(chain),
+					Size:             len(content),
+				},
+			}, &w)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return server
+}
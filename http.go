@@ -6,8 +6,10 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	bip32 "github.com/bitcoin-sv/go-sdk/compat/bip32"
 	ec "github.com/bitcoin-sv/go-sdk/primitives/ec"
@@ -26,6 +28,16 @@ func (wc *WalletClient) IsSignRequest() bool {
 	return wc.signRequest
 }
 
+// Transport returns the type of transport used to reach the spv-wallet
+func (wc *WalletClient) Transport() TransportType {
+	return wc.transport
+}
+
+// SetRetryPolicy sets the policy used to retry requests failing with transient errors; nil disables retries
+func (wc *WalletClient) SetRetryPolicy(policy *RetryPolicy) {
+	wc.retryPolicy = policy
+}
+
 // SetAdminKey set the admin key
 func (wc *WalletClient) SetAdminKey(adminKey *bip32.ExtendedKey) {
 	wc.adminXPriv = adminKey
@@ -33,6 +45,7 @@ func (wc *WalletClient) SetAdminKey(adminKey *bip32.ExtendedKey) {
 
 // GetXPub will get the xpub of the current xpub
 func (wc *WalletClient) GetXPub(ctx context.Context) (*models.Xpub, error) {
+	ctx = withOperation(ctx, "GetXPub")
 	var xPub models.Xpub
 	if err := wc.doHTTPRequest(
 		ctx, http.MethodGet, "/xpub", nil, wc.xPriv, true, &xPub,
@@ -45,6 +58,7 @@ func (wc *WalletClient) GetXPub(ctx context.Context) (*models.Xpub, error) {
 
 // UpdateXPubMetadata update the metadata of the logged in xpub
 func (wc *WalletClient) UpdateXPubMetadata(ctx context.Context, metadata map[string]any) (*models.Xpub, error) {
+	ctx = withOperation(ctx, "UpdateXPubMetadata")
 	jsonStr, err := json.Marshal(map[string]interface{}{
 		FieldMetadata: metadata,
 	})
@@ -64,6 +78,7 @@ func (wc *WalletClient) UpdateXPubMetadata(ctx context.Context, metadata map[str
 
 // GetAccessKey will get an access key by id
 func (wc *WalletClient) GetAccessKey(ctx context.Context, id string) (*models.AccessKey, error) {
+	ctx = withOperation(ctx, "GetAccessKey")
 	var accessKey models.AccessKey
 	if err := wc.doHTTPRequest(
 		ctx, http.MethodGet, "/access-key?"+FieldID+"="+id, nil, wc.xPriv, true, &accessKey,
@@ -81,6 +96,7 @@ func (wc *WalletClient) GetAccessKeys(
 	metadata map[string]any,
 	queryParams *filter.QueryParams,
 ) ([]*models.AccessKey, error) {
+	ctx = withOperation(ctx, "GetAccessKeys")
 	return Search[filter.AccessKeyFilter, []*models.AccessKey](
 		ctx, http.MethodPost,
 		"/access-key/search",
@@ -98,6 +114,7 @@ func (wc *WalletClient) GetAccessKeysCount(
 	conditions *filter.AccessKeyFilter,
 	metadata map[string]any,
 ) (int64, error) {
+	ctx = withOperation(ctx, "GetAccessKeysCount")
 	return Count[filter.AccessKeyFilter](
 		ctx, http.MethodPost,
 		"/access-key/count",
@@ -110,6 +127,7 @@ func (wc *WalletClient) GetAccessKeysCount(
 
 // RevokeAccessKey will revoke an access key by id
 func (wc *WalletClient) RevokeAccessKey(ctx context.Context, id string) (*models.AccessKey, error) {
+	ctx = withOperation(ctx, "RevokeAccessKey")
 	var accessKey models.AccessKey
 	if err := wc.doHTTPRequest(
 		ctx, http.MethodDelete, "/access-key?"+FieldID+"="+id, nil, wc.xPriv, true, &accessKey,
@@ -122,6 +140,7 @@ func (wc *WalletClient) RevokeAccessKey(ctx context.Context, id string) (*models
 
 // CreateAccessKey will create new access key
 func (wc *WalletClient) CreateAccessKey(ctx context.Context, metadata map[string]any) (*models.AccessKey, error) {
+	ctx = withOperation(ctx, "CreateAccessKey")
 	jsonStr, err := json.Marshal(map[string]interface{}{
 		FieldMetadata: metadata,
 	})
@@ -140,6 +159,7 @@ func (wc *WalletClient) CreateAccessKey(ctx context.Context, metadata map[string
 
 // GetDestinationByID will get a destination by id
 func (wc *WalletClient) GetDestinationByID(ctx context.Context, id string) (*models.Destination, error) {
+	ctx = withOperation(ctx, "GetDestinationByID")
 	var destination models.Destination
 	if err := wc.doHTTPRequest(
 		ctx, http.MethodGet, fmt.Sprintf("/destination?%s=%s", FieldID, id), nil, wc.xPriv, true, &destination,
@@ -152,6 +172,7 @@ func (wc *WalletClient) GetDestinationByID(ctx context.Context, id string) (*mod
 
 // GetDestinationByAddress will get a destination by address
 func (wc *WalletClient) GetDestinationByAddress(ctx context.Context, address string) (*models.Destination, error) {
+	ctx = withOperation(ctx, "GetDestinationByAddress")
 	var destination models.Destination
 	if err := wc.doHTTPRequest(
 		ctx, http.MethodGet, "/destination?"+FieldAddress+"="+address, nil, wc.xPriv, true, &destination,
@@ -164,6 +185,7 @@ func (wc *WalletClient) GetDestinationByAddress(ctx context.Context, address str
 
 // GetDestinationByLockingScript will get a destination by locking script
 func (wc *WalletClient) GetDestinationByLockingScript(ctx context.Context, lockingScript string) (*models.Destination, error) {
+	ctx = withOperation(ctx, "GetDestinationByLockingScript")
 	var destination models.Destination
 	if err := wc.doHTTPRequest(
 		ctx, http.MethodGet, "/destination?"+FieldLockingScript+"="+lockingScript, nil, wc.xPriv, true, &destination,
@@ -176,6 +198,7 @@ func (wc *WalletClient) GetDestinationByLockingScript(ctx context.Context, locki
 
 // GetDestinations will get all destinations matching the metadata filter
 func (wc *WalletClient) GetDestinations(ctx context.Context, conditions *filter.DestinationFilter, metadata map[string]any, queryParams *filter.QueryParams) ([]*models.Destination, error) {
+	ctx = withOperation(ctx, "GetDestinations")
 	return Search[filter.DestinationFilter, []*models.Destination](
 		ctx, http.MethodPost,
 		"/destination/search",
@@ -189,6 +212,7 @@ func (wc *WalletClient) GetDestinations(ctx context.Context, conditions *filter.
 
 // GetDestinationsCount will get the count of destinations matching the metadata filter
 func (wc *WalletClient) GetDestinationsCount(ctx context.Context, conditions *filter.DestinationFilter, metadata map[string]any) (int64, error) {
+	ctx = withOperation(ctx, "GetDestinationsCount")
 	return Count(
 		ctx,
 		http.MethodPost,
@@ -202,6 +226,7 @@ func (wc *WalletClient) GetDestinationsCount(ctx context.Context, conditions *fi
 
 // NewDestination will create a new destination and return it
 func (wc *WalletClient) NewDestination(ctx context.Context, metadata map[string]any) (*models.Destination, error) {
+	ctx = withOperation(ctx, "NewDestination")
 	jsonStr, err := json.Marshal(map[string]interface{}{
 		FieldMetadata: metadata,
 	})
@@ -220,6 +245,7 @@ func (wc *WalletClient) NewDestination(ctx context.Context, metadata map[string]
 
 // UpdateDestinationMetadataByID updates the destination metadata by id
 func (wc *WalletClient) UpdateDestinationMetadataByID(ctx context.Context, id string, metadata map[string]any) (*models.Destination, error) {
+	ctx = withOperation(ctx, "UpdateDestinationMetadataByID")
 	jsonStr, err := json.Marshal(map[string]interface{}{
 		FieldID:       id,
 		FieldMetadata: metadata,
@@ -240,6 +266,7 @@ func (wc *WalletClient) UpdateDestinationMetadataByID(ctx context.Context, id st
 
 // UpdateDestinationMetadataByAddress updates the destination metadata by address
 func (wc *WalletClient) UpdateDestinationMetadataByAddress(ctx context.Context, address string, metadata map[string]any) (*models.Destination, error) {
+	ctx = withOperation(ctx, "UpdateDestinationMetadataByAddress")
 	jsonStr, err := json.Marshal(map[string]interface{}{
 		FieldAddress:  address,
 		FieldMetadata: metadata,
@@ -260,6 +287,7 @@ func (wc *WalletClient) UpdateDestinationMetadataByAddress(ctx context.Context,
 
 // UpdateDestinationMetadataByLockingScript updates the destination metadata by locking script
 func (wc *WalletClient) UpdateDestinationMetadataByLockingScript(ctx context.Context, lockingScript string, metadata map[string]any) (*models.Destination, error) {
+	ctx = withOperation(ctx, "UpdateDestinationMetadataByLockingScript")
 	jsonStr, err := json.Marshal(map[string]interface{}{
 		FieldLockingScript: lockingScript,
 		FieldMetadata:      metadata,
@@ -280,6 +308,7 @@ func (wc *WalletClient) UpdateDestinationMetadataByLockingScript(ctx context.Con
 
 // GetTransaction will get a transaction by ID
 func (wc *WalletClient) GetTransaction(ctx context.Context, txID string) (*models.Transaction, error) {
+	ctx = withOperation(ctx, "GetTransaction")
 	var transaction models.Transaction
 	if err := wc.doHTTPRequest(ctx, http.MethodGet, "/transaction?"+FieldID+"="+txID, nil, wc.xPriv, wc.signRequest, &transaction); err != nil {
 		return nil, err
@@ -295,6 +324,7 @@ func (wc *WalletClient) GetTransactions(
 	metadata map[string]any,
 	queryParams *filter.QueryParams,
 ) ([]*models.Transaction, error) {
+	ctx = withOperation(ctx, "GetTransactions")
 	return Search[filter.TransactionFilter, []*models.Transaction](
 		ctx, http.MethodPost,
 		"/transaction/search",
@@ -312,6 +342,7 @@ func (wc *WalletClient) GetTransactionsCount(
 	conditions *filter.TransactionFilter,
 	metadata map[string]any,
 ) (int64, error) {
+	ctx = withOperation(ctx, "GetTransactionsCount")
 	return Count[filter.TransactionFilter](
 		ctx, http.MethodPost,
 		"/transaction/count",
@@ -324,6 +355,7 @@ func (wc *WalletClient) GetTransactionsCount(
 
 // DraftToRecipients is a draft transaction to a slice of recipients
 func (wc *WalletClient) DraftToRecipients(ctx context.Context, recipients []*Recipients, metadata map[string]any) (*models.DraftTransaction, error) {
+	ctx = withOperation(ctx, "DraftToRecipients")
 	outputs := make([]map[string]interface{}, 0)
 	for _, recipient := range recipients {
 		outputs = append(outputs, map[string]interface{}{
@@ -343,6 +375,7 @@ func (wc *WalletClient) DraftToRecipients(ctx context.Context, recipients []*Rec
 
 // DraftTransaction is a draft transaction
 func (wc *WalletClient) DraftTransaction(ctx context.Context, transactionConfig *models.TransactionConfig, metadata map[string]any) (*models.DraftTransaction, error) {
+	ctx = withOperation(ctx, "DraftTransaction")
 	return wc.createDraftTransaction(ctx, map[string]interface{}{
 		FieldConfig:   transactionConfig,
 		FieldMetadata: metadata,
@@ -365,7 +398,7 @@ func (wc *WalletClient) createDraftTransaction(ctx context.Context,
 		return nil, err
 	}
 	if draftTransaction == nil {
-		return nil, ErrCouldNotFindDraftTransaction
+		return nil, WrapError(ErrCouldNotFindDraftTransaction)
 	}
 
 	return draftTransaction, nil
@@ -373,6 +406,7 @@ func (wc *WalletClient) createDraftTransaction(ctx context.Context,
 
 // RecordTransaction will record a transaction
 func (wc *WalletClient) RecordTransaction(ctx context.Context, hex, referenceID string, metadata map[string]any) (*models.Transaction, error) {
+	ctx = withOperation(ctx, "RecordTransaction")
 	jsonStr, err := json.Marshal(map[string]interface{}{
 		FieldHex:         hex,
 		FieldReferenceID: referenceID,
@@ -394,6 +428,7 @@ func (wc *WalletClient) RecordTransaction(ctx context.Context, hex, referenceID
 
 // UpdateTransactionMetadata update the metadata of a transaction
 func (wc *WalletClient) UpdateTransactionMetadata(ctx context.Context, txID string, metadata map[string]any) (*models.Transaction, error) {
+	ctx = withOperation(ctx, "UpdateTransactionMetadata")
 	jsonStr, err := json.Marshal(map[string]interface{}{
 		FieldID:       txID,
 		FieldMetadata: metadata,
@@ -430,6 +465,7 @@ func SetSignatureFromAccessKey(header *http.Header, privateKeyHex, bodyString st
 
 // GetUtxo will get a utxo by transaction ID
 func (wc *WalletClient) GetUtxo(ctx context.Context, txID string, outputIndex uint32) (*models.Utxo, error) {
+	ctx = withOperation(ctx, "GetUtxo")
 	outputIndexStr := strconv.FormatUint(uint64(outputIndex), 10)
 
 	url := fmt.Sprintf("/utxo?%s=%s&%s=%s", FieldTransactionID, txID, FieldOutputIndex, outputIndexStr)
@@ -446,6 +482,7 @@ func (wc *WalletClient) GetUtxo(ctx context.Context, txID string, outputIndex ui
 
 // GetUtxos will get a list of utxos filtered by conditions and metadata
 func (wc *WalletClient) GetUtxos(ctx context.Context, conditions *filter.UtxoFilter, metadata map[string]any, queryParams *filter.QueryParams) ([]*models.Utxo, error) {
+	ctx = withOperation(ctx, "GetUtxos")
 	return Search[filter.UtxoFilter, []*models.Utxo](
 		ctx, http.MethodPost,
 		"/utxo/search",
@@ -459,6 +496,7 @@ func (wc *WalletClient) GetUtxos(ctx context.Context, conditions *filter.UtxoFil
 
 // GetUtxosCount will get the count of utxos filtered by conditions and metadata
 func (wc *WalletClient) GetUtxosCount(ctx context.Context, conditions *filter.UtxoFilter, metadata map[string]any) (int64, error) {
+	ctx = withOperation(ctx, "GetUtxosCount")
 	return Count[filter.UtxoFilter](
 		ctx, http.MethodPost,
 		"/utxo/count",
@@ -503,31 +541,98 @@ func createSignatureAccessKey(privateKeyHex, bodyString string) (payload *models
 func (wc *WalletClient) doHTTPRequest(ctx context.Context, method string, path string,
 	rawJSON []byte, xPriv *bip32.ExtendedKey, sign bool, responseJSON interface{},
 ) error {
+	call := &CallInfo{Operation: contextOperation(ctx), Method: method, Path: endpointPath(path)}
+	ctx = wc.interceptBeforeCall(ctx, call)
+	start := time.Now()
+
+	call.Err = wc.sendHTTPRequest(ctx, call, path, rawJSON, xPriv, sign, responseJSON)
+	call.Latency = time.Since(start)
+	wc.interceptAfterResponse(ctx, call)
+	wc.logCall(ctx, call)
+
+	return call.Err
+}
+
+// sendHTTPRequest will send the HTTP request, retrying it according to the retry policy, and record the attempts in the call
+func (wc *WalletClient) sendHTTPRequest(ctx context.Context, call *CallInfo, path string,
+	rawJSON []byte, xPriv *bip32.ExtendedKey, sign bool, responseJSON interface{},
+) error {
+	retryable := wc.retryPolicy.allows(ctx, call.Method)
+
+	for attempt := 1; ; attempt++ {
+		call.Retries = attempt - 1
+
+		// the request is re-created on every attempt, so it is signed with a fresh auth time and nonce
+		req, err := wc.newHTTPRequest(ctx, call.Method, path, rawJSON, xPriv, sign, attempt)
+		if err != nil {
+			return err
+		}
+
+		resp, err := wc.httpClient.Do(req)
+		call.StatusCode = 0
+		if resp != nil {
+			call.StatusCode = resp.StatusCode
+		}
+		if retryable && attempt < wc.retryPolicy.MaxAttempts && wc.retryPolicy.shouldRetry(ctx, resp, err) {
+			delay := wc.retryPolicy.backoff(attempt, resp)
+			closeResponseBody(resp)
+			wc.logger.InfoContext(ctx, "retrying spv-wallet request",
+				slog.String("operation", call.Operation),
+				slog.Int("attempt", attempt),
+				slog.Int("status", call.StatusCode),
+				slog.Duration("delay", delay),
+			)
+			if err := sleepContext(ctx, delay); err != nil {
+				return WrapError(err)
+			}
+			continue
+		}
+
+		return wc.handleHTTPResponse(resp, err, responseJSON)
+	}
+}
+
+// newHTTPRequest will create and sign the HTTP request, running the interceptors around the signing
+func (wc *WalletClient) newHTTPRequest(ctx context.Context, method string, path string,
+	rawJSON []byte, xPriv *bip32.ExtendedKey, sign bool, attempt int,
+) (*http.Request, error) {
 	req, err := http.NewRequestWithContext(ctx, method, wc.server+path, bytes.NewBuffer(rawJSON))
 	if err != nil {
-		return WrapError(err)
+		return nil, WrapError(err)
+	}
+	for key, values := range wc.defaultHeaders {
+		req.Header[key] = append([]string(nil), values...)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
+	if err = wc.interceptBeforeSign(req, attempt); err != nil {
+		return nil, WrapError(err)
+	}
+
 	if xPriv != nil {
 		err := wc.authenticateWithXpriv(sign, req, xPriv, rawJSON)
 		if err != nil {
-			return err
+			return nil, WrapError(err)
 		}
 	} else {
 		err := wc.authenticateWithAccessKey(req, rawJSON)
 		if err != nil {
-			return err
+			return nil, WrapError(err)
 		}
 	}
 
-	var resp *http.Response
-	defer func() {
-		if resp != nil && resp.Body != nil {
-			_ = resp.Body.Close()
-		}
-	}()
-	if resp, err = wc.httpClient.Do(req); err != nil {
+	if err = wc.interceptAfterSign(req, attempt); err != nil {
+		return nil, WrapError(err)
+	}
+	wc.logRequest(req, attempt)
+
+	return req, nil
+}
+
+// handleHTTPResponse will convert the outcome of the last attempt into the response model or an error
+func (wc *WalletClient) handleHTTPResponse(resp *http.Response, err error, responseJSON interface{}) error {
+	defer closeResponseBody(resp)
+	if err != nil {
 		return WrapError(err)
 	}
 	if resp.StatusCode >= http.StatusBadRequest {
@@ -545,6 +650,12 @@ func (wc *WalletClient) doHTTPRequest(ctx context.Context, method string, path s
 	return nil
 }
 
+func closeResponseBody(resp *http.Response) {
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+}
+
 func (wc *WalletClient) authenticateWithXpriv(sign bool, req *http.Request, xPriv *bip32.ExtendedKey, rawJSON []byte) error {
 	if sign {
 		if err := addSignature(&req.Header, xPriv, string(rawJSON)); err != nil {
@@ -564,13 +675,14 @@ func (wc *WalletClient) authenticateWithXpriv(sign bool, req *http.Request, xPri
 
 func (wc *WalletClient) authenticateWithAccessKey(req *http.Request, rawJSON []byte) error {
 	if wc.accessKey == nil {
-		return ErrMissingAccessKey
+		return WrapError(ErrMissingAccessKey)
 	}
 	return SetSignatureFromAccessKey(&req.Header, hex.EncodeToString(wc.accessKey.Serialize()), string(rawJSON))
 }
 
 // AcceptContact will accept the contact associated with the paymail
 func (wc *WalletClient) AcceptContact(ctx context.Context, paymail string) error {
+	ctx = withOperation(ctx, "AcceptContact")
 	if err := wc.doHTTPRequest(
 		ctx, http.MethodPatch, "/contact/accepted/"+paymail, nil, wc.xPriv, wc.signRequest, nil,
 	); err != nil {
@@ -582,6 +694,7 @@ func (wc *WalletClient) AcceptContact(ctx context.Context, paymail string) error
 
 // RejectContact will reject the contact associated with the paymail
 func (wc *WalletClient) RejectContact(ctx context.Context, paymail string) error {
+	ctx = withOperation(ctx, "RejectContact")
 	if err := wc.doHTTPRequest(
 		ctx, http.MethodPatch, "/contact/rejected/"+paymail, nil, wc.xPriv, wc.signRequest, nil,
 	); err != nil {
@@ -593,6 +706,7 @@ func (wc *WalletClient) RejectContact(ctx context.Context, paymail string) error
 
 // ConfirmContact will confirm the contact associated with the paymail
 func (wc *WalletClient) ConfirmContact(ctx context.Context, contact *models.Contact, passcode, requesterPaymail string, period, digits uint) error {
+	ctx = withOperation(ctx, "ConfirmContact")
 	isTotpValid, err := wc.ValidateTotpForContact(contact, passcode, requesterPaymail, period, digits)
 	if err != nil {
 		return WrapError(ErrTotpInvalid)
@@ -613,6 +727,7 @@ func (wc *WalletClient) ConfirmContact(ctx context.Context, contact *models.Cont
 
 // GetContacts will get contacts by conditions
 func (wc *WalletClient) GetContacts(ctx context.Context, conditions *filter.ContactFilter, metadata map[string]any, queryParams *filter.QueryParams) (*models.SearchContactsResponse, error) {
+	ctx = withOperation(ctx, "GetContacts")
 	return Search[filter.ContactFilter, *models.SearchContactsResponse](
 		ctx, http.MethodPost,
 		"/contact/search",
@@ -626,11 +741,13 @@ func (wc *WalletClient) GetContacts(ctx context.Context, conditions *filter.Cont
 
 // UpsertContact add or update contact. When adding a new contact, the system utilizes Paymail's PIKE capability to dispatch an invitation request, asking the counterparty to include the current user in their contacts.
 func (wc *WalletClient) UpsertContact(ctx context.Context, paymail, fullName, requesterPaymail string, metadata map[string]any) (*models.Contact, error) {
+	ctx = withOperation(ctx, "UpsertContact")
 	return wc.UpsertContactForPaymail(ctx, paymail, fullName, metadata, requesterPaymail)
 }
 
 // UpsertContactForPaymail add or update contact. When adding a new contact, the system utilizes Paymail's PIKE capability to dispatch an invitation request, asking the counterparty to include the current user in their contacts.
 func (wc *WalletClient) UpsertContactForPaymail(ctx context.Context, paymail, fullName string, metadata map[string]any, requesterPaymail string) (*models.Contact, error) {
+	ctx = withOperation(ctx, "UpsertContactForPaymail")
 	payload := map[string]interface{}{
 		"fullName":    fullName,
 		FieldMetadata: metadata,
@@ -657,6 +774,7 @@ func (wc *WalletClient) UpsertContactForPaymail(ctx context.Context, paymail, fu
 
 // GetSharedConfig gets the shared config
 func (wc *WalletClient) GetSharedConfig(ctx context.Context) (*models.SharedConfig, error) {
+	ctx = withOperation(ctx, "GetSharedConfig")
 	var model *models.SharedConfig
 
 	key := wc.xPriv
@@ -677,6 +795,7 @@ func (wc *WalletClient) GetSharedConfig(ctx context.Context) (*models.SharedConf
 
 // AdminNewXpub will register an xPub
 func (wc *WalletClient) AdminNewXpub(ctx context.Context, rawXPub string, metadata map[string]any) error {
+	ctx = withOperation(ctx, "AdminNewXpub")
 	// Adding a xpub needs to be signed by an admin key
 	if wc.adminXPriv == nil {
 		return WrapError(ErrAdminKey)
@@ -699,6 +818,7 @@ func (wc *WalletClient) AdminNewXpub(ctx context.Context, rawXPub string, metada
 
 // AdminGetStatus get whether admin key is valid
 func (wc *WalletClient) AdminGetStatus(ctx context.Context) (bool, error) {
+	ctx = withOperation(ctx, "AdminGetStatus")
 	var status bool
 	if err := wc.doHTTPRequest(
 		ctx, http.MethodGet, "/admin/status", nil, wc.adminXPriv, true, &status,
@@ -711,6 +831,7 @@ func (wc *WalletClient) AdminGetStatus(ctx context.Context) (bool, error) {
 
 // AdminGetStats get admin stats
 func (wc *WalletClient) AdminGetStats(ctx context.Context) (*models.AdminStats, error) {
+	ctx = withOperation(ctx, "AdminGetStats")
 	var stats *models.AdminStats
 	if err := wc.doHTTPRequest(
 		ctx, http.MethodGet, "/admin/stats", nil, wc.adminXPriv, true, &stats,
@@ -728,6 +849,7 @@ func (wc *WalletClient) AdminGetAccessKeys(
 	metadata map[string]any,
 	queryParams *filter.QueryParams,
 ) ([]*models.AccessKey, error) {
+	ctx = withOperation(ctx, "AdminGetAccessKeys")
 	return Search[filter.AdminAccessKeyFilter, []*models.AccessKey](
 		ctx, http.MethodPost,
 		"/admin/access-keys/search",
@@ -745,6 +867,7 @@ func (wc *WalletClient) AdminGetAccessKeysCount(
 	conditions *filter.AdminAccessKeyFilter,
 	metadata map[string]any,
 ) (int64, error) {
+	ctx = withOperation(ctx, "AdminGetAccessKeysCount")
 	return Count[filter.AdminAccessKeyFilter](
 		ctx, http.MethodPost,
 		"/admin/access-keys/count",
@@ -762,6 +885,7 @@ func (wc *WalletClient) AdminGetBlockHeaders(
 	metadata map[string]any,
 	queryParams *filter.QueryParams,
 ) ([]*models.BlockHeader, error) {
+	ctx = withOperation(ctx, "AdminGetBlockHeaders")
 	var models []*models.BlockHeader
 	if err := wc.adminGetModels(ctx, conditions, metadata, queryParams, "/admin/block-headers/search", &models); err != nil {
 		return nil, err
@@ -776,6 +900,7 @@ func (wc *WalletClient) AdminGetBlockHeadersCount(
 	conditions map[string]interface{},
 	metadata map[string]any,
 ) (int64, error) {
+	ctx = withOperation(ctx, "AdminGetBlockHeadersCount")
 	return wc.adminCount(ctx, conditions, metadata, "/admin/block-headers/count")
 }
 
@@ -783,6 +908,7 @@ func (wc *WalletClient) AdminGetBlockHeadersCount(
 func (wc *WalletClient) AdminGetDestinations(ctx context.Context, conditions *filter.DestinationFilter,
 	metadata map[string]any, queryParams *filter.QueryParams,
 ) ([]*models.Destination, error) {
+	ctx = withOperation(ctx, "AdminGetDestinations")
 	return Search[filter.DestinationFilter, []*models.Destination](
 		ctx, http.MethodPost,
 		"/admin/destinations/search",
@@ -796,6 +922,7 @@ func (wc *WalletClient) AdminGetDestinations(ctx context.Context, conditions *fi
 
 // AdminGetDestinationsCount get a count of all the destinations filtered by conditions
 func (wc *WalletClient) AdminGetDestinationsCount(ctx context.Context, conditions *filter.DestinationFilter, metadata map[string]any) (int64, error) {
+	ctx = withOperation(ctx, "AdminGetDestinationsCount")
 	return Count(
 		ctx,
 		http.MethodPost,
@@ -809,6 +936,7 @@ func (wc *WalletClient) AdminGetDestinationsCount(ctx context.Context, condition
 
 // AdminGetPaymail get a paymail by address
 func (wc *WalletClient) AdminGetPaymail(ctx context.Context, address string) (*models.PaymailAddress, error) {
+	ctx = withOperation(ctx, "AdminGetPaymail")
 	jsonStr, err := json.Marshal(map[string]interface{}{
 		FieldAddress: address,
 	})
@@ -833,6 +961,7 @@ func (wc *WalletClient) AdminGetPaymails(
 	metadata map[string]any,
 	queryParams *filter.QueryParams,
 ) ([]*models.PaymailAddress, error) {
+	ctx = withOperation(ctx, "AdminGetPaymails")
 	return Search[filter.AdminPaymailFilter, []*models.PaymailAddress](
 		ctx, http.MethodPost,
 		"/admin/paymails/search",
@@ -846,6 +975,7 @@ func (wc *WalletClient) AdminGetPaymails(
 
 // AdminGetPaymailsCount get a count of all the paymails filtered by conditions
 func (wc *WalletClient) AdminGetPaymailsCount(ctx context.Context, conditions *filter.AdminPaymailFilter, metadata map[string]any) (int64, error) {
+	ctx = withOperation(ctx, "AdminGetPaymailsCount")
 	return Count(
 		ctx, http.MethodPost,
 		"/admin/paymails/count",
@@ -858,6 +988,7 @@ func (wc *WalletClient) AdminGetPaymailsCount(ctx context.Context, conditions *f
 
 // AdminCreatePaymail create a new paymail for a xpub
 func (wc *WalletClient) AdminCreatePaymail(ctx context.Context, rawXPub string, address string, publicName string, avatar string) (*models.PaymailAddress, error) {
+	ctx = withOperation(ctx, "AdminCreatePaymail")
 	jsonStr, err := json.Marshal(map[string]interface{}{
 		FieldXpubKey:    rawXPub,
 		FieldAddress:    address,
@@ -880,6 +1011,7 @@ func (wc *WalletClient) AdminCreatePaymail(ctx context.Context, rawXPub string,
 
 // AdminDeletePaymail delete a paymail address from the database
 func (wc *WalletClient) AdminDeletePaymail(ctx context.Context, address string) error {
+	ctx = withOperation(ctx, "AdminDeletePaymail")
 	jsonStr, err := json.Marshal(map[string]interface{}{
 		FieldAddress: address,
 	})
@@ -903,6 +1035,7 @@ func (wc *WalletClient) AdminGetTransactions(
 	metadata map[string]any,
 	queryParams *filter.QueryParams,
 ) ([]*models.Transaction, error) {
+	ctx = withOperation(ctx, "AdminGetTransactions")
 	return Search[filter.TransactionFilter, []*models.Transaction](
 		ctx, http.MethodPost,
 		"/admin/transactions/search",
@@ -920,6 +1053,7 @@ func (wc *WalletClient) AdminGetTransactionsCount(
 	conditions *filter.TransactionFilter,
 	metadata map[string]any,
 ) (int64, error) {
+	ctx = withOperation(ctx, "AdminGetTransactionsCount")
 	return Count[filter.TransactionFilter](
 		ctx, http.MethodPost,
 		"/admin/transactions/count",
@@ -937,6 +1071,7 @@ func (wc *WalletClient) AdminGetUtxos(
 	metadata map[string]any,
 	queryParams *filter.QueryParams,
 ) ([]*models.Utxo, error) {
+	ctx = withOperation(ctx, "AdminGetUtxos")
 	return Search[filter.AdminUtxoFilter, []*models.Utxo](
 		ctx, http.MethodPost,
 		"/admin/utxos/search",
@@ -954,6 +1089,7 @@ func (wc *WalletClient) AdminGetUtxosCount(
 	conditions *filter.AdminUtxoFilter,
 	metadata map[string]any,
 ) (int64, error) {
+	ctx = withOperation(ctx, "AdminGetUtxosCount")
 	return Count[filter.AdminUtxoFilter](
 		ctx, http.MethodPost,
 		"/admin/utxos/count",
@@ -968,6 +1104,7 @@ func (wc *WalletClient) AdminGetUtxosCount(
 func (wc *WalletClient) AdminGetXPubs(ctx context.Context, conditions *filter.XpubFilter,
 	metadata map[string]any, queryParams *filter.QueryParams,
 ) ([]*models.Xpub, error) {
+	ctx = withOperation(ctx, "AdminGetXPubs")
 	return Search[filter.XpubFilter, []*models.Xpub](
 		ctx, http.MethodPost,
 		"/admin/xpubs/search",
@@ -985,6 +1122,7 @@ func (wc *WalletClient) AdminGetXPubsCount(
 	conditions *filter.XpubFilter,
 	metadata map[string]any,
 ) (int64, error) {
+	ctx = withOperation(ctx, "AdminGetXPubsCount")
 	return Count[filter.XpubFilter](
 		ctx, http.MethodPost,
 		"/admin/xpubs/count",
@@ -1013,7 +1151,7 @@ func (wc *WalletClient) adminGetModels(
 	}
 
 	if err := wc.doHTTPRequest(
-		ctx, http.MethodPost, path, jsonStr, wc.adminXPriv, true, &models,
+		WithRetry(ctx), http.MethodPost, path, jsonStr, wc.adminXPriv, true, &models,
 	); err != nil {
 		return err
 	}
@@ -1032,7 +1170,7 @@ func (wc *WalletClient) adminCount(ctx context.Context, conditions map[string]in
 
 	var count int64
 	if err := wc.doHTTPRequest(
-		ctx, http.MethodPost, path, jsonStr, wc.adminXPriv, true, &count,
+		WithRetry(ctx), http.MethodPost, path, jsonStr, wc.adminXPriv, true, &count,
 	); err != nil {
 		return 0, err
 	}
@@ -1042,6 +1180,7 @@ func (wc *WalletClient) adminCount(ctx context.Context, conditions map[string]in
 
 // AdminRecordTransaction will record a transaction as an admin
 func (wc *WalletClient) AdminRecordTransaction(ctx context.Context, hex string) (*models.Transaction, error) {
+	ctx = withOperation(ctx, "AdminRecordTransaction")
 	jsonStr, err := json.Marshal(map[string]interface{}{
 		FieldHex: hex,
 	})
@@ -1061,6 +1200,7 @@ func (wc *WalletClient) AdminRecordTransaction(ctx context.Context, hex string)
 
 // AdminGetContacts executes an HTTP POST request to search for contacts based on specified conditions, metadata, and query parameters.
 func (wc *WalletClient) AdminGetContacts(ctx context.Context, conditions *filter.ContactFilter, metadata map[string]any, queryParams *filter.QueryParams) (*models.SearchContactsResponse, error) {
+	ctx = withOperation(ctx, "AdminGetContacts")
 	return Search[filter.ContactFilter, *models.SearchContactsResponse](
 		ctx, http.MethodPost,
 		"/admin/contact/search",
@@ -1074,6 +1214,7 @@ func (wc *WalletClient) AdminGetContacts(ctx context.Context, conditions *filter
 
 // AdminUpdateContact executes an HTTP PATCH request to update a specific contact's full name using their ID.
 func (wc *WalletClient) AdminUpdateContact(ctx context.Context, id, fullName string, metadata map[string]any) (*models.Contact, error) {
+	ctx = withOperation(ctx, "AdminUpdateContact")
 	jsonStr, err := json.Marshal(map[string]interface{}{
 		"fullName":    fullName,
 		FieldMetadata: metadata,
@@ -1088,12 +1229,14 @@ func (wc *WalletClient) AdminUpdateContact(ctx context.Context, id, fullName str
 
 // AdminDeleteContact executes an HTTP DELETE request to remove a contact using their ID.
 func (wc *WalletClient) AdminDeleteContact(ctx context.Context, id string) error {
+	ctx = withOperation(ctx, "AdminDeleteContact")
 	err := wc.doHTTPRequest(ctx, http.MethodDelete, fmt.Sprintf("/admin/contact/%s", id), nil, wc.adminXPriv, true, nil)
 	return WrapError(err)
 }
 
 // AdminAcceptContact executes an HTTP PATCH request to mark a contact as accepted using their ID.
 func (wc *WalletClient) AdminAcceptContact(ctx context.Context, id string) (*models.Contact, error) {
+	ctx = withOperation(ctx, "AdminAcceptContact")
 	var contact models.Contact
 	err := wc.doHTTPRequest(ctx, http.MethodPatch, fmt.Sprintf("/admin/contact/accepted/%s", id), nil, wc.adminXPriv, true, &contact)
 	return &contact, WrapError(err)
@@ -1101,6 +1244,7 @@ func (wc *WalletClient) AdminAcceptContact(ctx context.Context, id string) (*mod
 
 // AdminRejectContact executes an HTTP PATCH request to mark a contact as rejected using their ID.
 func (wc *WalletClient) AdminRejectContact(ctx context.Context, id string) (*models.Contact, error) {
+	ctx = withOperation(ctx, "AdminRejectContact")
 	var contact models.Contact
 	err := wc.doHTTPRequest(ctx, http.MethodPatch, fmt.Sprintf("/admin/contact/rejected/%s", id), nil, wc.adminXPriv, true, &contact)
 	return &contact, WrapError(err)
@@ -1118,11 +1262,22 @@ func (wc *WalletClient) FinalizeTransaction(draft *models.DraftTransaction) (str
 
 // SendToRecipients send to recipients
 func (wc *WalletClient) SendToRecipients(ctx context.Context, recipients []*Recipients, metadata map[string]any) (*models.Transaction, error) {
+	ctx = withOperation(ctx, "SendToRecipients")
 	draft, err := wc.DraftToRecipients(ctx, recipients, metadata)
 	if err != nil {
 		return nil, err
 	}
 
+	if wc.draftVerification != nil {
+		report, err := wc.VerifyDraft(ctx, draft, recipients, wc.draftVerification)
+		if err != nil {
+			return nil, err
+		}
+		if err = report.Err(); err != nil {
+			return nil, err
+		}
+	}
+
 	var hex string
 	if hex, err = wc.FinalizeTransaction(draft); err != nil {
 		return nil, err
@@ -1133,6 +1288,7 @@ func (wc *WalletClient) SendToRecipients(ctx context.Context, recipients []*Reci
 
 // AdminSubscribeWebhook subscribes to a webhook to receive notifications from spv-wallet
 func (wc *WalletClient) AdminSubscribeWebhook(ctx context.Context, webhookURL, tokenHeader, tokenValue string) error {
+	ctx = withOperation(ctx, "AdminSubscribeWebhook")
 	requestModel := models.SubscribeRequestBody{
 		URL:         webhookURL,
 		TokenHeader: tokenHeader,
@@ -1148,6 +1304,7 @@ func (wc *WalletClient) AdminSubscribeWebhook(ctx context.Context, webhookURL, t
 
 // AdminUnsubscribeWebhook unsubscribes from a webhook
 func (wc *WalletClient) AdminUnsubscribeWebhook(ctx context.Context, webhookURL string) error {
+	ctx = withOperation(ctx, "AdminUnsubscribeWebhook")
 	requestModel := models.UnsubscribeRequestBody{
 		URL: webhookURL,
 	}
@@ -1161,6 +1318,7 @@ func (wc *WalletClient) AdminUnsubscribeWebhook(ctx context.Context, webhookURL
 
 // AdminGetWebhooks gets all webhooks
 func (wc *WalletClient) AdminGetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	ctx = withOperation(ctx, "AdminGetWebhooks")
 	var webhooks []*models.Webhook
 	err := wc.doHTTPRequest(ctx, http.MethodGet, "/admin/webhooks/subscriptions", nil, wc.adminXPriv, true, &webhooks)
 	if err != nil {
@@ -0,0 +1,130 @@
+package walletclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// Interceptor hooks into every call the WalletClient makes to the spv-wallet, e.g. to add tracing headers, log or collect metrics.
+// Any of the hooks can be nil. The request hooks run on every attempt, as retried requests are built and signed from scratch.
+type Interceptor struct {
+	// BeforeCall is called once before the first attempt of a call; the returned context, if not nil,
+	// is used for the requests of the call and passed to AfterResponse (e.g. to carry a span).
+	BeforeCall func(ctx context.Context, call *CallInfo) context.Context
+	// BeforeSign is called with the request before the auth headers are set; it can add or change headers.
+	// An error aborts the call.
+	BeforeSign func(req *http.Request, attempt int) error
+	// AfterSign is called with the signed request right before it is sent; it can inspect or override the auth headers.
+	// An error aborts the call.
+	AfterSign func(req *http.Request, attempt int) error
+	// AfterResponse is called once the call completes, after the last attempt, with its outcome.
+	AfterResponse func(ctx context.Context, call *CallInfo)
+}
+
+// CallInfo describes the outcome of a call to the spv-wallet
+type CallInfo struct {
+	// Operation is the name of the WalletClient method which made the call (e.g. "GetUtxos" or "AdminCreatePaymail").
+	Operation string
+	// Method is the HTTP method of the call.
+	Method string
+	// Path is the path of the endpoint, relative to the base path and without the query (e.g. "/transaction/search").
+	Path string
+	// StatusCode is the status code of the last response, 0 if no response was received.
+	StatusCode int
+	// Latency is the duration of the whole call, retries and backoff included.
+	Latency time.Duration
+	// Retries is the number of attempts made after the first one.
+	Retries int
+	// ErrorCode is the code of the SPVError returned by the call, empty on success.
+	ErrorCode string
+	// Err is the error returned by the call.
+	Err error
+}
+
+// WithInterceptors registers interceptors on the client; they are run in the order they are registered
+func WithInterceptors(interceptors ...*Interceptor) Option {
+	return &interceptorsConf{Interceptors: interceptors}
+}
+
+// Use registers interceptors on the client, after the ones already registered
+func (wc *WalletClient) Use(interceptors ...*Interceptor) {
+	for _, interceptor := range interceptors {
+		if interceptor != nil {
+			wc.interceptors = append(wc.interceptors, interceptor)
+		}
+	}
+}
+
+func (wc *WalletClient) interceptBeforeCall(ctx context.Context, call *CallInfo) context.Context {
+	for _, interceptor := range wc.interceptors {
+		if interceptor.BeforeCall == nil {
+			continue
+		}
+		if callCtx := interceptor.BeforeCall(ctx, call); callCtx != nil {
+			ctx = callCtx
+		}
+	}
+	return ctx
+}
+
+func (wc *WalletClient) interceptBeforeSign(req *http.Request, attempt int) error {
+	for _, interceptor := range wc.interceptors {
+		if interceptor.BeforeSign == nil {
+			continue
+		}
+		if err := interceptor.BeforeSign(req, attempt); err != nil {
+			return WrapError(err)
+		}
+	}
+	return nil
+}
+
+func (wc *WalletClient) interceptAfterSign(req *http.Request, attempt int) error {
+	for _, interceptor := range wc.interceptors {
+		if interceptor.AfterSign == nil {
+			continue
+		}
+		if err := interceptor.AfterSign(req, attempt); err != nil {
+			return WrapError(err)
+		}
+	}
+	return nil
+}
+
+func (wc *WalletClient) interceptAfterResponse(ctx context.Context, call *CallInfo) {
+	var spvErr models.SPVError
+	if errors.As(call.Err, &spvErr) {
+		call.ErrorCode = spvErr.Code
+	}
+
+	for _, interceptor := range wc.interceptors {
+		if interceptor.AfterResponse != nil {
+			interceptor.AfterResponse(ctx, call)
+		}
+	}
+}
+
+// operationKey is the context key of the operation requests are made for
+type operationKey struct{}
+
+// withOperation names the exported WalletClient method the requests made with the context are for
+func withOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// contextOperation returns the operation set with withOperation, or "" when the request is not made by a WalletClient method
+func contextOperation(ctx context.Context) string {
+	operation, _ := ctx.Value(operationKey{}).(string)
+	return operation
+}
+
+// endpointPath strips the query from the path of a call
+func endpointPath(path string) string {
+	endpoint, _, _ := strings.Cut(path, "?")
+	return endpoint
+}
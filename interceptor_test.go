@@ -0,0 +1,139 @@
+package walletclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/bitcoin-sv/spv-wallet-go-client/fixtures"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptors(t *testing.T) {
+	t.Run("Should run the hooks around signing and report the call", func(t *testing.T) {
+		// given
+		server, requests := flakyServer(1, http.StatusServiceUnavailable, nil)
+		defer server.Close()
+
+		var hooks []string
+		var call *CallInfo
+		client, err := New(server.URL,
+			WithXPriv(fixtures.XPrivString),
+			WithRetryPolicy(testRetryPolicy()),
+			WithInterceptors(&Interceptor{
+				BeforeSign: func(req *http.Request, attempt int) error {
+					require.Empty(t, req.Header.Get(models.AuthSignature))
+					req.Header.Set("X-Trace-Id", "trace")
+					hooks = append(hooks, "before-sign")
+					return nil
+				},
+				AfterSign: func(req *http.Request, attempt int) error {
+					require.NotEmpty(t, req.Header.Get(models.AuthSignature))
+					hooks = append(hooks, "after-sign")
+					return nil
+				},
+				AfterResponse: func(_ context.Context, info *CallInfo) {
+					call = info
+				},
+			}),
+		)
+		require.NoError(t, err)
+
+		// when
+		_, err = client.GetAccessKey(context.Background(), "key-id")
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, []string{"before-sign", "after-sign", "before-sign", "after-sign"}, hooks)
+		require.Len(t, *requests, 2)
+		require.Equal(t, "trace", (*requests)[1].Header.Get("X-Trace-Id"))
+
+		require.Equal(t, "GetAccessKey", call.Operation)
+		require.Equal(t, http.MethodGet, call.Method)
+		require.Equal(t, "/access-key", call.Path)
+		require.Equal(t, http.StatusOK, call.StatusCode)
+		require.Equal(t, 1, call.Retries)
+		require.Positive(t, call.Latency)
+		require.Empty(t, call.ErrorCode)
+		require.NoError(t, call.Err)
+	})
+
+	t.Run("Should report the operation of the search and count calls", func(t *testing.T) {
+		// given
+		server, _ := flakyServer(0, http.StatusOK, nil)
+		defer server.Close()
+
+		var operations []string
+		client, err := New(server.URL,
+			WithAdminKey(fixtures.XPrivString),
+			WithXPriv(fixtures.XPrivString),
+			WithInterceptors(&Interceptor{AfterResponse: func(_ context.Context, info *CallInfo) {
+				operations = append(operations, info.Operation)
+			}}),
+		)
+		require.NoError(t, err)
+		ctx := context.Background()
+
+		// when
+		_, _ = client.GetTransactions(ctx, nil, nil, nil)
+		_, _ = client.GetAccessKeysCount(ctx, nil, nil)
+		_, _ = client.AdminGetUtxos(ctx, nil, nil, nil)
+		_, _ = client.AdminGetXPubsCount(ctx, nil, nil)
+
+		// then
+		require.Equal(t, []string{"GetTransactions", "GetAccessKeysCount", "AdminGetUtxos", "AdminGetXPubsCount"}, operations)
+	})
+
+	t.Run("Should report the SPVError code", func(t *testing.T) {
+		// given
+		server, _ := flakyServer(1, http.StatusNotFound, nil)
+		defer server.Close()
+
+		var call *CallInfo
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		client.Use(&Interceptor{AfterResponse: func(_ context.Context, info *CallInfo) {
+			call = info
+		}})
+
+		// when
+		_, err = client.GetXPub(context.Background())
+
+		// then
+		require.Error(t, err)
+		require.Equal(t, http.StatusNotFound, call.StatusCode)
+		require.Equal(t, "error-unavailable", call.ErrorCode)
+		require.Equal(t, 0, call.Retries)
+	})
+
+	t.Run("Should abort the call when a hook fails", func(t *testing.T) {
+		// given
+		server, requests := flakyServer(0, http.StatusOK, nil)
+		defer server.Close()
+
+		var call *CallInfo
+		client, err := New(server.URL,
+			WithXPriv(fixtures.XPrivString),
+			WithInterceptors(
+				&Interceptor{BeforeSign: func(*http.Request, int) error {
+					return errors.New("rejected by policy")
+				}},
+				&Interceptor{AfterResponse: func(_ context.Context, info *CallInfo) {
+					call = info
+				}},
+			),
+		)
+		require.NoError(t, err)
+
+		// when
+		_, err = client.GetXPub(context.Background())
+
+		// then
+		require.ErrorContains(t, err, "rejected by policy")
+		require.Empty(t, *requests)
+		require.Equal(t, 0, call.StatusCode)
+		require.Equal(t, models.UnknownErrorCode, call.ErrorCode)
+	})
+}
@@ -0,0 +1,102 @@
+package walletclient
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// redacted replaces the values of the secret headers in the logs
+const redacted = "[REDACTED]"
+
+// redactedHeaders are the headers whose values are never logged: all the auth headers, which identify the caller, and the credentials
+var redactedHeaders = []string{
+	models.AuthHeader,
+	models.AuthAccessKey,
+	models.AuthSignature,
+	models.AuthHeaderHash,
+	models.AuthHeaderNonce,
+	models.AuthHeaderTime,
+	"Authorization",
+	"Cookie",
+}
+
+// WithLogger sets the logger of the client; nothing is logged by default.
+// Calls are logged at debug level, failed calls at warn level and retries at info level.
+func WithLogger(logger *slog.Logger) Option {
+	return &loggerConf{Logger: logger}
+}
+
+// loggerConf sets the logger of a WalletClient
+type loggerConf struct {
+	Logger *slog.Logger
+}
+
+func (w *loggerConf) Configure(c *WalletClient) error {
+	c.logger = w.Logger
+	if c.logger == nil {
+		c.logger = discardLogger()
+	}
+	return nil
+}
+
+// discardLogger returns a logger which drops all the records
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// logRequest logs an attempt of a call with its headers, the secret ones redacted
+func (wc *WalletClient) logRequest(req *http.Request, attempt int) {
+	if !wc.logger.Enabled(req.Context(), slog.LevelDebug) {
+		return
+	}
+	wc.logger.DebugContext(req.Context(), "sending spv-wallet request",
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.Redacted()),
+		slog.Int("attempt", attempt),
+		headersAttr(req.Header),
+	)
+}
+
+// logCall logs the outcome of a call
+func (wc *WalletClient) logCall(ctx context.Context, call *CallInfo) {
+	attrs := []slog.Attr{
+		slog.String("operation", call.Operation),
+		slog.String("method", call.Method),
+		slog.String("path", call.Path),
+		slog.Int("status", call.StatusCode),
+		slog.Duration("latency", call.Latency),
+		slog.Int("retries", call.Retries),
+	}
+	if call.Err != nil {
+		attrs = append(attrs, slog.String("error_code", call.ErrorCode), slog.String("error", call.Err.Error()))
+		wc.logger.LogAttrs(ctx, slog.LevelWarn, "spv-wallet call failed", attrs...)
+		return
+	}
+	wc.logger.LogAttrs(ctx, slog.LevelDebug, "spv-wallet call completed", attrs...)
+}
+
+// headersAttr returns the headers as a log group, with the values of the secret headers redacted
+func headersAttr(header http.Header) slog.Attr {
+	attrs := make([]any, 0, len(header))
+	for key, values := range header {
+		value := any(values)
+		if isRedactedHeader(key) {
+			value = redacted
+		}
+		attrs = append(attrs, slog.Any(key, value))
+	}
+	return slog.Group("headers", attrs...)
+}
+
+func isRedactedHeader(key string) bool {
+	for _, header := range redactedHeaders {
+		if http.CanonicalHeaderKey(header) == http.CanonicalHeaderKey(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package walletclient
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/bitcoin-sv/spv-wallet-go-client/fixtures"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLogger(t *testing.T) {
+	newLoggedClient := func(t *testing.T, serverURL string) (*WalletClient, *bytes.Buffer) {
+		var logs bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		client, err := New(serverURL,
+			WithXPriv(fixtures.XPrivString),
+			WithLogger(logger),
+			WithRetryPolicy(testRetryPolicy()),
+			WithDefaultHeaders(http.Header{"Authorization": []string{"Bearer secret-token"}}),
+		)
+		require.NoError(t, err)
+		return client, &logs
+	}
+
+	t.Run("Should log the requests with the secrets redacted", func(t *testing.T) {
+		// given
+		server, requests := flakyServer(0, http.StatusOK, nil)
+		defer server.Close()
+		client, logs := newLoggedClient(t, server.URL)
+
+		// when
+		_, err := client.GetXPub(context.Background())
+
+		// then
+		require.NoError(t, err)
+		for _, header := range []string{models.AuthHeader, models.AuthSignature, models.AuthHeaderHash, models.AuthHeaderNonce} {
+			value := (*requests)[0].Header.Get(header)
+			require.NotEmpty(t, value, header)
+			require.NotContains(t, logs.String(), value, header)
+		}
+		require.NotContains(t, logs.String(), "secret-token")
+		require.Contains(t, logs.String(), redacted)
+		require.Contains(t, logs.String(), `"msg":"spv-wallet call completed"`)
+		require.Contains(t, logs.String(), `"operation":"GetXPub"`)
+	})
+
+	t.Run("Should redact the access key header", func(t *testing.T) {
+		// given
+		server, requests := flakyServer(0, http.StatusOK, nil)
+		defer server.Close()
+		var logs bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		client, err := New(server.URL, WithAccessKey(fixtures.AccessKeyString), WithLogger(logger))
+		require.NoError(t, err)
+
+		// when
+		_, err = client.GetXPub(context.Background())
+
+		// then
+		require.NoError(t, err)
+		accessKey := (*requests)[0].Header.Get(models.AuthAccessKey)
+		require.NotEmpty(t, accessKey)
+		require.NotContains(t, logs.String(), accessKey)
+	})
+
+	t.Run("Should log the retries and the failed call", func(t *testing.T) {
+		// given
+		server, _ := flakyServer(3, http.StatusServiceUnavailable, nil)
+		defer server.Close()
+		client, logs := newLoggedClient(t, server.URL)
+
+		// when
+		_, err := client.GetXPub(context.Background())
+
+		// then
+		require.Error(t, err)
+		require.Contains(t, logs.String(), `"level":"INFO","msg":"retrying spv-wallet request"`)
+		require.Contains(t, logs.String(), `"level":"WARN","msg":"spv-wallet call failed"`)
+		require.Contains(t, logs.String(), `"error_code":"error-unavailable"`)
+	})
+}
@@ -0,0 +1,285 @@
+package walletclient
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// DefaultMerkleRootsFollowInterval is how often the MerkleRootsFollower syncs when no interval is given
+const DefaultMerkleRootsFollowInterval = 1 * time.Minute
+
+// MerkleRootsFollowerOptions configures the MerkleRootsFollower
+type MerkleRootsFollowerOptions struct {
+	// Interval is how often the merkle roots are synced; DefaultMerkleRootsFollowInterval when zero.
+	Interval time.Duration
+	// SyncTimeout limits every sync; zero means no limit.
+	SyncTimeout time.Duration
+	// Backoff delays the next sync after a failed one, growing with the consecutive failures; DefaultMerkleRootsFollowerBackoff when nil.
+	Backoff *MerkleRootsFollowerBackoff
+}
+
+// MerkleRootsFollowerBackoff describes how the MerkleRootsFollower delays the syncs after the failed ones.
+// The zero fields, except Jitter, take the values of DefaultMerkleRootsFollowerBackoff.
+type MerkleRootsFollowerBackoff struct {
+	// Initial is the delay after the first failed sync.
+	Initial time.Duration
+	// Max caps the delay, however many syncs failed.
+	Max time.Duration
+	// Multiplier is the factor by which the delay grows after every failed sync; values lower than 1 take the default.
+	Multiplier float64
+	// Jitter is the fraction (0..1) of the delay which is randomized; zero disables it.
+	Jitter float64
+}
+
+// DefaultMerkleRootsFollowerBackoff returns the backoff of the MerkleRootsFollower: starting at 1s and growing up to 5 minutes.
+func DefaultMerkleRootsFollowerBackoff() *MerkleRootsFollowerBackoff {
+	return &MerkleRootsFollowerBackoff{
+		Initial:    1 * time.Second,
+		Max:        5 * time.Minute,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}
+}
+
+// withDefaults returns a copy of the backoff with the defaults in place of the zero fields
+func (b *MerkleRootsFollowerBackoff) withDefaults() *MerkleRootsFollowerBackoff {
+	defaults := DefaultMerkleRootsFollowerBackoff()
+	if b == nil {
+		return defaults
+	}
+
+	backoff := *b
+	if backoff.Initial <= 0 {
+		backoff.Initial = defaults.Initial
+	}
+	if backoff.Max <= 0 {
+		backoff.Max = defaults.Max
+	}
+	if backoff.Max < backoff.Initial {
+		backoff.Max = backoff.Initial
+	}
+	if backoff.Multiplier < 1 {
+		backoff.Multiplier = defaults.Multiplier
+	}
+	backoff.Jitter = min(max(backoff.Jitter, 0), 1)
+	return &backoff
+}
+
+// delay returns the delay after the given number of consecutive failed syncs, never above Max
+func (b *MerkleRootsFollowerBackoff) delay(failures int) time.Duration {
+	delay := float64(b.Initial) * math.Pow(b.Multiplier, float64(failures-1))
+	if math.IsNaN(delay) || delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		delay -= delay * b.Jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+// MerkleRootsFollower keeps the repository in sync with the merkle roots of the spv-wallet: it syncs on an interval,
+// or right away when triggered, e.g. by a BlockHeaderEvent of the webhook, and notifies the subscribers of every new root.
+// Failed syncs, including ErrStaleLastEvaluatedKey and ErrSyncMerkleRootsTimeout, are logged and retried with a backoff.
+type MerkleRootsFollower struct {
+	client  *WalletClient
+	repo    MerkleRootsRepository
+	options MerkleRootsFollowerOptions
+	trigger chan struct{}
+
+	syncing sync.Mutex
+
+	mu             sync.RWMutex
+	tip            models.MerkleRoot
+	subscribers    map[int]func(root models.MerkleRoot)
+	nextSubscriber int
+}
+
+// NewMerkleRootsFollower creates a follower syncing the repository with the client;
+// the tip height is known from the start if the repository is a ReorgAwareMerkleRootsRepository
+func NewMerkleRootsFollower(client *WalletClient, repo MerkleRootsRepository, opts *MerkleRootsFollowerOptions) *MerkleRootsFollower {
+	options := MerkleRootsFollowerOptions{}
+	if opts != nil {
+		options = *opts
+	}
+	if options.Interval <= 0 {
+		options.Interval = DefaultMerkleRootsFollowInterval
+	}
+	options.Backoff = options.Backoff.withDefaults()
+
+	follower := &MerkleRootsFollower{
+		client:      client,
+		repo:        repo,
+		options:     options,
+		trigger:     make(chan struct{}, 1),
+		tip:         models.MerkleRoot{MerkleRoot: repo.GetLastMerkleRoot(), BlockHeight: -1},
+		subscribers: make(map[int]func(root models.MerkleRoot)),
+	}
+	if reorgRepo, ok := repo.(ReorgAwareMerkleRootsRepository); ok {
+		follower.tip.BlockHeight = reorgRepo.GetLastMerkleRootHeight()
+	}
+	return follower
+}
+
+// Run syncs the merkle roots until ctx is done
+func (f *MerkleRootsFollower) Run(ctx context.Context) error {
+	ticker := time.NewTicker(f.options.Interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		if err := f.Sync(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			failures++
+			delay := f.options.Backoff.delay(failures)
+			f.client.logger.WarnContext(ctx, "cannot sync merkle roots",
+				slog.String("error", err.Error()),
+				slog.Int("failures", failures),
+				slog.Duration("delay", delay),
+			)
+			if sleepContext(ctx, delay) != nil {
+				return nil
+			}
+			continue
+		}
+
+		failures = 0
+		select {
+		case <-ticker.C:
+		case <-f.trigger:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Sync syncs the merkle roots once, like SyncMerkleRoots, and notifies the subscribers of the new roots
+func (f *MerkleRootsFollower) Sync(ctx context.Context) error {
+	f.syncing.Lock()
+	defer f.syncing.Unlock()
+
+	if f.options.SyncTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.options.SyncTimeout)
+		defer cancel()
+	}
+
+	var repo MerkleRootsRepository = &followedRepository{MerkleRootsRepository: f.repo, follower: f}
+	if reorgRepo, ok := f.repo.(ReorgAwareMerkleRootsRepository); ok {
+		repo = &followedReorgAwareRepository{ReorgAwareMerkleRootsRepository: reorgRepo, follower: f}
+	}
+	return f.client.SyncMerkleRoots(ctx, repo)
+}
+
+// Trigger makes the running follower sync right away, e.g. when the webhook receives a BlockHeaderEvent; it does not block
+func (f *MerkleRootsFollower) Trigger() {
+	select {
+	case f.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Tip returns the last synced merkle root; its BlockHeight is -1 while unknown
+func (f *MerkleRootsFollower) Tip() models.MerkleRoot {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.tip
+}
+
+// TipHeight returns the block height of the last synced merkle root, or -1 while unknown
+func (f *MerkleRootsFollower) TipHeight() int {
+	return f.Tip().BlockHeight
+}
+
+// Subscribe registers a subscriber called with every new merkle root, in the order of block height, after it is saved;
+// it is called on the syncing goroutine, so it should not block. The returned function removes the subscriber.
+func (f *MerkleRootsFollower) Subscribe(subscriber func(root models.MerkleRoot)) (unsubscribe func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := f.nextSubscriber
+	f.nextSubscriber++
+	f.subscribers[id] = subscriber
+
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		delete(f.subscribers, id)
+	}
+}
+
+// saved moves the tip to the saved roots and notifies the subscribers
+func (f *MerkleRootsFollower) saved(merkleRoots []models.MerkleRoot) {
+	if len(merkleRoots) == 0 {
+		return
+	}
+
+	f.mu.Lock()
+	f.tip = merkleRoots[len(merkleRoots)-1]
+	subscribers := make([]func(root models.MerkleRoot), 0, len(f.subscribers))
+	for id := 0; id < f.nextSubscriber; id++ {
+		if subscriber, ok := f.subscribers[id]; ok {
+			subscribers = append(subscribers, subscriber)
+		}
+	}
+	f.mu.Unlock()
+
+	for _, root := range merkleRoots {
+		for _, subscriber := range subscribers {
+			subscriber(root)
+		}
+	}
+}
+
+// rolledBack moves the tip back to the fork point of a chain reorganisation
+func (f *MerkleRootsFollower) rolledBack(height int, root string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.tip = models.MerkleRoot{MerkleRoot: root, BlockHeight: height}
+}
+
+// followedRepository tells the follower about the roots saved by SyncMerkleRoots
+type followedRepository struct {
+	MerkleRootsRepository
+	follower *MerkleRootsFollower
+}
+
+func (r *followedRepository) SaveMerkleRoots(syncedMerkleRoots []models.MerkleRoot) error {
+	if err := r.MerkleRootsRepository.SaveMerkleRoots(syncedMerkleRoots); err != nil {
+		return err
+	}
+	r.follower.saved(syncedMerkleRoots)
+	return nil
+}
+
+// followedReorgAwareRepository tells the follower about the roots saved and rolled back by SyncMerkleRoots
+type followedReorgAwareRepository struct {
+	ReorgAwareMerkleRootsRepository
+	follower *MerkleRootsFollower
+}
+
+func (r *followedReorgAwareRepository) SaveMerkleRoots(syncedMerkleRoots []models.MerkleRoot) error {
+	if err := r.ReorgAwareMerkleRootsRepository.SaveMerkleRoots(syncedMerkleRoots); err != nil {
+		return err
+	}
+	r.follower.saved(syncedMerkleRoots)
+	return nil
+}
+
+func (r *followedReorgAwareRepository) DeleteMerkleRootsAbove(height int) error {
+	if err := r.ReorgAwareMerkleRootsRepository.DeleteMerkleRootsAbove(height); err != nil {
+		return err
+	}
+	r.follower.rolledBack(r.GetLastMerkleRootHeight(), r.GetLastMerkleRoot())
+	return nil
+}
@@ -0,0 +1,206 @@
+package walletclient
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet-go-client/fixtures"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+// rootsRecorder records the merkle roots notified to a subscriber
+type rootsRecorder struct {
+	mu      sync.Mutex
+	heights []int
+}
+
+func (r *rootsRecorder) record(root models.MerkleRoot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.heights = append(r.heights, root.BlockHeight)
+}
+
+func (r *rootsRecorder) recorded() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.heights...)
+}
+
+// runFollower runs the follower until the test ends, and returns a channel closed when Run returns
+func runFollower(t *testing.T, follower *MerkleRootsFollower) (context.CancelFunc, <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		require.NoError(t, follower.Run(ctx))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return cancel, done
+}
+
+func TestMerkleRootsFollower(t *testing.T) {
+	t.Run("Should notify the subscribers of the new roots", func(t *testing.T) {
+		// setup
+		server := fixtures.MockMerkleRootsAPIResponseChain(fixtures.NewMerkleRootsChain(fixtures.MockedSPVWalletData), 4)
+		defer server.Close()
+
+		// given
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		follower := NewMerkleRootsFollower(client, NewMemoryMerkleRootsRepository(fixtures.MockedSPVWalletData[:5]...), nil)
+		recorder := &rootsRecorder{}
+		follower.Subscribe(recorder.record)
+		unsubscribed := &rootsRecorder{}
+		follower.Subscribe(unsubscribed.record)()
+		require.Equal(t, 4, follower.TipHeight())
+
+		// when
+		err = follower.Sync(context.Background())
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, []int{5, 6, 7, 8, 9, 10, 11, 12, 13, 14}, recorder.recorded())
+		require.Empty(t, unsubscribed.recorded())
+		require.Equal(t, fixtures.LastMockedMerkleRoot(), follower.Tip())
+	})
+
+	t.Run("Should notify the roots synced again after a chain reorganisation", func(t *testing.T) {
+		// setup
+		chain := fixtures.ReorgedMockedSPVWalletData(10, 12)
+		server := fixtures.MockMerkleRootsAPIResponseChain(fixtures.NewMerkleRootsChain(chain), 4)
+		defer server.Close()
+
+		// given
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		follower := NewMerkleRootsFollower(client, NewMemoryMerkleRootsRepository(fixtures.MockedSPVWalletData...), nil)
+		recorder := &rootsRecorder{}
+		follower.Subscribe(recorder.record)
+
+		// when
+		err = follower.Sync(context.Background())
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, []int{11, 12}, recorder.recorded())
+		require.Equal(t, chain[12], follower.Tip())
+	})
+
+	t.Run("Should sync right away when triggered", func(t *testing.T) {
+		// setup
+		chain := fixtures.NewMerkleRootsChain(fixtures.MockedSPVWalletData)
+		server := fixtures.MockMerkleRootsAPIResponseChain(chain, 4)
+		defer server.Close()
+
+		// given
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		follower := NewMerkleRootsFollower(client, NewMemoryMerkleRootsRepository(), &MerkleRootsFollowerOptions{Interval: time.Hour})
+		runFollower(t, follower)
+		require.Eventually(t, func() bool { return follower.TipHeight() == 14 }, time.Second, 5*time.Millisecond)
+
+		// when
+		chain.Set(fixtures.ReorgedMockedSPVWalletData(14, 16))
+		follower.Trigger()
+
+		// then
+		require.Eventually(t, func() bool { return follower.TipHeight() == 16 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("Should back off on failed syncs instead of stopping", func(t *testing.T) {
+		// setup
+		chain := fixtures.NewMerkleRootsChain(fixtures.MockedSPVWalletData)
+		chain.Fail(0, 3)
+		server := fixtures.MockMerkleRootsAPIResponseChain(chain, 20)
+		defer server.Close()
+
+		// given
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		follower := NewMerkleRootsFollower(client, NewMemoryMerkleRootsRepository(), &MerkleRootsFollowerOptions{
+			Interval: time.Hour,
+			Backoff:  &MerkleRootsFollowerBackoff{Initial: 5 * time.Millisecond, Multiplier: 2},
+		})
+
+		// when
+		runFollower(t, follower)
+
+		// then
+		require.Eventually(t, func() bool { return follower.TipHeight() == 14 }, time.Second, 5*time.Millisecond)
+		require.Equal(t, 4, chain.Requests())
+	})
+
+	t.Run("Should keep running on a stale last evaluated key until canceled", func(t *testing.T) {
+		// setup
+		server := fixtures.MockMerkleRootsAPIResponseStale()
+		defer server.Close()
+
+		// given
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		follower := NewMerkleRootsFollower(client, fixtures.CreateRepository([]models.MerkleRoot{}), &MerkleRootsFollowerOptions{
+			Backoff: &MerkleRootsFollowerBackoff{Initial: 5 * time.Millisecond},
+		})
+
+		// when
+		cancel, done := runFollower(t, follower)
+
+		// then
+		require.Never(t, func() bool {
+			select {
+			case <-done:
+				return true
+			default:
+				return false
+			}
+		}, 50*time.Millisecond, 5*time.Millisecond)
+		cancel()
+		require.Eventually(t, func() bool {
+			<-done
+			return true
+		}, time.Second, 5*time.Millisecond)
+	})
+}
+
+func TestMerkleRootsFollowerBackoff(t *testing.T) {
+	t.Run("Should grow the delay up to the max", func(t *testing.T) {
+		backoff := (&MerkleRootsFollowerBackoff{Initial: 100 * time.Millisecond, Max: 300 * time.Millisecond, Multiplier: 2}).withDefaults()
+
+		require.Equal(t, 100*time.Millisecond, backoff.delay(1))
+		require.Equal(t, 200*time.Millisecond, backoff.delay(2))
+		require.Equal(t, 300*time.Millisecond, backoff.delay(3))
+	})
+
+	t.Run("Should apply the defaults to the zero fields", func(t *testing.T) {
+		backoff := (&MerkleRootsFollowerBackoff{Initial: 10 * time.Millisecond}).withDefaults()
+
+		require.Equal(t, DefaultMerkleRootsFollowerBackoff().Max, backoff.Max)
+		require.Equal(t, DefaultMerkleRootsFollowerBackoff().Multiplier, backoff.Multiplier)
+		require.Zero(t, backoff.Jitter)
+	})
+
+	t.Run("Should clamp the delay after many failures", func(t *testing.T) {
+		backoff := (&MerkleRootsFollowerBackoff{Initial: time.Second, Multiplier: 10}).withDefaults()
+
+		for _, failures := range []int{64, 1000, math.MaxInt} {
+			require.Equal(t, DefaultMerkleRootsFollowerBackoff().Max, backoff.delay(failures))
+		}
+	})
+
+	t.Run("Should keep the jittered delay within the computed one", func(t *testing.T) {
+		backoff := (&MerkleRootsFollowerBackoff{Initial: 200 * time.Millisecond, Jitter: 0.5}).withDefaults()
+
+		for i := 0; i < 10; i++ {
+			delay := backoff.delay(1)
+			require.GreaterOrEqual(t, delay, 100*time.Millisecond)
+			require.LessOrEqual(t, delay, 200*time.Millisecond)
+		}
+	})
+}
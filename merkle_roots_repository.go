@@ -0,0 +1,276 @@
+package walletclient
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// ReorgAwareMerkleRootsRepository is a MerkleRootsRepository which indexes the merkle roots by block height.
+// SyncMerkleRoots uses it to detect chain reorganisations: when the roots of the spv-wallet no longer link to the stored tip,
+// the roots above the fork point are deleted and synced again.
+type ReorgAwareMerkleRootsRepository interface {
+	MerkleRootsRepository
+	// GetLastMerkleRootHeight should return the block height of the last merkle root, or -1 if empty.
+	GetLastMerkleRootHeight() int
+	// GetMerkleRootAt should return the merkle root at the block height, or an empty string if it is not stored.
+	GetMerkleRootAt(height int) string
+	// DeleteMerkleRootsAbove should delete the merkle roots with a block height greater than height.
+	DeleteMerkleRootsAbove(height int) error
+}
+
+// MemoryMerkleRootsRepository keeps the merkle roots in memory, indexed by block height.
+// Saving a merkle root at or below the last height replaces the roots from that height.
+type MemoryMerkleRootsRepository struct {
+	mu    sync.RWMutex
+	roots map[int]string
+	last  int
+}
+
+// NewMemoryMerkleRootsRepository creates an in-memory repository holding the merkle roots.
+func NewMemoryMerkleRootsRepository(merkleRoots ...models.MerkleRoot) *MemoryMerkleRootsRepository {
+	repo := &MemoryMerkleRootsRepository{roots: make(map[int]string), last: -1}
+	repo.save(merkleRoots)
+	return repo
+}
+
+// GetLastMerkleRoot returns the merkle root with the highest block height, or an empty string if empty.
+func (r *MemoryMerkleRootsRepository) GetLastMerkleRoot() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.roots[r.last]
+}
+
+// GetLastMerkleRootHeight returns the highest block height, or -1 if empty.
+func (r *MemoryMerkleRootsRepository) GetLastMerkleRootHeight() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.last
+}
+
+// GetMerkleRootAt returns the merkle root at the block height, or an empty string if it is not stored.
+func (r *MemoryMerkleRootsRepository) GetMerkleRootAt(height int) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.roots[height]
+}
+
+// SaveMerkleRoots stores the merkle roots, replacing the stored ones from the height of each root.
+func (r *MemoryMerkleRootsRepository) SaveMerkleRoots(syncedMerkleRoots []models.MerkleRoot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.save(syncedMerkleRoots)
+	return nil
+}
+
+// DeleteMerkleRootsAbove deletes the merkle roots with a block height greater than height.
+func (r *MemoryMerkleRootsRepository) DeleteMerkleRootsAbove(height int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deleteAbove(height)
+	return nil
+}
+
+// MerkleRoots returns the stored merkle roots sorted by block height.
+func (r *MemoryMerkleRootsRepository) MerkleRoots() []models.MerkleRoot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	merkleRoots := make([]models.MerkleRoot, 0, len(r.roots))
+	for height, root := range r.roots {
+		merkleRoots = append(merkleRoots, models.MerkleRoot{MerkleRoot: root, BlockHeight: height})
+	}
+	slices.SortFunc(merkleRoots, func(a, b models.MerkleRoot) int {
+		return a.BlockHeight - b.BlockHeight
+	})
+	return merkleRoots
+}
+
+func (r *MemoryMerkleRootsRepository) save(merkleRoots []models.MerkleRoot) {
+	for _, root := range merkleRoots {
+		if root.BlockHeight <= r.last {
+			r.deleteAbove(root.BlockHeight - 1)
+		}
+		r.roots[root.BlockHeight] = root.MerkleRoot
+		r.last = root.BlockHeight
+	}
+}
+
+func (r *MemoryMerkleRootsRepository) deleteAbove(height int) {
+	for stored := range r.roots {
+		if stored > height {
+			delete(r.roots, stored)
+		}
+	}
+	r.last = -1
+	for stored := range r.roots {
+		r.last = max(r.last, stored)
+	}
+}
+
+// FileMerkleRootsRepository keeps the merkle roots in memory and in a file of JSON lines, so they survive restarts.
+// Synced roots are appended to the file, which is rewritten when the roots above a fork point are deleted.
+type FileMerkleRootsRepository struct {
+	*MemoryMerkleRootsRepository
+
+	path string
+	file *os.File
+	mu   sync.Mutex
+}
+
+// NewFileMerkleRootsRepository opens the repository in the file at path, creating the file if it does not exist.
+// The caller should Close it when done.
+func NewFileMerkleRootsRepository(path string) (*FileMerkleRootsRepository, error) {
+	merkleRoots, err := readMerkleRoots(path)
+	if err != nil {
+		return nil, err
+	}
+	memory := NewMemoryMerkleRootsRepository(merkleRoots...)
+
+	// the file is compacted, which also drops the replaced roots and an interrupted last line
+	if err = writeMerkleRoots(path, memory.MerkleRoots()); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileMerkleRootsRepository{MemoryMerkleRootsRepository: memory, path: path, file: file}, nil
+}
+
+// SaveMerkleRoots appends the merkle roots to the file, syncs it to the disk and stores them in memory.
+func (r *FileMerkleRootsRepository) SaveMerkleRoots(syncedMerkleRoots []models.MerkleRoot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var content []byte
+	for _, root := range syncedMerkleRoots {
+		line, err := json.Marshal(root)
+		if err != nil {
+			return err
+		}
+		content = append(append(content, line...), '\n')
+	}
+	if _, err := r.file.Write(content); err != nil {
+		return err
+	}
+	if err := r.file.Sync(); err != nil {
+		return err
+	}
+	return r.MemoryMerkleRootsRepository.SaveMerkleRoots(syncedMerkleRoots)
+}
+
+// DeleteMerkleRootsAbove deletes the merkle roots with a block height greater than height, and rewrites the file with the remaining ones.
+func (r *FileMerkleRootsRepository) DeleteMerkleRootsAbove(height int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remaining := slices.DeleteFunc(r.MerkleRoots(), func(root models.MerkleRoot) bool {
+		return root.BlockHeight > height
+	})
+	if err := writeMerkleRoots(r.path, remaining); err != nil {
+		return err
+	}
+
+	// the rewritten file replaced the one which is open for appending
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_ = r.file.Close()
+	r.file = file
+
+	return r.MemoryMerkleRootsRepository.DeleteMerkleRootsAbove(height)
+}
+
+// Close closes the file of the repository.
+func (r *FileMerkleRootsRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Close()
+}
+
+// readMerkleRoots reads the merkle roots from the file; a last line without a newline, left by an interrupted write, is ignored
+func readMerkleRoots(path string) ([]models.MerkleRoot, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	content = content[:bytes.LastIndexByte(content, '\n')+1]
+
+	var merkleRoots []models.MerkleRoot
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		var root models.MerkleRoot
+		if err = json.Unmarshal(scanner.Bytes(), &root); err != nil {
+			return nil, err
+		}
+		merkleRoots = append(merkleRoots, root)
+	}
+	return merkleRoots, scanner.Err()
+}
+
+// writeMerkleRoots replaces the file with the merkle roots atomically
+func writeMerkleRoots(path string, merkleRoots []models.MerkleRoot) error {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	for _, root := range merkleRoots {
+		if err = encoder.Encode(root); err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+	if err = file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// FileMerkleRootsCheckpoint keeps the checkpoint of SyncMerkleRootsWithOptions in a file, replaced atomically on every save.
+type FileMerkleRootsCheckpoint struct {
+	path string
+}
+
+// NewFileMerkleRootsCheckpoint creates a checkpoint in the file at path; the file is created on the first save.
+func NewFileMerkleRootsCheckpoint(path string) *FileMerkleRootsCheckpoint {
+	return &FileMerkleRootsCheckpoint{path: path}
+}
+
+// LoadCheckpoint reads the merkle root from the file, or returns nil if there is no file.
+func (c *FileMerkleRootsCheckpoint) LoadCheckpoint() (*models.MerkleRoot, error) {
+	merkleRoots, err := readMerkleRoots(c.path)
+	if err != nil || len(merkleRoots) == 0 {
+		return nil, err
+	}
+	return &merkleRoots[len(merkleRoots)-1], nil
+}
+
+// SaveCheckpoint writes the merkle root to the file.
+func (c *FileMerkleRootsCheckpoint) SaveCheckpoint(root models.MerkleRoot) error {
+	return writeMerkleRoots(c.path, []models.MerkleRoot{root})
+}
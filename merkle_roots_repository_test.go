@@ -0,0 +1,116 @@
+package walletclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitcoin-sv/spv-wallet-go-client/fixtures"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryMerkleRootsRepository(t *testing.T) {
+	t.Run("Should return no root when empty", func(t *testing.T) {
+		repo := NewMemoryMerkleRootsRepository()
+
+		require.Equal(t, "", repo.GetLastMerkleRoot())
+		require.Equal(t, -1, repo.GetLastMerkleRootHeight())
+		require.Equal(t, "", repo.GetMerkleRootAt(0))
+	})
+
+	t.Run("Should index the roots by height", func(t *testing.T) {
+		repo := NewMemoryMerkleRootsRepository()
+
+		err := repo.SaveMerkleRoots(fixtures.MockedSPVWalletData)
+
+		require.NoError(t, err)
+		last := fixtures.LastMockedMerkleRoot()
+		require.Equal(t, last.MerkleRoot, repo.GetLastMerkleRoot())
+		require.Equal(t, last.BlockHeight, repo.GetLastMerkleRootHeight())
+		require.Equal(t, fixtures.MockedSPVWalletData[3].MerkleRoot, repo.GetMerkleRootAt(3))
+	})
+
+	t.Run("Should replace the roots from the height of a saved root", func(t *testing.T) {
+		repo := NewMemoryMerkleRootsRepository(fixtures.MockedSPVWalletData...)
+		replacement := models.MerkleRoot{MerkleRoot: "replacement", BlockHeight: 5}
+
+		err := repo.SaveMerkleRoots([]models.MerkleRoot{replacement})
+
+		require.NoError(t, err)
+		require.Equal(t, append(fixtures.MockedSPVWalletData[:5:5], replacement), repo.MerkleRoots())
+	})
+
+	t.Run("Should delete the roots above the height", func(t *testing.T) {
+		repo := NewMemoryMerkleRootsRepository(fixtures.MockedSPVWalletData...)
+
+		err := repo.DeleteMerkleRootsAbove(7)
+
+		require.NoError(t, err)
+		require.Equal(t, 7, repo.GetLastMerkleRootHeight())
+		require.Equal(t, fixtures.MockedSPVWalletData[:8], repo.MerkleRoots())
+
+		err = repo.DeleteMerkleRootsAbove(-1)
+
+		require.NoError(t, err)
+		require.Equal(t, -1, repo.GetLastMerkleRootHeight())
+		require.Empty(t, repo.MerkleRoots())
+	})
+}
+
+func TestFileMerkleRootsRepository(t *testing.T) {
+	t.Run("Should keep the roots across reopening", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "merkleroots.jsonl")
+		repo, err := NewFileMerkleRootsRepository(path)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.SaveMerkleRoots(fixtures.MockedSPVWalletData[:10]))
+		require.NoError(t, repo.SaveMerkleRoots(fixtures.MockedSPVWalletData[10:]))
+		require.NoError(t, repo.Close())
+
+		reopened, err := NewFileMerkleRootsRepository(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		require.Equal(t, fixtures.MockedSPVWalletData, reopened.MerkleRoots())
+	})
+
+	t.Run("Should keep the rollback across reopening", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "merkleroots.jsonl")
+		repo, err := NewFileMerkleRootsRepository(path)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.SaveMerkleRoots(fixtures.MockedSPVWalletData))
+		require.NoError(t, repo.DeleteMerkleRootsAbove(9))
+		reorged := fixtures.ReorgedMockedSPVWalletData(9, 12)[10:]
+		require.NoError(t, repo.SaveMerkleRoots(reorged))
+		require.NoError(t, repo.Close())
+
+		reopened, err := NewFileMerkleRootsRepository(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		require.Equal(t, append(fixtures.MockedSPVWalletData[:10:10], reorged...), reopened.MerkleRoots())
+	})
+
+	t.Run("Should ignore an interrupted last line", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "merkleroots.jsonl")
+		repo, err := NewFileMerkleRootsRepository(path)
+		require.NoError(t, err)
+		require.NoError(t, repo.SaveMerkleRoots(fixtures.MockedSPVWalletData[:3]))
+		require.NoError(t, repo.Close())
+
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+		require.NoError(t, err)
+		_, err = file.WriteString(`{"merkleRoot":"interr`)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		reopened, err := NewFileMerkleRootsRepository(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+		require.NoError(t, reopened.SaveMerkleRoots(fixtures.MockedSPVWalletData[3:5]))
+
+		require.Equal(t, fixtures.MockedSPVWalletData[:5], reopened.MerkleRoots())
+	})
+}
@@ -0,0 +1,313 @@
+package mock
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/bitcoin-sv/spv-wallet-go-client/utils"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/bitcoin-sv/spv-wallet/models/response"
+)
+
+// adminXPubRequest is the body of the admin xpub endpoint
+type adminXPubRequest struct {
+	Key      string         `json:"key"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+// adminPaymailRequest is the body of the admin paymail endpoints
+type adminPaymailRequest struct {
+	Key        string `json:"key"`
+	Address    string `json:"address"`
+	PublicName string `json:"public_name"`
+	Avatar     string `json:"avatar"`
+}
+
+// adminContactRequest is the body of the admin update contact endpoint
+type adminContactRequest struct {
+	FullName string         `json:"fullName"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+func (m *SPVWallet) adminGetStatus(_ *request) (any, error) {
+	return true, nil
+}
+
+func (m *SPVWallet) adminGetStats(_ *request) (any, error) {
+	stats := &models.AdminStats{
+		Destinations:       int64(len(m.destinations)),
+		PaymailAddresses:   int64(len(m.paymails)),
+		Transactions:       int64(len(m.transactions)),
+		TransactionsPerDay: make(map[string]interface{}),
+		Utxos:              int64(len(m.utxos)),
+		UtxosPerType:       make(map[string]interface{}),
+		XPubs:              int64(len(m.xpubs)),
+	}
+	for _, utxo := range m.utxos {
+		if utxo.SpendingTxID == "" {
+			stats.Balance += int64(utxo.Satoshis)
+		}
+		count, _ := stats.UtxosPerType[utxo.Type].(int64)
+		stats.UtxosPerType[utxo.Type] = count + 1
+	}
+	for _, transaction := range m.transactions {
+		day := transaction.CreatedAt.Format("20060102")
+		count, _ := stats.TransactionsPerDay[day].(int64)
+		stats.TransactionsPerDay[day] = count + 1
+	}
+	return stats, nil
+}
+
+func (m *SPVWallet) adminNewXPub(req *request) (any, error) {
+	var body adminXPubRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+
+	entry, err := m.addXPub(body.Key, body.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return m.xpubModel(entry), nil
+}
+
+// allXPubs returns the models of all the xpubs, in the order they were registered
+func (m *SPVWallet) allXPubs() []*models.Xpub {
+	xpubs := make([]*models.Xpub, 0, len(m.xpubs))
+	for _, entry := range m.xpubs {
+		xpubs = append(xpubs, m.xpubModel(entry))
+	}
+	slices.SortStableFunc(xpubs, func(a, b *models.Xpub) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return xpubs
+}
+
+func (m *SPVWallet) adminSearchXPubs(req *request) (any, error) {
+	return search(req, m.allXPubs())
+}
+
+func (m *SPVWallet) adminCountXPubs(req *request) (any, error) {
+	return count(req, m.allXPubs())
+}
+
+func (m *SPVWallet) adminSearchAccessKeys(req *request) (any, error) {
+	return search(req, m.accessKeys)
+}
+
+func (m *SPVWallet) adminCountAccessKeys(req *request) (any, error) {
+	return count(req, m.accessKeys)
+}
+
+// adminSearchBlockHeaders returns no block headers, the mock does not follow any chain
+func (m *SPVWallet) adminSearchBlockHeaders(_ *request) (any, error) {
+	return []*models.BlockHeader{}, nil
+}
+
+// adminCountBlockHeaders counts a block header for each merkle root added to the mock
+func (m *SPVWallet) adminCountBlockHeaders(_ *request) (any, error) {
+	return len(m.merkleRoots), nil
+}
+
+func (m *SPVWallet) adminSearchDestinations(req *request) (any, error) {
+	return search(req, m.destinations)
+}
+
+func (m *SPVWallet) adminCountDestinations(req *request) (any, error) {
+	return count(req, m.destinations)
+}
+
+func (m *SPVWallet) adminSearchUtxos(req *request) (any, error) {
+	return search(req, m.utxos)
+}
+
+func (m *SPVWallet) adminCountUtxos(req *request) (any, error) {
+	return count(req, m.utxos)
+}
+
+func (m *SPVWallet) adminSearchTransactions(req *request) (any, error) {
+	return search(req, m.transactions)
+}
+
+func (m *SPVWallet) adminCountTransactions(req *request) (any, error) {
+	return count(req, m.transactions)
+}
+
+func (m *SPVWallet) adminRecordTransaction(req *request) (any, error) {
+	var body recordRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	return m.record(body.Hex, nil, body.Metadata)
+}
+
+func (m *SPVWallet) paymailByAddress(address string) *models.PaymailAddress {
+	for _, paymail := range m.paymails {
+		if paymail.Alias+"@"+paymail.Domain == address {
+			return paymail
+		}
+	}
+	return nil
+}
+
+func (m *SPVWallet) adminCreatePaymail(req *request) (any, error) {
+	var body adminPaymailRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+
+	alias, domain, ok := strings.Cut(body.Address, "@")
+	if !ok || alias == "" || domain == "" {
+		return nil, ErrInvalidPaymailAddress
+	}
+	if len(m.options.PaymailDomains) > 0 && !slices.Contains(m.options.PaymailDomains, domain) {
+		return nil, ErrInvalidPaymailDomain
+	}
+	xpubID := utils.Hash(body.Key)
+	if _, exists := m.xpubs[xpubID]; !exists {
+		return nil, ErrXPubNotFound
+	}
+	if m.paymailByAddress(body.Address) != nil {
+		return nil, ErrPaymailAlreadyExists
+	}
+
+	paymail := &models.PaymailAddress{
+		Model:      m.newModel(nil),
+		ID:         utils.Hash(body.Address),
+		XpubID:     xpubID,
+		Alias:      alias,
+		Domain:     domain,
+		PublicName: body.PublicName,
+		Avatar:     body.Avatar,
+	}
+	m.paymails = append(m.paymails, paymail)
+	return paymail, nil
+}
+
+func (m *SPVWallet) adminGetPaymail(req *request) (any, error) {
+	var body adminPaymailRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+
+	paymail := m.paymailByAddress(body.Address)
+	if paymail == nil {
+		return nil, ErrPaymailNotFound
+	}
+	return paymail, nil
+}
+
+func (m *SPVWallet) adminDeletePaymail(req *request) (any, error) {
+	var body adminPaymailRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+
+	paymail := m.paymailByAddress(body.Address)
+	if paymail == nil {
+		return nil, ErrPaymailNotFound
+	}
+	m.paymails = slices.DeleteFunc(m.paymails, func(p *models.PaymailAddress) bool {
+		return p == paymail
+	})
+	return nil, nil
+}
+
+func (m *SPVWallet) adminSearchPaymails(req *request) (any, error) {
+	return search(req, m.paymails)
+}
+
+func (m *SPVWallet) adminCountPaymails(req *request) (any, error) {
+	return count(req, m.paymails)
+}
+
+func (m *SPVWallet) allContacts() []*models.Contact {
+	contacts := make([]*models.Contact, 0, len(m.contacts))
+	for _, entry := range m.contacts {
+		contacts = append(contacts, entry.contact)
+	}
+	return contacts
+}
+
+func (m *SPVWallet) adminSearchContacts(req *request) (any, error) {
+	return searchPaged(req, m.allContacts())
+}
+
+func (m *SPVWallet) adminUpdateContact(req *request) (any, error) {
+	var body adminContactRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+
+	contact := m.contactByID(req.PathValue("id"))
+	if contact == nil {
+		return nil, ErrContactNotFound
+	}
+	contact.FullName = body.FullName
+	m.updateMetadata(&contact.Model, body.Metadata)
+	return contact, nil
+}
+
+func (m *SPVWallet) adminDeleteContact(req *request) (any, error) {
+	contact := m.contactByID(req.PathValue("id"))
+	if contact == nil {
+		return nil, ErrContactNotFound
+	}
+	m.contacts = slices.DeleteFunc(m.contacts, func(entry *contactEntry) bool {
+		return entry.contact == contact
+	})
+	return nil, nil
+}
+
+func (m *SPVWallet) adminAcceptContact(req *request) (any, error) {
+	return m.changeContactStatus(m.contactByID(req.PathValue("id")), response.ContactAwaitAccept, response.ContactNotConfirmed)
+}
+
+func (m *SPVWallet) adminRejectContact(req *request) (any, error) {
+	return m.changeContactStatus(m.contactByID(req.PathValue("id")), response.ContactAwaitAccept, response.ContactRejected)
+}
+
+func (m *SPVWallet) adminSubscribeWebhook(req *request) (any, error) {
+	var body models.SubscribeRequestBody
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range m.webhooks {
+		if entry.webhook.URL == body.URL {
+			entry.tokenHeader, entry.tokenValue = body.TokenHeader, body.TokenValue
+			entry.webhook.Banned = false
+			return nil, nil
+		}
+	}
+	m.webhooks = append(m.webhooks, &webhookEntry{
+		webhook:     &models.Webhook{URL: body.URL},
+		tokenHeader: body.TokenHeader,
+		tokenValue:  body.TokenValue,
+	})
+	return nil, nil
+}
+
+func (m *SPVWallet) adminUnsubscribeWebhook(req *request) (any, error) {
+	var body models.UnsubscribeRequestBody
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+
+	subscribed := len(m.webhooks)
+	m.webhooks = slices.DeleteFunc(m.webhooks, func(entry *webhookEntry) bool {
+		return entry.webhook.URL == body.URL
+	})
+	if len(m.webhooks) == subscribed {
+		return nil, ErrWebhookNotFound
+	}
+	return nil, nil
+}
+
+func (m *SPVWallet) adminGetWebhooks(_ *request) (any, error) {
+	webhooks := make([]*models.Webhook, 0, len(m.webhooks))
+	for _, entry := range m.webhooks {
+		webhooks = append(webhooks, entry.webhook)
+	}
+	return webhooks, nil
+}
@@ -0,0 +1,57 @@
+package mock
+
+import (
+	"net/http"
+
+	"github.com/bitcoin-sv/spv-wallet-go-client/utils"
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// caller is the authenticated identity of a request
+type caller struct {
+	xpubID string
+	admin  bool
+}
+
+// authorize authenticates the request and checks that the caller has the required access
+func (m *SPVWallet) authorize(r *http.Request, body []byte, required access) (*caller, error) {
+	caller, err := m.authenticate(r, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if required == accessAdmin || (required == accessAny && caller.admin) {
+		if !caller.admin {
+			return nil, ErrNotAnAdminKey
+		}
+		return caller, nil
+	}
+
+	if _, ok := m.xpubs[caller.xpubID]; !ok {
+		return nil, ErrUnknownXPub
+	}
+	return caller, nil
+}
+
+// authenticate resolves the caller from the xpub or access key auth headers and verifies the signature
+func (m *SPVWallet) authenticate(r *http.Request, body []byte) (*caller, error) {
+	rawXPub := r.Header.Get(models.AuthHeader)
+	if rawXPub != "" && r.Header.Get(models.AuthSignature) == "" && m.options.AllowUnsignedRequests {
+		return &caller{xpubID: utils.Hash(rawXPub), admin: rawXPub == m.adminXPub}, nil
+	}
+
+	payload, err := m.verifier.VerifyRequest(r, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.XPub != "" {
+		return &caller{xpubID: utils.Hash(payload.XPub), admin: payload.XPub == m.adminXPub}, nil
+	}
+
+	key := m.accessKeyByID(utils.Hash(payload.AccessKey))
+	if key == nil || key.RevokedAt != nil {
+		return nil, ErrUnknownAccessKey
+	}
+	return &caller{xpubID: key.XpubID}, nil
+}
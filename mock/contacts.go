@@ -0,0 +1,161 @@
+package mock
+
+import (
+	"encoding/hex"
+
+	bip32 "github.com/bitcoin-sv/go-sdk/compat/bip32"
+	"github.com/bitcoin-sv/spv-wallet-go-client/utils"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/bitcoin-sv/spv-wallet/models/response"
+)
+
+// upsertContactRequest is the body of the upsert contact endpoint
+type upsertContactRequest struct {
+	FullName         string         `json:"fullName"`
+	Metadata         map[string]any `json:"metadata"`
+	RequesterPaymail string         `json:"requesterPaymail"`
+}
+
+// pkiPublicKey returns the PKI public key (m/0/0/0) of the xpub, which the clients use to compute the TOTP of a contact
+func pkiPublicKey(entry *xpubEntry) (string, error) {
+	key, err := bip32.GetHDKeyByPath(entry.key, utils.ChainExternal, 0)
+	if err != nil {
+		return "", err
+	}
+	if key, err = key.Child(0); err != nil {
+		return "", err
+	}
+	publicKey, err := key.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(publicKey.SerializeCompressed()), nil
+}
+
+// contactsOf returns the contacts of the xpub
+func (m *SPVWallet) contactsOf(xpubID string) []*models.Contact {
+	var contacts []*models.Contact
+	for _, entry := range m.contacts {
+		if entry.xpubID == xpubID {
+			contacts = append(contacts, entry.contact)
+		}
+	}
+	return contacts
+}
+
+func (m *SPVWallet) contactByPaymail(xpubID, paymail string) *models.Contact {
+	for _, entry := range m.contacts {
+		if entry.xpubID == xpubID && entry.contact.Paymail == paymail {
+			return entry.contact
+		}
+	}
+	return nil
+}
+
+func (m *SPVWallet) contactByID(id string) *models.Contact {
+	for _, entry := range m.contacts {
+		if entry.contact.ID == id {
+			return entry.contact
+		}
+	}
+	return nil
+}
+
+// addContact adds a new contact to the xpub
+func (m *SPVWallet) addContact(xpubID, paymail, fullName, pubKey string, status response.ContactStatus, metadata map[string]any) (*models.Contact, error) {
+	id, err := utils.RandomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	contact := &models.Contact{
+		Model:    m.newModel(metadata),
+		ID:       id,
+		FullName: fullName,
+		Paymail:  paymail,
+		PubKey:   pubKey,
+		Status:   status,
+	}
+	m.contacts = append(m.contacts, &contactEntry{xpubID: xpubID, contact: contact})
+	return contact, nil
+}
+
+// upsertContact adds or updates a contact; when the paymail is hosted by the mock, the counterparty receives
+// an invitation (an awaiting contact), as with PIKE
+func (m *SPVWallet) upsertContact(req *request) (any, error) {
+	var body upsertContactRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	paymail := req.PathValue("paymail")
+
+	var requester *models.PaymailAddress
+	if body.RequesterPaymail != "" {
+		if requester = m.paymailByAddress(body.RequesterPaymail); requester == nil || requester.XpubID != req.caller.xpubID {
+			return nil, ErrInvalidRequesterPaymail
+		}
+	}
+
+	if contact := m.contactByPaymail(req.caller.xpubID, paymail); contact != nil {
+		contact.FullName = body.FullName
+		m.updateMetadata(&contact.Model, body.Metadata)
+		return contact, nil
+	}
+
+	var pubKey string
+	counterparty := m.paymailByAddress(paymail)
+	if counterparty != nil {
+		var err error
+		if pubKey, err = pkiPublicKey(m.xpubs[counterparty.XpubID]); err != nil {
+			return nil, err
+		}
+	}
+
+	contact, err := m.addContact(req.caller.xpubID, paymail, body.FullName, pubKey, response.ContactNotConfirmed, body.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	if counterparty != nil && requester != nil && m.contactByPaymail(counterparty.XpubID, requester.Alias+"@"+requester.Domain) == nil {
+		requesterPubKey, err := pkiPublicKey(m.xpubs[req.caller.xpubID])
+		if err != nil {
+			return nil, err
+		}
+		if _, err = m.addContact(counterparty.XpubID, requester.Alias+"@"+requester.Domain, requester.PublicName, requesterPubKey, response.ContactAwaitAccept, nil); err != nil {
+			return nil, err
+		}
+	}
+	return contact, nil
+}
+
+// changeContactStatus moves the contact from the expected status to the next one
+func (m *SPVWallet) changeContactStatus(contact *models.Contact, expected, next response.ContactStatus) (*models.Contact, error) {
+	if contact == nil {
+		return nil, ErrContactNotFound
+	}
+	if contact.Status != expected {
+		return nil, ErrContactInvalidStatus
+	}
+	contact.Status = next
+	contact.UpdatedAt = m.options.Now().UTC()
+	return contact, nil
+}
+
+func (m *SPVWallet) acceptContact(req *request) (any, error) {
+	contact := m.contactByPaymail(req.caller.xpubID, req.PathValue("paymail"))
+	return m.changeContactStatus(contact, response.ContactAwaitAccept, response.ContactNotConfirmed)
+}
+
+func (m *SPVWallet) rejectContact(req *request) (any, error) {
+	contact := m.contactByPaymail(req.caller.xpubID, req.PathValue("paymail"))
+	return m.changeContactStatus(contact, response.ContactAwaitAccept, response.ContactRejected)
+}
+
+func (m *SPVWallet) confirmContact(req *request) (any, error) {
+	contact := m.contactByPaymail(req.caller.xpubID, req.PathValue("paymail"))
+	return m.changeContactStatus(contact, response.ContactNotConfirmed, response.ContactConfirmed)
+}
+
+func (m *SPVWallet) searchContacts(req *request) (any, error) {
+	return searchPaged(req, m.contactsOf(req.caller.xpubID))
+}
@@ -0,0 +1,111 @@
+package mock
+
+import (
+	walletclient "github.com/bitcoin-sv/spv-wallet-go-client"
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// ErrMissingAuthHeader is when neither the xpub nor the access key header is set
+var ErrMissingAuthHeader = walletclient.ErrMissingAuthHeader
+
+// ErrMissingSignature is when a request is not signed and unsigned requests are not allowed
+var ErrMissingSignature = walletclient.ErrMissingSignature
+
+// ErrAuthHashMismatch is when the auth hash header does not match the hash of the body
+var ErrAuthHashMismatch = walletclient.ErrAuthHashMismatch
+
+// ErrSignatureExpired is when the auth time of the request is outside of the signature TTL
+var ErrSignatureExpired = walletclient.ErrSignatureExpired
+
+// ErrInvalidSignature is when the signature does not match the xpub or the access key
+var ErrInvalidSignature = walletclient.ErrInvalidSignature
+
+// ErrNonceReplayed is when the auth nonce of the request has already been used
+var ErrNonceReplayed = walletclient.ErrNonceReplayed
+
+// ErrUnknownXPub is when the xpub of the request is not registered
+var ErrUnknownXPub = models.SPVError{Message: "xpub is not registered", StatusCode: 401, Code: "error-unauthorized-xpub-not-registered"}
+
+// ErrUnknownAccessKey is when the access key of the request does not exist or is revoked
+var ErrUnknownAccessKey = models.SPVError{Message: "access key does not exist or is revoked", StatusCode: 401, Code: "error-unauthorized-access-key-unknown"}
+
+// ErrNotAnAdminKey is when an admin endpoint is called without the admin xpub
+var ErrNotAnAdminKey = models.SPVError{Message: "xpub provided is not an admin key", StatusCode: 401, Code: "error-unauthorized-not-an-admin-key"}
+
+// ErrRouteNotFound is when the endpoint is not served by the mock
+var ErrRouteNotFound = models.SPVError{Message: "route not found", StatusCode: 404, Code: "error-route-not-found"}
+
+// ErrCannotBindRequest is when the request body cannot be decoded
+var ErrCannotBindRequest = models.SPVError{Message: "cannot bind request body", StatusCode: 400, Code: "error-bind-body-invalid"}
+
+// ErrInvalidXPub is when an xpub cannot be parsed
+var ErrInvalidXPub = models.SPVError{Message: "xpub is invalid", StatusCode: 400, Code: "error-xpub-invalid"}
+
+// ErrXPubNotFound is when the xpub does not exist
+var ErrXPubNotFound = models.SPVError{Message: "xpub not found", StatusCode: 404, Code: "error-xpub-not-found"}
+
+// ErrXPubAlreadyExists is when the xpub is already registered
+var ErrXPubAlreadyExists = models.SPVError{Message: "xpub already exists", StatusCode: 409, Code: "error-xpub-already-exists"}
+
+// ErrAccessKeyNotFound is when the access key does not exist
+var ErrAccessKeyNotFound = models.SPVError{Message: "access key not found", StatusCode: 404, Code: "error-access-key-not-found"}
+
+// ErrDestinationNotFound is when the destination does not exist
+var ErrDestinationNotFound = models.SPVError{Message: "destination not found", StatusCode: 404, Code: "error-destination-not-found"}
+
+// ErrUtxoNotFound is when the utxo does not exist
+var ErrUtxoNotFound = models.SPVError{Message: "utxo not found", StatusCode: 404, Code: "error-utxo-not-found"}
+
+// ErrTransactionNotFound is when the transaction does not exist
+var ErrTransactionNotFound = models.SPVError{Message: "transaction not found", StatusCode: 404, Code: "error-transaction-not-found"}
+
+// ErrDraftNotFound is when the draft transaction does not exist or cannot be recorded anymore
+var ErrDraftNotFound = models.SPVError{Message: "draft transaction not found", StatusCode: 404, Code: "error-draft-transaction-not-found"}
+
+// ErrInvalidTransaction is when the transaction hex cannot be parsed or spends more than its inputs
+var ErrInvalidTransaction = models.SPVError{Message: "transaction is invalid", StatusCode: 400, Code: "error-transaction-invalid"}
+
+// ErrInvalidInput is when an input does not unlock the utxo it spends
+var ErrInvalidInput = models.SPVError{Message: "transaction input is invalid", StatusCode: 400, Code: "error-transaction-input-invalid"}
+
+// ErrInvalidOutput is when an output of a draft cannot be resolved to a locking script
+var ErrInvalidOutput = models.SPVError{Message: "transaction output is invalid", StatusCode: 400, Code: "error-transaction-output-invalid"}
+
+// ErrUtxoAlreadySpent is when a transaction spends an already spent utxo
+var ErrUtxoAlreadySpent = models.SPVError{Message: "utxo has already been spent", StatusCode: 400, Code: "error-utxo-already-spent"}
+
+// ErrUtxoReserved is when a transaction spends a utxo reserved by another draft
+var ErrUtxoReserved = models.SPVError{Message: "utxo is reserved by another draft transaction", StatusCode: 400, Code: "error-utxo-reserved"}
+
+// ErrTransactionAlreadyRecorded is when the transaction has already been recorded
+var ErrTransactionAlreadyRecorded = models.SPVError{Message: "transaction already recorded", StatusCode: 409, Code: "error-transaction-already-recorded"}
+
+// ErrTransactionNotRelated is when a transaction neither spends nor pays a known destination
+var ErrTransactionNotRelated = models.SPVError{Message: "transaction does not spend or pay any known destination", StatusCode: 400, Code: "error-transaction-not-related"}
+
+// ErrInvalidPaymailAddress is when a paymail address is not in the alias@domain form
+var ErrInvalidPaymailAddress = models.SPVError{Message: "paymail address is invalid", StatusCode: 400, Code: "error-paymail-address-invalid"}
+
+// ErrInvalidPaymailDomain is when the domain of a paymail is not one of the configured domains
+var ErrInvalidPaymailDomain = models.SPVError{Message: "paymail domain is not supported", StatusCode: 400, Code: "error-paymail-domain-invalid"}
+
+// ErrPaymailNotFound is when the paymail does not exist
+var ErrPaymailNotFound = models.SPVError{Message: "paymail not found", StatusCode: 404, Code: "error-paymail-not-found"}
+
+// ErrPaymailAlreadyExists is when the paymail is already registered
+var ErrPaymailAlreadyExists = models.SPVError{Message: "paymail already exists", StatusCode: 409, Code: "error-paymail-already-exists"}
+
+// ErrContactNotFound is when the contact does not exist
+var ErrContactNotFound = models.SPVError{Message: "contact not found", StatusCode: 404, Code: "error-contact-not-found"}
+
+// ErrContactInvalidStatus is when the contact cannot change its status with the requested action
+var ErrContactInvalidStatus = models.SPVError{Message: "contact status does not allow this action", StatusCode: 400, Code: "error-contact-status-invalid"}
+
+// ErrInvalidRequesterPaymail is when the requester paymail does not belong to the caller
+var ErrInvalidRequesterPaymail = models.SPVError{Message: "requester paymail does not belong to the xpub", StatusCode: 400, Code: "error-contact-requester-invalid"}
+
+// ErrWebhookNotFound is when the webhook is not subscribed
+var ErrWebhookNotFound = models.SPVError{Message: "webhook not found", StatusCode: 404, Code: "error-webhook-not-found"}
+
+// ErrInvalidLastEvaluatedKey is when the last evaluated key of the merkle roots is unknown
+var ErrInvalidLastEvaluatedKey = models.SPVError{Message: "last evaluated key is not a known merkle root", StatusCode: 400, Code: "error-merkle-roots-invalid-last-evaluated-key"}
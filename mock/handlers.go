@@ -0,0 +1,327 @@
+package mock
+
+import (
+	"encoding/hex"
+	"slices"
+	"strconv"
+
+	bip32 "github.com/bitcoin-sv/go-sdk/compat/bip32"
+	ec "github.com/bitcoin-sv/go-sdk/primitives/ec"
+	"github.com/bitcoin-sv/go-sdk/transaction/template/p2pkh"
+	"github.com/bitcoin-sv/spv-wallet-go-client/utils"
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// metadataRequest is the body of the endpoints updating the metadata of a model
+type metadataRequest struct {
+	ID            string         `json:"id"`
+	Address       string         `json:"address"`
+	LockingScript string         `json:"locking_script"`
+	Metadata      map[string]any `json:"metadata"`
+}
+
+func (m *SPVWallet) getSharedConfig(_ *request) (any, error) {
+	return &models.SharedConfig{
+		PaymailDomains: m.options.PaymailDomains,
+		ExperimentalFeatures: map[string]bool{
+			"pike_contacts_enabled": true,
+			"pike_payment_enabled":  false,
+		},
+	}, nil
+}
+
+func (m *SPVWallet) getMerkleRoots(req *request) (any, error) {
+	start := 0
+	if lastEvaluatedKey := req.URL.Query().Get("lastEvaluatedKey"); lastEvaluatedKey != "" {
+		index := slices.IndexFunc(m.merkleRoots, func(root models.MerkleRoot) bool {
+			return root.MerkleRoot == lastEvaluatedKey
+		})
+		if index < 0 {
+			return nil, ErrInvalidLastEvaluatedKey
+		}
+		start = index + 1
+	}
+
+	pageSize := m.options.MerkleRootsPageSize
+	if batchSize, err := strconv.Atoi(req.URL.Query().Get("batchSize")); err == nil && batchSize > 0 {
+		pageSize = batchSize
+	}
+	end := min(start+max(pageSize, 1), len(m.merkleRoots))
+	page := &models.ExclusiveStartKeyPage[[]models.MerkleRoot]{
+		Content: slices.Clone(m.merkleRoots[start:end]),
+		Page: models.ExclusiveStartKeyPageInfo{
+			TotalElements: len(m.merkleRoots),
+			Size:          end - start,
+		},
+	}
+	if end < len(m.merkleRoots) {
+		page.Page.LastEvaluatedKey = m.merkleRoots[end-1].MerkleRoot
+	}
+	return page, nil
+}
+
+// addXPub registers the xpub
+func (m *SPVWallet) addXPub(rawXPub string, metadata map[string]any) (*xpubEntry, error) {
+	key, err := bip32.GetHDKeyFromExtendedPublicKey(rawXPub)
+	if err != nil {
+		return nil, ErrInvalidXPub.Wrap(err)
+	}
+
+	id := utils.Hash(rawXPub)
+	if _, ok := m.xpubs[id]; ok {
+		return nil, ErrXPubAlreadyExists
+	}
+
+	entry := &xpubEntry{key: key, model: &models.Xpub{Model: m.newModel(metadata), ID: id}}
+	m.xpubs[id] = entry
+	return entry, nil
+}
+
+// xpubModel returns the model of the xpub with its current balance
+func (m *SPVWallet) xpubModel(entry *xpubEntry) *models.Xpub {
+	var balance uint64
+	for _, utxo := range m.utxos {
+		if utxo.XpubID == entry.model.ID && utxo.SpendingTxID == "" {
+			balance += utxo.Satoshis
+		}
+	}
+	entry.model.CurrentBalance = balance
+	return entry.model
+}
+
+func (m *SPVWallet) getXPub(req *request) (any, error) {
+	return m.xpubModel(m.xpubs[req.caller.xpubID]), nil
+}
+
+func (m *SPVWallet) updateXPub(req *request) (any, error) {
+	var body metadataRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+
+	xPub := m.xpubModel(m.xpubs[req.caller.xpubID])
+	m.updateMetadata(&xPub.Model, body.Metadata)
+	return xPub, nil
+}
+
+func (m *SPVWallet) accessKeyByID(id string) *models.AccessKey {
+	for _, key := range m.accessKeys {
+		if key.ID == id {
+			return key
+		}
+	}
+	return nil
+}
+
+// accessKeysOf returns the access keys of the xpub
+func (m *SPVWallet) accessKeysOf(xpubID string) []*models.AccessKey {
+	var keys []*models.AccessKey
+	for _, key := range m.accessKeys {
+		if key.XpubID == xpubID {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (m *SPVWallet) createAccessKey(req *request) (any, error) {
+	var body metadataRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+
+	privateKey, err := ec.NewPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	publicKey := hex.EncodeToString(privateKey.PubKey().SerializeCompressed())
+
+	key := &models.AccessKey{
+		Model:  m.newModel(body.Metadata),
+		ID:     utils.Hash(publicKey),
+		XpubID: req.caller.xpubID,
+	}
+	m.accessKeys = append(m.accessKeys, key)
+
+	// the private key is returned only once, on creation
+	created := *key
+	created.Key = hex.EncodeToString(privateKey.Serialize())
+	return &created, nil
+}
+
+func (m *SPVWallet) getAccessKey(req *request) (any, error) {
+	key := m.accessKeyByID(req.URL.Query().Get("id"))
+	if key == nil || key.XpubID != req.caller.xpubID {
+		return nil, ErrAccessKeyNotFound
+	}
+	return key, nil
+}
+
+func (m *SPVWallet) revokeAccessKey(req *request) (any, error) {
+	key := m.accessKeyByID(req.URL.Query().Get("id"))
+	if key == nil || key.XpubID != req.caller.xpubID {
+		return nil, ErrAccessKeyNotFound
+	}
+
+	if key.RevokedAt == nil {
+		now := m.options.Now().UTC()
+		key.RevokedAt = &now
+		key.UpdatedAt = now
+	}
+	return key, nil
+}
+
+func (m *SPVWallet) searchAccessKeys(req *request) (any, error) {
+	return search(req, m.accessKeysOf(req.caller.xpubID))
+}
+
+func (m *SPVWallet) countAccessKeys(req *request) (any, error) {
+	return count(req, m.accessKeysOf(req.caller.xpubID))
+}
+
+// newDestination derives the next P2PKH destination of the xpub on the given chain
+func (m *SPVWallet) newDestination(entry *xpubEntry, chain uint32, metadata map[string]any) (*models.Destination, error) {
+	num := &entry.model.NextExternalNum
+	if chain == utils.ChainInternal {
+		num = &entry.model.NextInternalNum
+	}
+
+	key, err := bip32.GetHDKeyByPath(entry.key, chain, *num)
+	if err != nil {
+		return nil, err
+	}
+	address, err := bip32.GetAddressFromHDKey(key)
+	if err != nil {
+		return nil, err
+	}
+	lockingScript, err := p2pkh.Lock(address)
+	if err != nil {
+		return nil, err
+	}
+
+	destination := &models.Destination{
+		Model:         m.newModel(metadata),
+		ID:            utils.Hash(lockingScript.String()),
+		XpubID:        entry.model.ID,
+		LockingScript: lockingScript.String(),
+		Type:          "pubkeyhash",
+		Chain:         chain,
+		Num:           *num,
+		Address:       address.AddressString,
+	}
+	*num++
+	m.destinations = append(m.destinations, destination)
+	return destination, nil
+}
+
+func (m *SPVWallet) destinationByLockingScript(lockingScript string) *models.Destination {
+	for _, destination := range m.destinations {
+		if destination.LockingScript == lockingScript {
+			return destination
+		}
+	}
+	return nil
+}
+
+// findDestination returns the destination of the xpub with the given id, address or locking script
+func (m *SPVWallet) findDestination(xpubID, id, address, lockingScript string) (*models.Destination, error) {
+	for _, destination := range m.destinations {
+		if destination.XpubID != xpubID {
+			continue
+		}
+		if (id != "" && destination.ID == id) ||
+			(address != "" && destination.Address == address) ||
+			(lockingScript != "" && destination.LockingScript == lockingScript) {
+			return destination, nil
+		}
+	}
+	return nil, ErrDestinationNotFound
+}
+
+// destinationsOf returns the destinations of the xpub
+func (m *SPVWallet) destinationsOf(xpubID string) []*models.Destination {
+	var destinations []*models.Destination
+	for _, destination := range m.destinations {
+		if destination.XpubID == xpubID {
+			destinations = append(destinations, destination)
+		}
+	}
+	return destinations
+}
+
+func (m *SPVWallet) createDestination(req *request) (any, error) {
+	var body metadataRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	return m.newDestination(m.xpubs[req.caller.xpubID], utils.ChainExternal, body.Metadata)
+}
+
+func (m *SPVWallet) getDestination(req *request) (any, error) {
+	query := req.URL.Query()
+	return m.findDestination(req.caller.xpubID, query.Get("id"), query.Get("address"), query.Get("locking_script"))
+}
+
+func (m *SPVWallet) updateDestination(req *request) (any, error) {
+	var body metadataRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+
+	destination, err := m.findDestination(req.caller.xpubID, body.ID, body.Address, body.LockingScript)
+	if err != nil {
+		return nil, err
+	}
+	m.updateMetadata(&destination.Model, body.Metadata)
+	return destination, nil
+}
+
+func (m *SPVWallet) searchDestinations(req *request) (any, error) {
+	return search(req, m.destinationsOf(req.caller.xpubID))
+}
+
+func (m *SPVWallet) countDestinations(req *request) (any, error) {
+	return count(req, m.destinationsOf(req.caller.xpubID))
+}
+
+func (m *SPVWallet) utxoByPointer(txID string, outputIndex uint32) *models.Utxo {
+	for _, utxo := range m.utxos {
+		if utxo.TransactionID == txID && utxo.OutputIndex == outputIndex {
+			return utxo
+		}
+	}
+	return nil
+}
+
+// utxosOf returns the utxos of the xpub
+func (m *SPVWallet) utxosOf(xpubID string) []*models.Utxo {
+	var utxos []*models.Utxo
+	for _, utxo := range m.utxos {
+		if utxo.XpubID == xpubID {
+			utxos = append(utxos, utxo)
+		}
+	}
+	return utxos
+}
+
+func (m *SPVWallet) getUtxo(req *request) (any, error) {
+	query := req.URL.Query()
+	outputIndex, err := strconv.ParseUint(query.Get("output_index"), 10, 32)
+	if err != nil {
+		return nil, ErrUtxoNotFound.Wrap(err)
+	}
+
+	utxo := m.utxoByPointer(query.Get("tx_id"), uint32(outputIndex))
+	if utxo == nil || utxo.XpubID != req.caller.xpubID {
+		return nil, ErrUtxoNotFound
+	}
+	return utxo, nil
+}
+
+func (m *SPVWallet) searchUtxos(req *request) (any, error) {
+	return search(req, m.utxosOf(req.caller.xpubID))
+}
+
+func (m *SPVWallet) countUtxos(req *request) (any, error) {
+	return count(req, m.utxosOf(req.caller.xpubID))
+}
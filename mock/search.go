@@ -0,0 +1,130 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/bitcoin-sv/spv-wallet/models/filter"
+)
+
+// defaultPageSize is the page size of the searches which do not request one
+const defaultPageSize = 50
+
+// searchRequest is the body of the search and count endpoints
+type searchRequest struct {
+	Conditions map[string]any      `json:"conditions"`
+	Metadata   map[string]any      `json:"metadata"`
+	Params     *filter.QueryParams `json:"params"`
+}
+
+// search returns the requested page of the items matching the conditions and metadata of the request
+func search[T any](req *request, items []T) ([]T, error) {
+	var body searchRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+
+	page, _ := paginate(filterItems(items, &body), body.Params)
+	return page, nil
+}
+
+// count returns the number of items matching the conditions and metadata of the request
+func count[T any](req *request, items []T) (int64, error) {
+	var body searchRequest
+	if err := req.decode(&body); err != nil {
+		return 0, err
+	}
+	return int64(len(filterItems(items, &body))), nil
+}
+
+// searchPaged is search returning the page in the paged format of the contacts endpoints
+func searchPaged[T any](req *request, items []T) (*models.PagedResponse[T], error) {
+	var body searchRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+
+	matched := filterItems(items, &body)
+	content, params := paginate(matched, body.Params)
+	return &models.PagedResponse[T]{
+		Content: content,
+		Page: models.Page{
+			TotalElements: int64(len(matched)),
+			TotalPages:    (len(matched) + params.PageSize - 1) / params.PageSize,
+			Size:          params.PageSize,
+			Number:        params.Page,
+		},
+	}, nil
+}
+
+func filterItems[T any](items []T, body *searchRequest) []T {
+	matched := make([]T, 0, len(items))
+	for _, item := range items {
+		if matches(item, body.Conditions, body.Metadata) {
+			matched = append(matched, item)
+		}
+	}
+	return matched
+}
+
+// matches compares the scalar conditions with the fields of the model with the same JSON name, and the metadata with its metadata;
+// conditions on other fields (ranges, flags) are ignored
+func matches(item any, conditions, metadata map[string]any) bool {
+	if len(conditions) == 0 && len(metadata) == 0 {
+		return true
+	}
+
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return false
+	}
+	var fields map[string]any
+	if err = json.Unmarshal(raw, &fields); err != nil {
+		return false
+	}
+
+	for key, expected := range conditions {
+		actual, ok := fields[key]
+		if !ok || !isScalar(expected) {
+			continue
+		}
+		if fmt.Sprint(actual) != fmt.Sprint(expected) {
+			return false
+		}
+	}
+
+	itemMetadata, _ := fields["metadata"].(map[string]any)
+	for key, expected := range metadata {
+		if fmt.Sprint(itemMetadata[key]) != fmt.Sprint(expected) {
+			return false
+		}
+	}
+	return true
+}
+
+func isScalar(value any) bool {
+	switch value.(type) {
+	case string, float64, bool:
+		return true
+	default:
+		return false
+	}
+}
+
+// paginate returns the requested page of the items with the effective query params
+func paginate[T any](items []T, params *filter.QueryParams) ([]T, filter.QueryParams) {
+	effective := filter.QueryParams{Page: 1, PageSize: defaultPageSize}
+	if params != nil {
+		if params.Page > 0 {
+			effective.Page = params.Page
+		}
+		if params.PageSize > 0 {
+			effective.PageSize = params.PageSize
+		}
+	}
+
+	start := min((effective.Page-1)*effective.PageSize, len(items))
+	end := min(start+effective.PageSize, len(items))
+	return items[start:end], effective
+}
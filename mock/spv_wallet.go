@@ -0,0 +1,390 @@
+// Package mock provides an in-memory stand-in for the spv-wallet server.
+// It is meant to be passed to walletclient.WithMockTransport to test wallet workflows end to end without a running spv-wallet.
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	bip32 "github.com/bitcoin-sv/go-sdk/compat/bip32"
+	trx "github.com/bitcoin-sv/go-sdk/transaction"
+	walletclient "github.com/bitcoin-sv/spv-wallet-go-client"
+	"github.com/bitcoin-sv/spv-wallet-go-client/utils"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/bitcoin-sv/spv-wallet/models/common"
+)
+
+// DefaultMerkleRootsPageSize is the number of merkle roots returned per page when none is configured
+const DefaultMerkleRootsPageSize = 100
+
+// Options are the options of the mock spv-wallet
+type Options struct {
+	// PaymailDomains are the domains of the paymails which can be created; any domain is accepted when empty.
+	PaymailDomains []string
+	// AllowUnsignedRequests accepts xpub requests without a signature, like spv-wallet with signing disabled.
+	AllowUnsignedRequests bool
+	// FeeUnit is the fee rate of the draft transactions which do not define one.
+	FeeUnit models.FeeUnit
+	// MerkleRootsPageSize is the number of merkle roots returned per page, unless the request sets the batchSize.
+	MerkleRootsPageSize int
+	// Now returns the current time, used to check the auth time of the requests.
+	Now func() time.Time
+}
+
+// NewOptions creates the default options of the mock spv-wallet
+func NewOptions() *Options {
+	return &Options{
+		FeeUnit:             walletclient.DefaultFeeUnit,
+		MerkleRootsPageSize: DefaultMerkleRootsPageSize,
+		Now:                 time.Now,
+	}
+}
+
+// Opts are the functional options of the mock spv-wallet
+type Opts = func(*Options)
+
+// WithPaymailDomains restricts the domains of the paymails which can be created
+func WithPaymailDomains(domains ...string) Opts {
+	return func(o *Options) {
+		o.PaymailDomains = domains
+	}
+}
+
+// WithUnsignedRequests accepts xpub requests without a signature
+func WithUnsignedRequests() Opts {
+	return func(o *Options) {
+		o.AllowUnsignedRequests = true
+	}
+}
+
+// WithFeeUnit sets the fee rate of the draft transactions which do not define one
+func WithFeeUnit(feeUnit models.FeeUnit) Opts {
+	return func(o *Options) {
+		o.FeeUnit = feeUnit
+	}
+}
+
+// WithMerkleRootsPageSize sets the number of merkle roots returned per page
+func WithMerkleRootsPageSize(size int) Opts {
+	return func(o *Options) {
+		o.MerkleRootsPageSize = size
+	}
+}
+
+// WithClock sets the function returning the current time
+func WithClock(now func() time.Time) Opts {
+	return func(o *Options) {
+		o.Now = now
+	}
+}
+
+// SPVWallet is an in-memory stand-in for the spv-wallet server, serving its API under the "/v1" path.
+// It keeps xpubs, destinations, utxos, transactions, contacts, paymails, access keys, webhooks and merkle roots in memory
+// and validates the signed auth headers of every request the same way the server does.
+// Searches match the conditions on the fields of the models by equality and return the results in the order they were created.
+type SPVWallet struct {
+	options   *Options
+	adminXPub string
+	verifier  *walletclient.SignatureVerifier
+	mux       *http.ServeMux
+
+	mu           sync.Mutex
+	xpubs        map[string]*xpubEntry
+	destinations []*models.Destination
+	utxos        []*models.Utxo
+	transactions []*models.Transaction
+	drafts       map[string]*models.DraftTransaction
+	accessKeys   []*models.AccessKey
+	paymails     []*models.PaymailAddress
+	contacts     []*contactEntry
+	webhooks     []*webhookEntry
+	merkleRoots  []models.MerkleRoot
+}
+
+// xpubEntry is a registered xpub with its parsed key
+type xpubEntry struct {
+	key   *bip32.ExtendedKey
+	model *models.Xpub
+}
+
+// contactEntry is a contact of an xpub
+type contactEntry struct {
+	xpubID  string
+	contact *models.Contact
+}
+
+// webhookEntry is a subscribed webhook with its token
+type webhookEntry struct {
+	webhook     *models.Webhook
+	tokenHeader string
+	tokenValue  string
+}
+
+// New creates an empty mock spv-wallet; requests signed with the adminXPub are allowed to call the admin endpoints.
+func New(adminXPub string, opts ...Opts) *SPVWallet {
+	options := NewOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	m := &SPVWallet{
+		options:   options,
+		adminXPub: adminXPub,
+		verifier:  walletclient.NewSignatureVerifier(walletclient.WithVerifierClock(options.Now)),
+		mux:       http.NewServeMux(),
+		xpubs:     make(map[string]*xpubEntry),
+		drafts:    make(map[string]*models.DraftTransaction),
+	}
+	m.routes()
+	return m
+}
+
+// ServeHTTP serves the spv-wallet API
+func (m *SPVWallet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mux.ServeHTTP(w, r)
+}
+
+// AddXPub registers the xpub, as the admin would do with AdminNewXpub
+func (m *SPVWallet) AddXPub(rawXPub string, metadata map[string]any) (*models.Xpub, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.addXPub(rawXPub, metadata)
+	if err != nil {
+		return nil, err
+	}
+	xPub := *m.xpubModel(entry)
+	return &xPub, nil
+}
+
+// Fund records an incoming transaction paying the given amounts to new destinations of the registered xpub
+// and returns the created utxos
+func (m *SPVWallet) Fund(rawXPub string, satoshis ...uint64) ([]*models.Utxo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.xpubs[utils.Hash(rawXPub)]
+	if !ok {
+		return nil, ErrXPubNotFound
+	}
+
+	// the funding input spends an unknown output, so it is not validated
+	sourceTxID, err := utils.RandomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	tx := trx.NewTransaction()
+	if err = tx.AddInputFrom(sourceTxID, 0, "", 0, nil); err != nil {
+		return nil, err
+	}
+	for _, amount := range satoshis {
+		destination, err := m.newDestination(entry, utils.ChainExternal, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err = addOutput(tx, destination.LockingScript, amount); err != nil {
+			return nil, err
+		}
+	}
+
+	transaction, err := m.record(tx.String(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var utxos []*models.Utxo
+	for _, utxo := range m.utxos {
+		if utxo.TransactionID == transaction.ID {
+			funded := *utxo
+			utxos = append(utxos, &funded)
+		}
+	}
+	return utxos, nil
+}
+
+// AddMerkleRoots appends merkle roots to the ones served by the merkleroots endpoint; they must be in ascending block height order
+func (m *SPVWallet) AddMerkleRoots(merkleRoots ...models.MerkleRoot) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.merkleRoots = append(m.merkleRoots, merkleRoots...)
+}
+
+// access is the kind of identity an endpoint requires
+type access int
+
+const (
+	// accessUser requires a registered xpub, authenticated with its signature or one of its access keys
+	accessUser access = iota
+
+	// accessAdmin requires the admin xpub
+	accessAdmin
+
+	// accessAny accepts both the admin and the users
+	accessAny
+)
+
+// request is an authenticated request with its body
+type request struct {
+	*http.Request
+	body   []byte
+	caller *caller
+}
+
+// decode decodes the JSON body into v; an empty body leaves v untouched
+func (r *request) decode(v any) error {
+	if len(r.body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.body, v); err != nil {
+		return ErrCannotBindRequest.Wrap(err)
+	}
+	return nil
+}
+
+// handlerFunc handles an authenticated request and returns the model encoded in the response
+type handlerFunc func(req *request) (any, error)
+
+// handle registers the handler of the pattern, which is served after the caller is authorized
+func (m *SPVWallet) handle(pattern string, required access, handler handlerFunc) {
+	m.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, ErrCannotBindRequest.Wrap(err))
+			return
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		caller, err := m.authorize(r, body, required)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		result, err := handler(&request{Request: r, body: body, caller: caller})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+}
+
+func (m *SPVWallet) routes() {
+	m.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, ErrRouteNotFound)
+	})
+
+	m.handle("GET /v1/shared-config", accessAny, m.getSharedConfig)
+	m.handle("GET /v1/merkleroots", accessUser, m.getMerkleRoots)
+
+	m.handle("GET /v1/xpub", accessUser, m.getXPub)
+	m.handle("PATCH /v1/xpub", accessUser, m.updateXPub)
+
+	m.handle("POST /v1/access-key", accessUser, m.createAccessKey)
+	m.handle("GET /v1/access-key", accessUser, m.getAccessKey)
+	m.handle("DELETE /v1/access-key", accessUser, m.revokeAccessKey)
+	m.handle("POST /v1/access-key/search", accessUser, m.searchAccessKeys)
+	m.handle("POST /v1/access-key/count", accessUser, m.countAccessKeys)
+
+	m.handle("POST /v1/destination", accessUser, m.createDestination)
+	m.handle("GET /v1/destination", accessUser, m.getDestination)
+	m.handle("PATCH /v1/destination", accessUser, m.updateDestination)
+	m.handle("POST /v1/destination/search", accessUser, m.searchDestinations)
+	m.handle("POST /v1/destination/count", accessUser, m.countDestinations)
+
+	m.handle("POST /v1/transaction", accessUser, m.createDraft)
+	m.handle("POST /v1/transaction/record", accessUser, m.recordTransaction)
+	m.handle("GET /v1/transaction", accessUser, m.getTransaction)
+	m.handle("PATCH /v1/transaction", accessUser, m.updateTransaction)
+	m.handle("POST /v1/transaction/search", accessUser, m.searchTransactions)
+	m.handle("POST /v1/transaction/count", accessUser, m.countTransactions)
+
+	m.handle("GET /v1/utxo", accessUser, m.getUtxo)
+	m.handle("POST /v1/utxo/search", accessUser, m.searchUtxos)
+	m.handle("POST /v1/utxo/count", accessUser, m.countUtxos)
+
+	m.handle("PUT /v1/contact/{paymail}", accessUser, m.upsertContact)
+	m.handle("PATCH /v1/contact/accepted/{paymail}", accessUser, m.acceptContact)
+	m.handle("PATCH /v1/contact/rejected/{paymail}", accessUser, m.rejectContact)
+	m.handle("PATCH /v1/contact/confirmed/{paymail}", accessUser, m.confirmContact)
+	m.handle("POST /v1/contact/search", accessUser, m.searchContacts)
+
+	m.handle("GET /v1/admin/status", accessAdmin, m.adminGetStatus)
+	m.handle("GET /v1/admin/stats", accessAdmin, m.adminGetStats)
+	m.handle("POST /v1/admin/xpub", accessAdmin, m.adminNewXPub)
+	m.handle("POST /v1/admin/xpubs/search", accessAdmin, m.adminSearchXPubs)
+	m.handle("POST /v1/admin/xpubs/count", accessAdmin, m.adminCountXPubs)
+	m.handle("POST /v1/admin/access-keys/search", accessAdmin, m.adminSearchAccessKeys)
+	m.handle("POST /v1/admin/access-keys/count", accessAdmin, m.adminCountAccessKeys)
+	m.handle("POST /v1/admin/block-headers/search", accessAdmin, m.adminSearchBlockHeaders)
+	m.handle("POST /v1/admin/block-headers/count", accessAdmin, m.adminCountBlockHeaders)
+	m.handle("POST /v1/admin/destinations/search", accessAdmin, m.adminSearchDestinations)
+	m.handle("POST /v1/admin/destinations/count", accessAdmin, m.adminCountDestinations)
+	m.handle("POST /v1/admin/utxos/search", accessAdmin, m.adminSearchUtxos)
+	m.handle("POST /v1/admin/utxos/count", accessAdmin, m.adminCountUtxos)
+	m.handle("POST /v1/admin/transactions/search", accessAdmin, m.adminSearchTransactions)
+	m.handle("POST /v1/admin/transactions/count", accessAdmin, m.adminCountTransactions)
+	m.handle("POST /v1/admin/transactions/record", accessAdmin, m.adminRecordTransaction)
+	m.handle("POST /v1/admin/paymail/create", accessAdmin, m.adminCreatePaymail)
+	m.handle("POST /v1/admin/paymail/get", accessAdmin, m.adminGetPaymail)
+	m.handle("DELETE /v1/admin/paymail/delete", accessAdmin, m.adminDeletePaymail)
+	m.handle("POST /v1/admin/paymails/search", accessAdmin, m.adminSearchPaymails)
+	m.handle("POST /v1/admin/paymails/count", accessAdmin, m.adminCountPaymails)
+	m.handle("POST /v1/admin/contact/search", accessAdmin, m.adminSearchContacts)
+	m.handle("PATCH /v1/admin/contact/{id}", accessAdmin, m.adminUpdateContact)
+	m.handle("DELETE /v1/admin/contact/{id}", accessAdmin, m.adminDeleteContact)
+	m.handle("PATCH /v1/admin/contact/accepted/{id}", accessAdmin, m.adminAcceptContact)
+	m.handle("PATCH /v1/admin/contact/rejected/{id}", accessAdmin, m.adminRejectContact)
+	m.handle("POST /v1/admin/webhooks/subscriptions", accessAdmin, m.adminSubscribeWebhook)
+	m.handle("DELETE /v1/admin/webhooks/subscriptions", accessAdmin, m.adminUnsubscribeWebhook)
+	m.handle("GET /v1/admin/webhooks/subscriptions", accessAdmin, m.adminGetWebhooks)
+}
+
+// newModel returns the common fields of a model created now
+func (m *SPVWallet) newModel(metadata map[string]any) common.Model {
+	now := m.options.Now().UTC()
+	return common.Model{CreatedAt: now, UpdatedAt: now, Metadata: metadata}
+}
+
+// updateMetadata merges the metadata into the model; keys with a nil value are removed
+func (m *SPVWallet) updateMetadata(model *common.Model, metadata map[string]any) {
+	if model.Metadata == nil {
+		model.Metadata = make(map[string]any, len(metadata))
+	}
+	for key, value := range metadata {
+		if value == nil {
+			delete(model.Metadata, key)
+			continue
+		}
+		model.Metadata[key] = value
+	}
+	model.UpdatedAt = m.options.Now().UTC()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes the error as the spv-wallet does; errors other than SPVError are reported as internal errors
+func writeError(w http.ResponseWriter, err error) {
+	var spvErr models.SPVError
+	if !errors.As(err, &spvErr) {
+		spvErr = models.SPVError{Message: err.Error(), StatusCode: http.StatusInternalServerError, Code: models.UnknownErrorCode}
+	}
+
+	message := spvErr.Message
+	if cause := spvErr.Unwrap(); cause != nil {
+		message = fmt.Sprintf("%s: %s", message, cause.Error())
+	}
+	writeJSON(w, spvErr.StatusCode, models.ResponseError{Code: spvErr.Code, Message: message})
+}
@@ -0,0 +1,345 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	walletclient "github.com/bitcoin-sv/spv-wallet-go-client"
+	"github.com/bitcoin-sv/spv-wallet-go-client/xpriv"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/bitcoin-sv/spv-wallet/models/filter"
+	"github.com/bitcoin-sv/spv-wallet/models/response"
+	"github.com/stretchr/testify/require"
+)
+
+const serverURL = "https://spv-wallet.mock"
+
+// fixture is a mock spv-wallet with an admin client
+type fixture struct {
+	wallet   *SPVWallet
+	admin    *walletclient.WalletClient
+	adminKey string
+}
+
+func newFixture(t *testing.T, opts ...Opts) *fixture {
+	adminKeys, err := xpriv.Generate()
+	require.NoError(t, err)
+
+	wallet := New(adminKeys.XPub().String(), opts...)
+	admin, err := walletclient.New(serverURL, walletclient.WithAdminKey(adminKeys.XPriv()), walletclient.WithMockTransport(wallet))
+	require.NoError(t, err)
+	return &fixture{wallet: wallet, admin: admin, adminKey: adminKeys.XPriv()}
+}
+
+// newUser registers a new xpub through the admin API and returns its client
+func (f *fixture) newUser(t *testing.T) (*walletclient.WalletClient, xpriv.KeyWithMnemonic) {
+	keys, err := xpriv.Generate()
+	require.NoError(t, err)
+	require.NoError(t, f.admin.AdminNewXpub(context.Background(), keys.XPub().String(), map[string]any{"name": "user"}))
+
+	client, err := walletclient.New(serverURL,
+		walletclient.WithXPriv(keys.XPriv()),
+		walletclient.WithMockTransport(f.wallet),
+		walletclient.WithDraftVerification(&walletclient.DraftVerificationOptions{}),
+	)
+	require.NoError(t, err)
+	return client, keys
+}
+
+func TestWithMockTransport(t *testing.T) {
+	t.Run("reports the mock transport", func(t *testing.T) {
+		f := newFixture(t)
+		require.Equal(t, walletclient.SPVWalletTransportMock, f.admin.Transport())
+
+		status, err := f.admin.AdminGetStatus(context.Background())
+		require.NoError(t, err)
+		require.True(t, status)
+	})
+
+	t.Run("rejects a nil handler", func(t *testing.T) {
+		client, err := walletclient.New(serverURL, walletclient.WithMockTransport(nil))
+		require.ErrorIs(t, err, walletclient.ErrInvalidTransportHandler)
+		require.Nil(t, client)
+	})
+}
+
+func TestSPVWallet_SendToRecipients(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	alice, aliceKeys := f.newUser(t)
+	bob, bobKeys := f.newUser(t)
+
+	_, err := f.wallet.Fund(aliceKeys.XPub().String(), 3000, 5000)
+	require.NoError(t, err)
+
+	t.Run("to an address", func(t *testing.T) {
+		destination, err := bob.NewDestination(ctx, nil)
+		require.NoError(t, err)
+
+		transaction, err := alice.SendToRecipients(ctx, []*walletclient.Recipients{{To: destination.Address, Satoshis: 1000}}, map[string]any{"note": "rent"})
+		require.NoError(t, err)
+		require.Equal(t, "rent", transaction.Metadata["note"])
+
+		xPub, err := bob.GetXPub(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1000), xPub.CurrentBalance)
+
+		xPub, err = alice.GetXPub(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(8000-1000)-transaction.Fee, xPub.CurrentBalance)
+	})
+
+	t.Run("to a paymail", func(t *testing.T) {
+		_, err := f.admin.AdminCreatePaymail(ctx, bobKeys.XPub().String(), "bob@example.com", "Bob", "")
+		require.NoError(t, err)
+
+		// the scripts resolved by the spv-wallet for a paymail cannot be verified without a paymail resolver
+		_, err = alice.SendToRecipients(ctx, []*walletclient.Recipients{{To: "bob@example.com", Satoshis: 500}}, nil)
+		require.ErrorIs(t, err, walletclient.ErrDraftVerificationFailed)
+
+		unverified, err := walletclient.New(serverURL, walletclient.WithXPriv(aliceKeys.XPriv()), walletclient.WithMockTransport(f.wallet))
+		require.NoError(t, err)
+		_, err = unverified.SendToRecipients(ctx, []*walletclient.Recipients{{To: "bob@example.com", Satoshis: 500}}, nil)
+		require.NoError(t, err)
+
+		xPub, err := bob.GetXPub(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1500), xPub.CurrentBalance)
+	})
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		_, err := alice.SendToRecipients(ctx, []*walletclient.Recipients{{To: "bob@example.com", Satoshis: 1_000_000}}, nil)
+		require.ErrorIs(t, err, walletclient.ErrInsufficientFunds)
+	})
+
+	t.Run("double spend", func(t *testing.T) {
+		draft, err := alice.DraftToRecipients(ctx, []*walletclient.Recipients{{To: "bob@example.com", Satoshis: 100}}, nil)
+		require.NoError(t, err)
+		hex, err := alice.FinalizeTransaction(draft)
+		require.NoError(t, err)
+
+		_, err = alice.RecordTransaction(ctx, hex, draft.ID, nil)
+		require.NoError(t, err)
+		_, err = alice.RecordTransaction(ctx, hex, draft.ID, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("utxos iterator", func(t *testing.T) {
+		count, err := alice.GetUtxosCount(ctx, nil, nil)
+		require.NoError(t, err)
+
+		var utxos int64
+		for _, err := range alice.GetUtxosIter(ctx, nil, nil, &walletclient.PaginationOptions{PageSize: 1}) {
+			require.NoError(t, err)
+			utxos++
+		}
+		require.Equal(t, count, utxos)
+	})
+}
+
+func TestSPVWallet_Authentication(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown xpub", func(t *testing.T) {
+		f := newFixture(t)
+		keys, err := xpriv.Generate()
+		require.NoError(t, err)
+		client, err := walletclient.New(serverURL, walletclient.WithXPriv(keys.XPriv()), walletclient.WithMockTransport(f.wallet))
+		require.NoError(t, err)
+
+		_, err = client.GetXPub(ctx)
+		require.ErrorIs(t, err, ErrUnknownXPub)
+	})
+
+	t.Run("user calling an admin endpoint", func(t *testing.T) {
+		f := newFixture(t)
+		_, keys := f.newUser(t)
+		client, err := walletclient.New(serverURL, walletclient.WithAdminKey(keys.XPriv()), walletclient.WithMockTransport(f.wallet))
+		require.NoError(t, err)
+
+		_, err = client.AdminGetStatus(ctx)
+		require.ErrorIs(t, err, ErrNotAnAdminKey)
+	})
+
+	t.Run("unsigned request", func(t *testing.T) {
+		for name, allowed := range map[string]bool{"rejected": false, "allowed": true} {
+			t.Run(name, func(t *testing.T) {
+				var opts []Opts
+				if allowed {
+					opts = append(opts, WithUnsignedRequests())
+				}
+				f := newFixture(t, opts...)
+				keys, err := xpriv.Generate()
+				require.NoError(t, err)
+				_, err = f.wallet.AddXPub(keys.XPub().String(), nil)
+				require.NoError(t, err)
+
+				client, err := walletclient.New(serverURL,
+					walletclient.WithXPriv(keys.XPriv()),
+					walletclient.WithSignRequest(false),
+					walletclient.WithMockTransport(f.wallet),
+				)
+				require.NoError(t, err)
+
+				_, err = client.GetTransaction(ctx, "unknown")
+				if allowed {
+					require.ErrorIs(t, err, ErrTransactionNotFound)
+				} else {
+					require.ErrorIs(t, err, ErrMissingSignature)
+				}
+			})
+		}
+	})
+
+	t.Run("expired signature", func(t *testing.T) {
+		f := newFixture(t, WithClock(func() time.Time {
+			return time.Now().Add(time.Minute)
+		}))
+
+		_, err := f.admin.AdminGetStatus(ctx)
+		require.ErrorIs(t, err, ErrSignatureExpired)
+	})
+
+	t.Run("access key", func(t *testing.T) {
+		f := newFixture(t)
+		user, _ := f.newUser(t)
+
+		accessKey, err := user.CreateAccessKey(ctx, nil)
+		require.NoError(t, err)
+		require.NotEmpty(t, accessKey.Key)
+
+		client, err := walletclient.New(serverURL, walletclient.WithAccessKey(accessKey.Key), walletclient.WithMockTransport(f.wallet))
+		require.NoError(t, err)
+		_, err = client.NewDestination(ctx, nil)
+		require.NoError(t, err)
+
+		_, err = user.RevokeAccessKey(ctx, accessKey.ID)
+		require.NoError(t, err)
+		_, err = client.NewDestination(ctx, nil)
+		require.ErrorIs(t, err, ErrUnknownAccessKey)
+	})
+}
+
+func TestSPVWallet_Contacts(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t, WithPaymailDomains("example.com"))
+	alice, aliceKeys := f.newUser(t)
+	bob, bobKeys := f.newUser(t)
+
+	_, err := f.admin.AdminCreatePaymail(ctx, aliceKeys.XPub().String(), "alice@example.com", "Alice", "")
+	require.NoError(t, err)
+	_, err = f.admin.AdminCreatePaymail(ctx, bobKeys.XPub().String(), "bob@example.com", "Bob", "")
+	require.NoError(t, err)
+
+	_, err = f.admin.AdminCreatePaymail(ctx, bobKeys.XPub().String(), "bob@other.com", "Bob", "")
+	require.ErrorIs(t, err, ErrInvalidPaymailDomain)
+
+	bobForAlice, err := alice.UpsertContact(ctx, "bob@example.com", "Bob", "alice@example.com", nil)
+	require.NoError(t, err)
+	require.Equal(t, response.ContactNotConfirmed, bobForAlice.Status)
+
+	require.NoError(t, bob.AcceptContact(ctx, "alice@example.com"))
+
+	contacts, err := bob.GetContacts(ctx, &filter.ContactFilter{Paymail: ptr("alice@example.com")}, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, contacts.Content, 1)
+	aliceForBob := contacts.Content[0]
+	require.Equal(t, response.ContactNotConfirmed, aliceForBob.Status)
+
+	passcode, err := alice.GenerateTotpForContact(bobForAlice, 30, 6)
+	require.NoError(t, err)
+	require.NoError(t, bob.ConfirmContact(ctx, aliceForBob, passcode, "bob@example.com", 30, 6))
+
+	contacts, err = bob.GetContacts(ctx, nil, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, response.ContactConfirmed, contacts.Content[0].Status)
+
+	err = bob.AcceptContact(ctx, "alice@example.com")
+	require.ErrorIs(t, err, ErrContactInvalidStatus)
+}
+
+func TestSPVWallet_SyncMerkleRoots(t *testing.T) {
+	f := newFixture(t, WithMerkleRootsPageSize(2))
+	user, _ := f.newUser(t)
+
+	f.wallet.AddMerkleRoots(
+		models.MerkleRoot{BlockHeight: 0, MerkleRoot: "root-0"},
+		models.MerkleRoot{BlockHeight: 1, MerkleRoot: "root-1"},
+		models.MerkleRoot{BlockHeight: 2, MerkleRoot: "root-2"},
+		models.MerkleRoot{BlockHeight: 3, MerkleRoot: "root-3"},
+		models.MerkleRoot{BlockHeight: 4, MerkleRoot: "root-4"},
+	)
+
+	repo := &merkleRootsRepository{}
+	require.NoError(t, user.SyncMerkleRoots(context.Background(), repo))
+	require.Len(t, repo.roots, 5)
+	require.Equal(t, "root-4", repo.GetLastMerkleRoot())
+}
+
+func TestSPVWallet_SyncMerkleRootsWithOptions(t *testing.T) {
+	f := newFixture(t, WithMerkleRootsPageSize(2))
+	_, keys := f.newUser(t)
+	client, err := walletclient.New(serverURL,
+		walletclient.WithXPriv(keys.XPriv()),
+		walletclient.WithAdminKey(f.adminKey),
+		walletclient.WithMockTransport(f.wallet),
+	)
+	require.NoError(t, err)
+
+	for height := range 5 {
+		f.wallet.AddMerkleRoots(models.MerkleRoot{BlockHeight: height, MerkleRoot: fmt.Sprintf("root-%d", height)})
+	}
+
+	repo := walletclient.NewMemoryMerkleRootsRepository()
+	var reported []walletclient.SyncMerkleRootsProgress
+	progress, err := client.SyncMerkleRootsWithOptions(context.Background(), repo, &walletclient.SyncMerkleRootsOptions{
+		PageSize:          3,
+		CountBlockHeaders: true,
+		OnProgress: func(progress walletclient.SyncMerkleRootsProgress) {
+			reported = append(reported, progress)
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 4, repo.GetLastMerkleRootHeight())
+	require.Len(t, reported, 2)
+	require.Equal(t, 2, reported[0].Remaining)
+	require.Equal(t, 5, progress.Total)
+	require.Equal(t, 0, progress.Remaining)
+}
+
+func TestSPVWallet_Webhooks(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+
+	require.NoError(t, f.admin.AdminSubscribeWebhook(ctx, "https://example.com/hook", "X-Token", "secret"))
+	webhooks, err := f.admin.AdminGetWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	require.Equal(t, "https://example.com/hook", webhooks[0].URL)
+
+	require.NoError(t, f.admin.AdminUnsubscribeWebhook(ctx, "https://example.com/hook"))
+	err = f.admin.AdminUnsubscribeWebhook(ctx, "https://example.com/hook")
+	require.ErrorIs(t, err, ErrWebhookNotFound)
+}
+
+type merkleRootsRepository struct {
+	roots []models.MerkleRoot
+}
+
+func (r *merkleRootsRepository) GetLastMerkleRoot() string {
+	if len(r.roots) == 0 {
+		return ""
+	}
+	return r.roots[len(r.roots)-1].MerkleRoot
+}
+
+func (r *merkleRootsRepository) SaveMerkleRoots(roots []models.MerkleRoot) error {
+	r.roots = append(r.roots, roots...)
+	return nil
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
@@ -0,0 +1,421 @@
+package mock
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	script "github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/script/interpreter"
+	trx "github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/template/p2pkh"
+	walletclient "github.com/bitcoin-sv/spv-wallet-go-client"
+	"github.com/bitcoin-sv/spv-wallet-go-client/utils"
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// transactionStatusCreated is the status of the recorded transactions; the mock does not broadcast them
+const transactionStatusCreated = "CREATED"
+
+// draftRequest is the body of the draft transaction endpoint
+type draftRequest struct {
+	Config   models.TransactionConfig `json:"config"`
+	Metadata map[string]any           `json:"metadata"`
+}
+
+// recordRequest is the body of the record transaction endpoints
+type recordRequest struct {
+	Hex         string         `json:"hex"`
+	ReferenceID string         `json:"reference_id"`
+	Metadata    map[string]any `json:"metadata"`
+}
+
+// createDraft selects the utxos of the xpub (largest first), adds a change output to a new internal destination
+// and reserves the utxos for the draft until it is recorded
+func (m *SPVWallet) createDraft(req *request) (any, error) {
+	var body draftRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	config := body.Config
+	if len(config.Outputs) == 0 {
+		return nil, walletclient.ErrMissingRecipients
+	}
+
+	draftID, err := utils.RandomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	var outputs []*trx.TransactionOutput
+	var target uint64
+	for _, output := range config.Outputs {
+		if err = m.resolveOutput(output); err != nil {
+			return nil, err
+		}
+		for _, scriptOutput := range output.Scripts {
+			lockingScript, err := script.NewFromHex(scriptOutput.Script)
+			if err != nil {
+				return nil, ErrInvalidOutput.Wrap(err)
+			}
+			outputs = append(outputs, &trx.TransactionOutput{Satoshis: scriptOutput.Satoshis, LockingScript: lockingScript})
+			target += scriptOutput.Satoshis
+		}
+	}
+
+	feeUnit := config.FeeUnit
+	if feeUnit == nil {
+		feeUnit = &m.options.FeeUnit
+	}
+	selection, err := walletclient.SelectCoins(
+		walletclient.CoinSelectionLargestFirst, m.spendableUtxos(req.caller.xpubID), target,
+		walletclient.NewFeeEstimator(feeUnit, outputs), config.ChangeMinimumSatoshis,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if selection.Change > 0 {
+		change, err := m.newDestination(m.xpubs[req.caller.xpubID], utils.ChainInternal, nil)
+		if err != nil {
+			return nil, err
+		}
+		change.DraftID = draftID
+		changeOutput := &models.TransactionOutput{
+			To:           change.Address,
+			Satoshis:     selection.Change,
+			Script:       change.LockingScript,
+			Scripts:      []*models.ScriptOutput{{Address: change.Address, Satoshis: selection.Change, Script: change.LockingScript, ScriptType: "pubkeyhash"}},
+			UseForChange: true,
+		}
+		config.Outputs = append(config.Outputs, changeOutput)
+		config.ChangeDestinations = []*models.Destination{change}
+		config.ChangeNumberOfDestinations = 1
+		config.ChangeSatoshis = selection.Change
+
+		lockingScript, _ := script.NewFromHex(change.LockingScript)
+		outputs = append(outputs, &trx.TransactionOutput{Satoshis: selection.Change, LockingScript: lockingScript, Change: true})
+	}
+	config.Fee = selection.Fee
+	config.FeeUnit = feeUnit
+
+	tx := trx.NewTransaction()
+	config.Inputs = nil
+	now := m.options.Now().UTC()
+	for _, utxo := range selection.Utxos {
+		utxo.DraftID = draftID
+		utxo.ReservedAt = now
+		destination := m.destinationByLockingScript(utxo.ScriptPubKey)
+		config.Inputs = append(config.Inputs, &models.TransactionInput{Utxo: *utxo, Destination: *destination})
+		if err = tx.AddInputFrom(utxo.TransactionID, utxo.OutputIndex, utxo.ScriptPubKey, utxo.Satoshis, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, output := range outputs {
+		tx.AddOutput(output)
+	}
+
+	draft := &models.DraftTransaction{
+		Model:         m.newModel(body.Metadata),
+		ID:            draftID,
+		Hex:           tx.String(),
+		XpubID:        req.caller.xpubID,
+		Configuration: config,
+		Status:        models.DraftStatusDraft,
+	}
+	if config.ExpiresIn > 0 {
+		draft.ExpiresAt = now.Add(config.ExpiresIn)
+	}
+	m.drafts[draftID] = draft
+	return draft, nil
+}
+
+// resolveOutput sets the locking scripts of an address, paymail or OP_RETURN output; only paymails hosted by the mock can be paid
+func (m *SPVWallet) resolveOutput(output *models.TransactionOutput) error {
+	switch {
+	case output.OpReturn != nil:
+		lockingScript, err := opReturnLockingScript(output.OpReturn)
+		if err != nil {
+			return ErrInvalidOutput.Wrap(err)
+		}
+		output.Script = lockingScript.String()
+		output.Scripts = []*models.ScriptOutput{{Satoshis: output.Satoshis, Script: output.Script, ScriptType: "nulldata"}}
+	case strings.Contains(output.To, "@"):
+		paymail := m.paymailByAddress(output.To)
+		if paymail == nil {
+			return ErrInvalidOutput.Wrap(fmt.Errorf("paymail %s is not hosted by the mock", output.To))
+		}
+		destination, err := m.newDestination(m.xpubs[paymail.XpubID], utils.ChainExternal, nil)
+		if err != nil {
+			return err
+		}
+		output.Scripts = []*models.ScriptOutput{{Address: destination.Address, Satoshis: output.Satoshis, Script: destination.LockingScript, ScriptType: "pubkeyhash"}}
+	default:
+		address, err := script.NewAddressFromString(output.To)
+		if err != nil {
+			return ErrInvalidOutput.Wrap(err)
+		}
+		lockingScript, err := p2pkh.Lock(address)
+		if err != nil {
+			return ErrInvalidOutput.Wrap(err)
+		}
+		output.Script = lockingScript.String()
+		output.Scripts = []*models.ScriptOutput{{Address: output.To, Satoshis: output.Satoshis, Script: output.Script, ScriptType: "pubkeyhash"}}
+	}
+	return nil
+}
+
+// opReturnLockingScript builds the OP_FALSE OP_RETURN locking script of the given data
+func opReturnLockingScript(opReturn *models.OpReturn) (*script.Script, error) {
+	if opReturn.Hex != "" {
+		return script.NewFromHex(opReturn.Hex)
+	}
+
+	var parts [][]byte
+	for _, part := range opReturn.HexParts {
+		data, err := hex.DecodeString(part)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, data)
+	}
+	for _, part := range opReturn.StringParts {
+		parts = append(parts, []byte(part))
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("unsupported op_return data")
+	}
+
+	output, err := trx.CreateOpReturnOutput(parts)
+	if err != nil {
+		return nil, err
+	}
+	return output.LockingScript, nil
+}
+
+// spendableUtxos returns the utxos of the xpub which are neither spent nor reserved by a draft
+func (m *SPVWallet) spendableUtxos(xpubID string) []*models.Utxo {
+	var utxos []*models.Utxo
+	for _, utxo := range m.utxos {
+		if utxo.XpubID == xpubID && utxo.SpendingTxID == "" && utxo.DraftID == "" {
+			utxos = append(utxos, utxo)
+		}
+	}
+	return utxos
+}
+
+func addOutput(tx *trx.Transaction, lockingScriptHex string, satoshis uint64) error {
+	lockingScript, err := script.NewFromHex(lockingScriptHex)
+	if err != nil {
+		return err
+	}
+	tx.AddOutput(&trx.TransactionOutput{Satoshis: satoshis, LockingScript: lockingScript})
+	return nil
+}
+
+// record verifies the inputs spending known utxos with the script interpreter, marks them as spent
+// and creates the utxos of the outputs paying known destinations; the draft (if any) is completed
+func (m *SPVWallet) record(txHex string, draft *models.DraftTransaction, metadata map[string]any) (*models.Transaction, error) {
+	tx, err := trx.NewTransactionFromHex(txHex)
+	if err != nil {
+		return nil, ErrInvalidTransaction.Wrap(err)
+	}
+	txID := tx.TxID().String()
+	if m.transactionByID(txID) != nil {
+		return nil, ErrTransactionAlreadyRecorded
+	}
+
+	var spent []*models.Utxo
+	var inputSatoshis uint64
+	allInputsKnown := true
+	for index, input := range tx.Inputs {
+		utxo := m.utxoByPointer(input.SourceTXID.String(), input.SourceTxOutIndex)
+		if utxo == nil {
+			allInputsKnown = false
+			continue
+		}
+		if utxo.SpendingTxID != "" {
+			return nil, ErrUtxoAlreadySpent
+		}
+		if utxo.DraftID != "" && (draft == nil || utxo.DraftID != draft.ID) {
+			return nil, ErrUtxoReserved
+		}
+
+		lockingScript, err := script.NewFromHex(utxo.ScriptPubKey)
+		if err != nil {
+			return nil, ErrInvalidInput.Wrap(err)
+		}
+		if err = interpreter.NewEngine().Execute(
+			interpreter.WithTx(tx, index, &trx.TransactionOutput{Satoshis: utxo.Satoshis, LockingScript: lockingScript}),
+			interpreter.WithForkID(),
+			interpreter.WithAfterGenesis(),
+		); err != nil {
+			return nil, ErrInvalidInput.Wrap(err)
+		}
+		spent = append(spent, utxo)
+		inputSatoshis += utxo.Satoshis
+	}
+
+	var outputSatoshis uint64
+	paid := make(map[int]*models.Destination)
+	for index, output := range tx.Outputs {
+		outputSatoshis += output.Satoshis
+		if destination := m.destinationByLockingScript(output.LockingScript.String()); destination != nil {
+			paid[index] = destination
+		}
+	}
+	if len(spent) == 0 && len(paid) == 0 {
+		return nil, ErrTransactionNotRelated
+	}
+	if allInputsKnown && outputSatoshis > inputSatoshis {
+		return nil, ErrInvalidTransaction.Wrap(fmt.Errorf("outputs (%d satoshis) exceed inputs (%d satoshis)", outputSatoshis, inputSatoshis))
+	}
+
+	transaction := &models.Transaction{
+		Model:           m.newModel(metadata),
+		ID:              txID,
+		Hex:             txHex,
+		NumberOfInputs:  uint32(len(tx.Inputs)),
+		NumberOfOutputs: uint32(len(tx.Outputs)),
+		TotalValue:      outputSatoshis,
+		Outputs:         make(map[string]int64),
+		Status:          transactionStatusCreated,
+	}
+	if allInputsKnown {
+		transaction.Fee = inputSatoshis - outputSatoshis
+	}
+
+	for _, utxo := range spent {
+		utxo.SpendingTxID = txID
+		transaction.Outputs[utxo.XpubID] -= int64(utxo.Satoshis)
+		if !slices.Contains(transaction.XpubInIDs, utxo.XpubID) {
+			transaction.XpubInIDs = append(transaction.XpubInIDs, utxo.XpubID)
+		}
+	}
+	for index, output := range tx.Outputs {
+		destination, ok := paid[index]
+		if !ok {
+			continue
+		}
+		m.utxos = append(m.utxos, &models.Utxo{
+			Model:        m.newModel(nil),
+			UtxoPointer:  models.UtxoPointer{TransactionID: txID, OutputIndex: uint32(index)},
+			ID:           utils.Hash(fmt.Sprintf("%s%d", txID, index)),
+			XpubID:       destination.XpubID,
+			Satoshis:     output.Satoshis,
+			ScriptPubKey: destination.LockingScript,
+			Type:         destination.Type,
+		})
+		transaction.Outputs[destination.XpubID] += int64(output.Satoshis)
+		if !slices.Contains(transaction.XpubOutIDs, destination.XpubID) {
+			transaction.XpubOutIDs = append(transaction.XpubOutIDs, destination.XpubID)
+		}
+	}
+
+	if draft != nil {
+		transaction.DraftID = draft.ID
+		draft.Status = models.DraftStatusComplete
+		draft.FinalTxID = txID
+		// the utxos reserved by the draft but not spent by the transaction are released
+		for _, utxo := range m.utxos {
+			if utxo.DraftID == draft.ID && utxo.SpendingTxID == "" {
+				utxo.DraftID = ""
+			}
+		}
+	}
+
+	m.transactions = append(m.transactions, transaction)
+	return transaction, nil
+}
+
+func (m *SPVWallet) transactionByID(id string) *models.Transaction {
+	for _, transaction := range m.transactions {
+		if transaction.ID == id {
+			return transaction
+		}
+	}
+	return nil
+}
+
+// transactionView returns the transaction as seen by the xpub, with its direction and value
+func transactionView(transaction *models.Transaction, xpubID string) *models.Transaction {
+	view := *transaction
+	view.OutputValue = transaction.Outputs[xpubID]
+	view.TransactionDirection = "incoming"
+	if slices.Contains(transaction.XpubInIDs, xpubID) {
+		view.TransactionDirection = "outgoing"
+	}
+	return &view
+}
+
+// transactionsOf returns the transactions spending or paying the xpub, as seen by the xpub
+func (m *SPVWallet) transactionsOf(xpubID string) []*models.Transaction {
+	var transactions []*models.Transaction
+	for _, transaction := range m.transactions {
+		if slices.Contains(transaction.XpubInIDs, xpubID) || slices.Contains(transaction.XpubOutIDs, xpubID) {
+			transactions = append(transactions, transactionView(transaction, xpubID))
+		}
+	}
+	return transactions
+}
+
+// transactionOf returns the transaction with the given id if it spends or pays the xpub
+func (m *SPVWallet) transactionOf(xpubID, id string) (*models.Transaction, error) {
+	transaction := m.transactionByID(id)
+	if transaction == nil || !(slices.Contains(transaction.XpubInIDs, xpubID) || slices.Contains(transaction.XpubOutIDs, xpubID)) {
+		return nil, ErrTransactionNotFound
+	}
+	return transaction, nil
+}
+
+func (m *SPVWallet) recordTransaction(req *request) (any, error) {
+	var body recordRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+
+	var draft *models.DraftTransaction
+	if body.ReferenceID != "" {
+		draft = m.drafts[body.ReferenceID]
+		if draft == nil || draft.XpubID != req.caller.xpubID || draft.Status != models.DraftStatusDraft {
+			return nil, ErrDraftNotFound
+		}
+	}
+
+	transaction, err := m.record(body.Hex, draft, body.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return transactionView(transaction, req.caller.xpubID), nil
+}
+
+func (m *SPVWallet) getTransaction(req *request) (any, error) {
+	transaction, err := m.transactionOf(req.caller.xpubID, req.URL.Query().Get("id"))
+	if err != nil {
+		return nil, err
+	}
+	return transactionView(transaction, req.caller.xpubID), nil
+}
+
+func (m *SPVWallet) updateTransaction(req *request) (any, error) {
+	var body metadataRequest
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+
+	transaction, err := m.transactionOf(req.caller.xpubID, body.ID)
+	if err != nil {
+		return nil, err
+	}
+	m.updateMetadata(&transaction.Model, body.Metadata)
+	return transactionView(transaction, req.caller.xpubID), nil
+}
+
+func (m *SPVWallet) searchTransactions(req *request) (any, error) {
+	return search(req, m.transactionsOf(req.caller.xpubID))
+}
+
+func (m *SPVWallet) countTransactions(req *request) (any, error) {
+	return count(req, m.transactionsOf(req.caller.xpubID))
+}
@@ -0,0 +1,136 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// PollCursor - the position of a poll source: the update time of the last polled record,
+// and the ids of the polled records updated at that time, which are skipped by the next poll
+type PollCursor struct {
+	UpdatedAt time.Time `json:"updatedAt"`
+	IDs       []string  `json:"ids"`
+}
+
+// next returns the cursor moved to the record
+func (c *PollCursor) next(id string, updatedAt time.Time) *PollCursor {
+	if updatedAt.Equal(c.UpdatedAt) {
+		return &PollCursor{UpdatedAt: c.UpdatedAt, IDs: append(slices.Clip(c.IDs), id)}
+	}
+	return &PollCursor{UpdatedAt: updatedAt, IDs: []string{id}}
+}
+
+// CursorStore - persists the cursors of the poll sources
+type CursorStore interface {
+	// Load returns the cursor of the source, or nil if none is stored
+	Load(ctx context.Context, source string) (*PollCursor, error)
+	// Save stores the cursor of the source
+	Save(ctx context.Context, source string, cursor *PollCursor) error
+}
+
+// MemoryCursorStore - keeps the cursors in memory; the poller starts over after a restart
+type MemoryCursorStore struct {
+	mu      sync.Mutex
+	cursors map[string]*PollCursor
+}
+
+// NewMemoryCursorStore - creates an empty in-memory cursor store
+func NewMemoryCursorStore() *MemoryCursorStore {
+	return &MemoryCursorStore{cursors: make(map[string]*PollCursor)}
+}
+
+// Load - returns the cursor of the source
+func (s *MemoryCursorStore) Load(_ context.Context, source string) (*PollCursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cursors[source], nil
+}
+
+// Save - stores the cursor of the source
+func (s *MemoryCursorStore) Save(_ context.Context, source string, cursor *PollCursor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cursors[source] = cursor
+	return nil
+}
+
+// FileCursorStore - keeps the cursors in a JSON file, replaced atomically on every save
+type FileCursorStore struct {
+	path string
+
+	mu sync.Mutex
+}
+
+// NewFileCursorStore - creates a cursor store in the file at path; the file is created on the first save
+func NewFileCursorStore(path string) *FileCursorStore {
+	return &FileCursorStore{path: path}
+}
+
+// Load - reads the cursor of the source from the file
+func (s *FileCursorStore) Load(_ context.Context, source string) (*PollCursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cursors, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return cursors[source], nil
+}
+
+// Save - writes the cursor of the source to the file and syncs it to the disk
+func (s *FileCursorStore) Save(_ context.Context, source string, cursor *PollCursor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cursors, err := s.read()
+	if err != nil {
+		return err
+	}
+	cursors[source] = cursor
+
+	content, err := json.Marshal(cursors)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(content); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *FileCursorStore) read() (map[string]*PollCursor, error) {
+	cursors := make(map[string]*PollCursor)
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cursors, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(content, &cursors); err != nil {
+		return nil, err
+	}
+	return cursors, nil
+}
@@ -0,0 +1,176 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// DeadLetter - an event which could not be processed, with the reason of the failure
+type DeadLetter struct {
+	Event    *models.RawEvent `json:"event"`
+	Error    string           `json:"error"`
+	Attempts int              `json:"attempts"`
+	FailedAt time.Time        `json:"failedAt"`
+}
+
+// DeadLetterSink - receives the events which could not be processed, so they can be inspected and replayed
+type DeadLetterSink interface {
+	Put(ctx context.Context, letter *DeadLetter) error
+}
+
+// MemoryDeadLetterSink - keeps the dead letters in memory
+type MemoryDeadLetterSink struct {
+	mu      sync.Mutex
+	letters []*DeadLetter
+}
+
+// NewMemoryDeadLetterSink - creates an empty in-memory dead-letter sink
+func NewMemoryDeadLetterSink() *MemoryDeadLetterSink {
+	return &MemoryDeadLetterSink{}
+}
+
+// Put - stores the dead letter
+func (s *MemoryDeadLetterSink) Put(_ context.Context, letter *DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.letters = append(s.letters, letter)
+	return nil
+}
+
+// Letters - returns the stored dead letters, oldest first
+func (s *MemoryDeadLetterSink) Letters() []*DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.letters)
+}
+
+// Drain - returns the stored dead letters and removes them from the sink, e.g. to replay them
+func (s *MemoryDeadLetterSink) Drain() []*DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	letters := s.letters
+	s.letters = nil
+	return letters
+}
+
+// FileDeadLetterSink - appends the dead letters to a file, one JSON document per line
+type FileDeadLetterSink struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileDeadLetterSink - creates a dead-letter sink appending to the file at path; the file is created if it does not exist
+func NewFileDeadLetterSink(path string) (*FileDeadLetterSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileDeadLetterSink{path: path, file: file}, nil
+}
+
+// Put - appends the dead letter to the file and syncs it to the disk
+func (s *FileDeadLetterSink) Put(_ context.Context, letter *DeadLetter) error {
+	line, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err = s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Letters - reads the dead letters stored in the file, oldest first
+func (s *FileDeadLetterSink) Letters() ([]*DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return readDeadLetters(s.path)
+}
+
+// Drain - reads the dead letters stored in the file and truncates it, e.g. to replay them
+func (s *FileDeadLetterSink) Drain() ([]*DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	letters, err := readDeadLetters(s.path)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.file.Truncate(0); err != nil {
+		return nil, err
+	}
+	return letters, nil
+}
+
+// Close - closes the file
+func (s *FileDeadLetterSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+// errorDetail returns the message of the error followed by the one of its cause, which SPVError.Error leaves out
+func errorDetail(err error) string {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		details := make([]string, 0, len(joined.Unwrap()))
+		for _, e := range joined.Unwrap() {
+			details = append(details, errorDetail(e))
+		}
+		return strings.Join(details, "; ")
+	}
+
+	var spvErr models.SPVError
+	if errors.As(err, &spvErr) {
+		if cause := spvErr.Unwrap(); cause != nil {
+			return spvErr.Error() + ": " + errorDetail(cause)
+		}
+	}
+	return err.Error()
+}
+
+func readDeadLetters(path string) ([]*DeadLetter, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var letters []*DeadLetter
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		letter := new(DeadLetter)
+		if err = json.Unmarshal(scanner.Bytes(), letter); err != nil {
+			return nil, err
+		}
+		letters = append(letters, letter)
+	}
+	return letters, scanner.Err()
+}
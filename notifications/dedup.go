@@ -0,0 +1,150 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// DefaultDedupTTL is how long the MemoryDedupStore remembers the processed events when no TTL is given
+const DefaultDedupTTL = 1 * time.Hour
+
+// EventIDFunc - returns the identity of an event; the events with the same identity are processed once
+type EventIDFunc func(event *models.RawEvent) string
+
+// DefaultEventID - the identity of an event is the hash of its type and its content
+func DefaultEventID(event *models.RawEvent) string {
+	content := new(bytes.Buffer)
+	if err := json.Compact(content, event.Content); err != nil {
+		content.Reset()
+		content.Write(event.Content)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(event.Type))
+	hash.Write([]byte{0})
+	hash.Write(content.Bytes())
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// DedupStore - remembers the identities of the processed events; it can be backed by a database
+// to deduplicate the events across restarts or between instances
+type DedupStore interface {
+	// Seen returns true if the event with the identity has already been processed
+	Seen(ctx context.Context, id string) (bool, error)
+	// Mark records the event with the identity as processed
+	Mark(ctx context.Context, id string) error
+}
+
+// MemoryDedupStore - remembers the processed events in memory for a limited time
+type MemoryDedupStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	expiresAt map[string]time.Time
+	nextPrune time.Time
+}
+
+// NewMemoryDedupStore - creates an in-memory store remembering the processed events for ttl, or DefaultDedupTTL if ttl is not positive
+func NewMemoryDedupStore(ttl time.Duration) *MemoryDedupStore {
+	if ttl <= 0 {
+		ttl = DefaultDedupTTL
+	}
+	return &MemoryDedupStore{ttl: ttl, now: time.Now, expiresAt: make(map[string]time.Time)}
+}
+
+// Seen - returns true if the event was marked less than the TTL ago
+func (s *MemoryDedupStore) Seen(_ context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.expiresAt[id]
+	return ok && s.now().Before(expiresAt), nil
+}
+
+// Mark - remembers the event for the TTL; the expired events are pruned on the way
+func (s *MemoryDedupStore) Mark(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.After(s.nextPrune) {
+		for key, expiresAt := range s.expiresAt {
+			if !now.Before(expiresAt) {
+				delete(s.expiresAt, key)
+			}
+		}
+		s.nextPrune = now.Add(s.ttl / 2)
+	}
+	s.expiresAt[id] = now.Add(s.ttl)
+	return nil
+}
+
+// Len - returns the number of remembered events, the expired ones which are not pruned yet included
+func (s *MemoryDedupStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.expiresAt)
+}
+
+// deduplicator skips the events which are processed or being processed
+type deduplicator struct {
+	store  DedupStore
+	id     EventIDFunc
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	inFlight map[string]struct{}
+}
+
+func newDeduplicator(store DedupStore, id EventIDFunc, logger *slog.Logger) *deduplicator {
+	return &deduplicator{store: store, id: id, logger: logger, inFlight: make(map[string]struct{})}
+}
+
+// claim returns the identity of the event and true if it should be processed;
+// the event is processed anyway if the store fails, as a duplicate is better than a lost event
+func (d *deduplicator) claim(ctx context.Context, event *models.RawEvent) (string, bool) {
+	id := d.id(event)
+
+	d.mu.Lock()
+	_, duplicated := d.inFlight[id]
+	d.inFlight[id] = struct{}{}
+	d.mu.Unlock()
+
+	if !duplicated {
+		seen, err := d.store.Seen(ctx, id)
+		if err != nil {
+			d.logger.WarnContext(ctx, "cannot check if webhook event is duplicated", slog.String("id", id), slog.String("error", err.Error()))
+		}
+		if !seen {
+			return id, true
+		}
+		d.mu.Lock()
+		delete(d.inFlight, id)
+		d.mu.Unlock()
+	}
+
+	d.logger.DebugContext(ctx, "skipped duplicated webhook event", slog.String("type", event.Type), slog.String("id", id))
+	return id, false
+}
+
+// release marks the claimed event as processed
+func (d *deduplicator) release(ctx context.Context, id string) {
+	if err := d.store.Mark(context.WithoutCancel(ctx), id); err != nil {
+		d.logger.WarnContext(ctx, "cannot mark webhook event as processed", slog.String("id", id), slog.String("error", err.Error()))
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.inFlight, id)
+}
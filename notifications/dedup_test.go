@@ -0,0 +1,113 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultEventID(t *testing.T) {
+	event := &models.RawEvent{Type: "StringEvent", Content: json.RawMessage(`{"value":"hello"}`)}
+	reformatted := &models.RawEvent{Type: "StringEvent", Content: json.RawMessage("{ \"value\": \"hello\" }\n")}
+	other := &models.RawEvent{Type: "StringEvent", Content: json.RawMessage(`{"value":"bye"}`)}
+
+	require.Equal(t, DefaultEventID(event), DefaultEventID(reformatted))
+	require.NotEqual(t, DefaultEventID(event), DefaultEventID(other))
+}
+
+func TestMemoryDedupStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryDedupStore(time.Minute)
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Mark(ctx, "first"))
+	seen, err := store.Seen(ctx, "first")
+	require.NoError(t, err)
+	require.True(t, seen)
+
+	now = now.Add(time.Minute)
+	seen, err = store.Seen(ctx, "first")
+	require.NoError(t, err)
+	require.False(t, seen)
+
+	require.NoError(t, store.Mark(ctx, "second"))
+	require.Equal(t, 1, store.Len())
+}
+
+func TestWebhook_Deduplication(t *testing.T) {
+	t.Run("processes a delivered again batch once", func(t *testing.T) {
+		wh := newTestWebhook(t, WithDeduplication(nil, nil))
+
+		var (
+			mu     sync.Mutex
+			values []string
+		)
+		require.NoError(t, RegisterHandler(wh, func(event *models.StringEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			values = append(values, event.Value)
+		}))
+
+		events := stringEvents(t, "a", "b")
+		require.Equal(t, http.StatusOK, deliver(t, wh, events...).Code)
+		require.Equal(t, http.StatusOK, deliver(t, wh, events...).Code)
+		require.Equal(t, http.StatusOK, deliver(t, wh, stringEvents(t, "c")...).Code)
+
+		_, err := wh.Shutdown(context.Background())
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"a", "b", "c"}, values)
+	})
+
+	t.Run("uses the configured event identity", func(t *testing.T) {
+		byTransaction := func(event *models.RawEvent) string {
+			var content models.TransactionEvent
+			_ = json.Unmarshal(event.Content, &content)
+			return content.TransactionID + "/" + content.Status
+		}
+		wh := newTestWebhook(t, WithDeduplication(NewMemoryDedupStore(time.Minute), byTransaction))
+
+		var handled []string
+		require.NoError(t, RegisterHandler(wh, func(event *models.TransactionEvent) {
+			handled = append(handled, event.XPubID)
+		}))
+
+		deliver(t, wh,
+			rawEvent(t, transactionEvent("alice", "tx", "MINED")),
+			rawEvent(t, transactionEvent("bob", "tx", "MINED")),
+		)
+
+		_, err := wh.Shutdown(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"alice"}, handled)
+	})
+
+	t.Run("does not deduplicate the replayed dead letters", func(t *testing.T) {
+		sink := NewMemoryDeadLetterSink()
+		wh := newTestWebhook(t, WithDeduplication(nil, nil), WithDeadLetterSink(sink))
+
+		failing := true
+		var handled int
+		require.NoError(t, RegisterHandlerWithError(wh, func(_ context.Context, event *models.StringEvent) error {
+			if failing {
+				return context.DeadlineExceeded
+			}
+			handled++
+			return nil
+		}))
+
+		deliver(t, wh, stringEvents(t, "a")...)
+		_, err := wh.Shutdown(context.Background())
+		require.NoError(t, err)
+
+		failing = false
+		require.NoError(t, wh.Replay(context.Background(), sink.Drain()...))
+		require.Equal(t, 1, handled)
+	})
+}
@@ -0,0 +1,30 @@
+package notifications
+
+import "github.com/bitcoin-sv/spv-wallet/models"
+
+// ErrNoHandler is when no handler is registered for the type of an event
+var ErrNoHandler = models.SPVError{Message: "no handler registered for the event type", StatusCode: 500, Code: "error-webhook-no-handler"}
+
+// ErrEventDecode is when the content of an event cannot be decoded into the model of its handler
+var ErrEventDecode = models.SPVError{Message: "cannot decode the event content", StatusCode: 500, Code: "error-webhook-event-decode"}
+
+// ErrHandlerPanic is when a handler panics while processing an event
+var ErrHandlerPanic = models.SPVError{Message: "event handler panicked", StatusCode: 500, Code: "error-webhook-handler-panic"}
+
+// ErrBufferFull is when an event cannot be enqueued because the buffer of the webhook is full
+var ErrBufferFull = models.SPVError{Message: "webhook buffer is full", StatusCode: 503, Code: "error-webhook-buffer-full"}
+
+// ErrWebhookStopped is when an event cannot be enqueued because the root context of the webhook is done
+var ErrWebhookStopped = models.SPVError{Message: "webhook is stopped", StatusCode: 503, Code: "error-webhook-stopped"}
+
+// ErrMissingWebhookSignature is when a delivery has no signature or timestamp header while the HMAC verification is enabled
+var ErrMissingWebhookSignature = models.SPVError{Message: "missing webhook signature", StatusCode: 401, Code: "error-webhook-signature-missing"}
+
+// ErrInvalidWebhookSignature is when the signature of a delivery does not match any of the secrets
+var ErrInvalidWebhookSignature = models.SPVError{Message: "invalid webhook signature", StatusCode: 401, Code: "error-webhook-signature-invalid"}
+
+// ErrWebhookSignatureExpired is when the timestamp of a delivery is outside of the tolerance window
+var ErrWebhookSignatureExpired = models.SPVError{Message: "webhook signature expired", StatusCode: 401, Code: "error-webhook-signature-expired"}
+
+// ErrHMACNotEnabled is when the HMAC secret is rotated on a webhook without the HMAC verification
+var ErrHMACNotEnabled = models.SPVError{Message: "webhook HMAC verification is not enabled", StatusCode: 500, Code: "error-webhook-hmac-not-enabled"}
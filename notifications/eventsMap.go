@@ -1,25 +1,67 @@
 package notifications
 
-import "sync"
+import (
+	"slices"
+	"sync"
+)
+
+// catchAllEvents is the key of the handlers of the events without a handler of their own type
+const catchAllEvents = "*"
 
 type eventsMap struct {
-	registered *sync.Map
+	mu         sync.RWMutex
+	registered map[string][]*eventHandler
 }
 
 func newEventsMap() *eventsMap {
 	return &eventsMap{
-		registered: &sync.Map{},
+		registered: make(map[string][]*eventHandler),
 	}
 }
 
-func (em *eventsMap) store(name string, handler *eventHandler) {
-	em.registered.Store(name, handler)
+// add registers the handler after the ones already registered for the name; the returned function unregisters it
+func (em *eventsMap) add(name string, handler *eventHandler) func() {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	// the slices are never modified in place, so the loaded ones can be used without the lock
+	em.registered[name] = append(slices.Clip(em.registered[name]), handler)
+	return func() {
+		em.removeHandler(name, handler)
+	}
 }
 
-func (em *eventsMap) load(name string) (*eventHandler, bool) {
-	h, ok := em.registered.Load(name)
-	if !ok {
-		return nil, false
+// removeHandler unregisters a single handler of the name, if it is still registered
+func (em *eventsMap) removeHandler(name string, handler *eventHandler) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	handlers := slices.DeleteFunc(slices.Clone(em.registered[name]), func(registered *eventHandler) bool {
+		return registered == handler
+	})
+	if len(handlers) == 0 {
+		delete(em.registered, name)
+		return
+	}
+	em.registered[name] = handlers
+}
+
+// remove unregisters all the handlers of the name
+func (em *eventsMap) remove(name string) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	delete(em.registered, name)
+}
+
+// load returns the handlers of the name, falling back to the catch-all handlers
+func (em *eventsMap) load(name string) ([]*eventHandler, bool) {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+
+	if handlers := em.registered[name]; len(handlers) > 0 {
+		return handlers, true
 	}
-	return h.(*eventHandler), true
+	handlers := em.registered[catchAllEvents]
+	return handlers, len(handlers) > 0
 }
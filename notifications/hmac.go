@@ -0,0 +1,117 @@
+package notifications
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Headers of the HMAC signed deliveries
+const (
+	// SignatureHeader holds the hex encoded HMAC-SHA256 of the timestamp and the body of the delivery
+	SignatureHeader = "X-Spv-Wallet-Signature"
+	// TimestampHeader holds the unix time in seconds at which the delivery was signed
+	TimestampHeader = "X-Spv-Wallet-Timestamp"
+)
+
+// DefaultHMACTolerance is how far the timestamp of a signed delivery can be from the current time when no tolerance is given
+const DefaultHMACTolerance = 5 * time.Minute
+
+// HMACSigner - signs the deliveries like the HMAC verification of the webhook expects, e.g. in tests or local stand-ins of the spv-wallet
+type HMACSigner struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewHMACSigner - creates a signer with the secret
+func NewHMACSigner(secret string) *HMACSigner {
+	return &HMACSigner{secret: []byte(secret), now: time.Now}
+}
+
+// SignRequest - sets the timestamp and signature headers of the request for the body
+func (s *HMACSigner) SignRequest(req *http.Request, body []byte) {
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, s.Signature(timestamp, body))
+}
+
+// Signature - returns the hex encoded HMAC-SHA256 of the timestamp, a dot and the body
+func (s *HMACSigner) Signature(timestamp string, body []byte) string {
+	return hex.EncodeToString(hmacSum(s.secret, timestamp, body))
+}
+
+func hmacSum(secret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// hmacVerifier verifies the signature of the deliveries with the current or the previous secret, so the secret can be rotated
+type hmacVerifier struct {
+	tolerance time.Duration
+	now       func() time.Time
+
+	mu       sync.RWMutex
+	current  []byte
+	previous []byte
+}
+
+func newHMACVerifier(current, previous string, tolerance time.Duration) *hmacVerifier {
+	if tolerance <= 0 {
+		tolerance = DefaultHMACTolerance
+	}
+	verifier := &hmacVerifier{tolerance: tolerance, now: time.Now, current: []byte(current)}
+	if previous != "" {
+		verifier.previous = []byte(previous)
+	}
+	return verifier
+}
+
+// rotate makes the secret the current one; the current one stays valid as the previous one
+func (v *hmacVerifier) rotate(secret string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.previous = v.current
+	v.current = []byte(secret)
+}
+
+// verify checks the timestamp and the signature headers of the request against the body
+func (v *hmacVerifier) verify(header http.Header, body []byte) error {
+	timestamp, signature := header.Get(TimestampHeader), header.Get(SignatureHeader)
+	if timestamp == "" || signature == "" {
+		return ErrMissingWebhookSignature
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidWebhookSignature.Wrap(err)
+	}
+	if age := v.now().Sub(time.Unix(seconds, 0)); age > v.tolerance || age < -v.tolerance {
+		return ErrWebhookSignatureExpired
+	}
+
+	sum, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidWebhookSignature.Wrap(err)
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	// both secrets are always checked, so the time does not tell which one matched
+	valid := hmac.Equal(sum, hmacSum(v.current, timestamp, body))
+	if v.previous != nil {
+		valid = hmac.Equal(sum, hmacSum(v.previous, timestamp, body)) || valid
+	}
+	if !valid {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
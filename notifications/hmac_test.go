@@ -0,0 +1,83 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook_HMACVerification(t *testing.T) {
+	body, err := json.Marshal([]*models.RawEvent{rawEvent(t, &models.StringEvent{Value: "hello"})})
+	require.NoError(t, err)
+
+	send := func(wh *Webhook, body []byte, sign func(req *http.Request)) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+		if sign != nil {
+			sign(req)
+		}
+		recorder := httptest.NewRecorder()
+		wh.HTTPHandler().ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+	signedWith := func(signer *HMACSigner, body []byte) func(req *http.Request) {
+		return func(req *http.Request) { signer.SignRequest(req, body) }
+	}
+
+	t.Run("accepts the deliveries signed with the secret", func(t *testing.T) {
+		wh := newTestWebhook(t, WithHMACVerification("secret", "", 0))
+
+		require.Equal(t, http.StatusOK, send(wh, body, signedWith(NewHMACSigner("secret"), body)))
+	})
+
+	t.Run("rejects the unsigned, tampered and wrongly signed deliveries", func(t *testing.T) {
+		wh := newTestWebhook(t, WithHMACVerification("secret", "", 0))
+		signer := NewHMACSigner("secret")
+		tampered := bytes.Replace(body, []byte("hello"), []byte("hullo"), 1)
+
+		require.Equal(t, http.StatusUnauthorized, send(wh, body, nil))
+		require.Equal(t, http.StatusUnauthorized, send(wh, tampered, signedWith(signer, body)))
+		require.Equal(t, http.StatusUnauthorized, send(wh, body, signedWith(NewHMACSigner("other"), body)))
+	})
+
+	t.Run("rejects the deliveries signed outside of the tolerance", func(t *testing.T) {
+		wh := newTestWebhook(t, WithHMACVerification("secret", "", time.Minute))
+		signer := NewHMACSigner("secret")
+		signer.now = func() time.Time { return time.Now().Add(-2 * time.Minute) }
+
+		require.Equal(t, http.StatusUnauthorized, send(wh, body, signedWith(signer, body)))
+	})
+
+	t.Run("accepts both secrets while rotating", func(t *testing.T) {
+		wh := newTestWebhook(t, WithHMACVerification("first", "", 0))
+		require.NoError(t, wh.RotateHMACSecret("second"))
+
+		require.Equal(t, http.StatusOK, send(wh, body, signedWith(NewHMACSigner("first"), body)))
+		require.Equal(t, http.StatusOK, send(wh, body, signedWith(NewHMACSigner("second"), body)))
+
+		require.NoError(t, wh.RotateHMACSecret("third"))
+		require.Equal(t, http.StatusUnauthorized, send(wh, body, signedWith(NewHMACSigner("first"), body)))
+	})
+
+	t.Run("cannot rotate the secret without the HMAC verification", func(t *testing.T) {
+		wh := newTestWebhook(t)
+
+		require.ErrorIs(t, wh.RotateHMACSecret("secret"), ErrHMACNotEnabled)
+	})
+}
+
+func TestHMACVerifier(t *testing.T) {
+	verifier := newHMACVerifier("secret", "", time.Minute)
+	body := []byte(`[]`)
+	header := http.Header{}
+	header.Set(TimestampHeader, "not-a-number")
+	header.Set(SignatureHeader, NewHMACSigner("secret").Signature("not-a-number", body))
+
+	require.ErrorIs(t, verifier.verify(header, body), ErrInvalidWebhookSignature)
+	require.ErrorIs(t, verifier.verify(http.Header{}, body), ErrMissingWebhookSignature)
+}
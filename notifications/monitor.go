@@ -0,0 +1,260 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/bitcoin-sv/spv-wallet/models/filter"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// DefaultMonitorInterval is how often the subscription is checked when no interval is given
+const DefaultMonitorInterval = 1 * time.Minute
+
+// defaultGapFillPageSize is the number of transactions fetched per request by the TransactionGapFiller
+const defaultGapFillPageSize = 100
+
+// gapFillOverlap extends the filled gap past the resubscription, so the events of the clock skew between the client
+// and the spv-wallet are not lost; the events both delivered and recovered are dropped by the deduplication of the webhook
+const gapFillOverlap = 5 * time.Second
+
+// attributeSubscriptionState is recorded on the subscription state changes metric
+const attributeSubscriptionState = attribute.Key("spv_wallet.webhook.subscription_state")
+
+// SubscriptionState - the state of the subscription of the webhook in the spv-wallet
+type SubscriptionState int
+
+const (
+	// SubscriptionUnknown is the state before the first check
+	SubscriptionUnknown SubscriptionState = iota
+	// SubscriptionActive is when the webhook is subscribed and not banned
+	SubscriptionActive
+	// SubscriptionMissing is when the webhook is not subscribed
+	SubscriptionMissing
+	// SubscriptionBanned is when the spv-wallet banned the webhook, e.g. after failed deliveries
+	SubscriptionBanned
+)
+
+// String - returns the name of the state
+func (s SubscriptionState) String() string {
+	switch s {
+	case SubscriptionActive:
+		return "active"
+	case SubscriptionMissing:
+		return "missing"
+	case SubscriptionBanned:
+		return "banned"
+	default:
+		return "unknown"
+	}
+}
+
+// SubscriptionChange - a change of the subscription state detected by the monitor
+type SubscriptionChange struct {
+	Previous SubscriptionState
+	Current  SubscriptionState
+	At       time.Time
+}
+
+// WebhookLister - lists the webhooks subscribed to the spv-wallet; the WalletClient implements it
+type WebhookLister interface {
+	AdminGetWebhooks(ctx context.Context) ([]*models.Webhook, error)
+}
+
+// GapFiller - recovers the events missed while the webhook was not subscribed, e.g. the TransactionGapFiller
+type GapFiller interface {
+	FillGap(ctx context.Context, from, to time.Time) error
+}
+
+// TransactionsGetter - searches the transactions of a user; the WalletClient implements it
+type TransactionsGetter interface {
+	GetTransactions(ctx context.Context, conditions *filter.TransactionFilter, metadata map[string]any, queryParams *filter.QueryParams) ([]*models.Transaction, error)
+}
+
+// TransactionGapFiller - a GapFiller synthesizing a TransactionEvent for each transaction of a user updated during the gap;
+// the events are queued in the webhook and processed by its handlers like the delivered ones
+type TransactionGapFiller struct {
+	webhook  *Webhook
+	getter   TransactionsGetter
+	xpubID   string
+	pageSize int
+}
+
+// NewTransactionGapFiller - creates a gap filler queuing the transactions of the user with xpubID in the webhook;
+// the getter must be a client of the same user
+func NewTransactionGapFiller(webhook *Webhook, getter TransactionsGetter, xpubID string) *TransactionGapFiller {
+	return &TransactionGapFiller{webhook: webhook, getter: getter, xpubID: xpubID, pageSize: defaultGapFillPageSize}
+}
+
+// FillGap - queues an event for each transaction updated between from and to, oldest first.
+// The transactions are paged by keyset like the ones of the Poller, so a transaction updated again while the gap is filled,
+// which moves out of the range, does not make the others skipped.
+func (f *TransactionGapFiller) FillGap(ctx context.Context, from, to time.Time) error {
+	_, err := pollRange(ctx, f.webhook, transactionPollSource(f.getter, f.xpubID), &PollCursor{UpdatedAt: from}, to, f.pageSize, nil)
+	return err
+}
+
+// synthesizedTransactionEvent synthesizes the TransactionEvent of a transaction of the user with xpubID
+func synthesizedTransactionEvent(xpubID string, tx *models.Transaction) (*models.RawEvent, error) {
+	outputs := tx.Outputs
+	if len(outputs) == 0 {
+		outputs = map[string]int64{xpubID: tx.OutputValue}
+	}
+	content, err := json.Marshal(&models.TransactionEvent{
+		UserEvent:       models.UserEvent{XPubID: xpubID},
+		TransactionID:   tx.ID,
+		Status:          tx.Status,
+		XpubOutputValue: outputs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.RawEvent{Type: reflect.TypeFor[models.TransactionEvent]().Name(), Content: content}, nil
+}
+
+// MonitorOptions - options of the subscription monitor
+type MonitorOptions struct {
+	// Interval is how often the subscription is checked; DefaultMonitorInterval when zero
+	Interval time.Duration
+	// OnStateChange is called when the state of the subscription changes
+	OnStateChange func(ctx context.Context, change *SubscriptionChange)
+	// GapFiller, when set, is called on every check which finds the subscription missing or banned,
+	// with the time range since the subscription was last seen active or the gap was last filled
+	GapFiller GapFiller
+	// MeterProvider creates the spv_wallet.webhook.subscription_changes counter; the global provider when nil
+	MeterProvider metric.MeterProvider
+}
+
+// subscriptionMonitor keeps the webhook subscribed
+type subscriptionMonitor struct {
+	webhook *Webhook
+	lister  WebhookLister
+	options *MonitorOptions
+	changes metric.Int64Counter
+
+	state SubscriptionState
+	// coveredUntil is the time until which the events are received or recovered
+	coveredUntil time.Time
+}
+
+// Monitor - checks periodically that the webhook is subscribed to the spv-wallet and not banned, and subscribes it again otherwise;
+// it runs until ctx is done. The lister is usually the WalletClient used as the subscriber.
+func (w *Webhook) Monitor(ctx context.Context, lister WebhookLister, opts *MonitorOptions) error {
+	if opts == nil {
+		opts = &MonitorOptions{}
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultMonitorInterval
+	}
+	meterProvider := opts.MeterProvider
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+	changes, err := meterProvider.Meter(instrumentationName).Int64Counter("spv_wallet.webhook.subscription_changes",
+		metric.WithDescription("Number of changes of the webhook subscription state"),
+		metric.WithUnit("{change}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	monitor := &subscriptionMonitor{webhook: w, lister: lister, options: opts, changes: changes}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		monitor.check(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// check updates the state of the subscription, subscribes the webhook again if needed and fills the gap
+func (m *subscriptionMonitor) check(ctx context.Context) {
+	logger := m.webhook.options.Logger
+	now := time.Now()
+
+	state, err := m.subscriptionState(ctx)
+	if err != nil {
+		logger.WarnContext(ctx, "cannot check the webhook subscription", slog.String("url", m.webhook.URL), slog.String("error", err.Error()))
+		return
+	}
+	m.setState(ctx, state, now)
+	if state == SubscriptionActive {
+		m.coveredUntil = now
+		return
+	}
+
+	// the gap ends once the webhook is subscribed again, as the events until then are not delivered
+	resubscribeErr := m.resubscribe(ctx, state)
+	until := time.Now()
+	if resubscribeErr != nil {
+		logger.ErrorContext(ctx, "cannot resubscribe the webhook", slog.String("url", m.webhook.URL), slog.String("error", resubscribeErr.Error()))
+	} else {
+		m.setState(ctx, SubscriptionActive, until)
+		until = until.Add(gapFillOverlap)
+	}
+
+	// the events are recovered from the last time the subscription was seen active
+	if m.options.GapFiller == nil || m.coveredUntil.IsZero() {
+		return
+	}
+	if err = m.options.GapFiller.FillGap(ctx, m.coveredUntil, until); err != nil {
+		logger.WarnContext(ctx, "cannot fill the webhook gap", slog.Time("from", m.coveredUntil), slog.String("error", err.Error()))
+		return
+	}
+	m.coveredUntil = until
+}
+
+// subscriptionState finds the webhook in the subscribed ones
+func (m *subscriptionMonitor) subscriptionState(ctx context.Context) (SubscriptionState, error) {
+	webhooks, err := m.lister.AdminGetWebhooks(ctx)
+	if err != nil {
+		return SubscriptionUnknown, err
+	}
+	for _, webhook := range webhooks {
+		if webhook.URL != m.webhook.URL {
+			continue
+		}
+		if webhook.Banned {
+			return SubscriptionBanned, nil
+		}
+		return SubscriptionActive, nil
+	}
+	return SubscriptionMissing, nil
+}
+
+// resubscribe subscribes the webhook again; a banned one is unsubscribed first
+func (m *subscriptionMonitor) resubscribe(ctx context.Context, state SubscriptionState) error {
+	if state == SubscriptionBanned {
+		if err := m.webhook.Unsubscribe(ctx); err != nil {
+			return err
+		}
+	}
+	return m.webhook.Subscribe(ctx)
+}
+
+// setState records a change of the state
+func (m *subscriptionMonitor) setState(ctx context.Context, state SubscriptionState, at time.Time) {
+	if state == m.state {
+		return
+	}
+	change := &SubscriptionChange{Previous: m.state, Current: state, At: at}
+	m.state = state
+
+	m.webhook.options.Logger.InfoContext(ctx, "webhook subscription state changed",
+		slog.String("url", m.webhook.URL), slog.String("previous", change.Previous.String()), slog.String("current", change.Current.String()))
+	m.changes.Add(ctx, 1, metric.WithAttributes(attributeSubscriptionState.String(state.String())))
+	if m.options.OnStateChange != nil {
+		m.options.OnStateChange(ctx, change)
+	}
+}
@@ -0,0 +1,262 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/bitcoin-sv/spv-wallet/models/filter"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// spvWalletMock keeps the webhook subscriptions and the transactions of a user
+type spvWalletMock struct {
+	mu           sync.Mutex
+	webhooks     map[string]*models.Webhook
+	listErr      error
+	transactions []*models.Transaction
+}
+
+func (s *spvWalletMock) AdminSubscribeWebhook(_ context.Context, url, _, _ string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhooks[url] = &models.Webhook{URL: url}
+	return nil
+}
+
+func (s *spvWalletMock) AdminUnsubscribeWebhook(_ context.Context, url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.webhooks, url)
+	return nil
+}
+
+func (s *spvWalletMock) AdminGetWebhooks(context.Context) ([]*models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listErr != nil {
+		return nil, s.listErr
+	}
+	webhooks := make([]*models.Webhook, 0, len(s.webhooks))
+	for _, webhook := range s.webhooks {
+		copied := *webhook
+		webhooks = append(webhooks, &copied)
+	}
+	return webhooks, nil
+}
+
+func (s *spvWalletMock) GetTransactions(_ context.Context, conditions *filter.TransactionFilter, _ map[string]any, queryParams *filter.QueryParams) ([]*models.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matching []*models.Transaction
+	for _, tx := range s.transactions {
+		updated := conditions.UpdatedRange
+		if updated != nil && ((updated.From != nil && tx.UpdatedAt.Before(*updated.From)) || (updated.To != nil && tx.UpdatedAt.After(*updated.To))) {
+			continue
+		}
+		matching = append(matching, tx)
+	}
+	start := min((queryParams.Page-1)*queryParams.PageSize, len(matching))
+	end := min(start+queryParams.PageSize, len(matching))
+	return matching[start:end], nil
+}
+
+func (s *spvWalletMock) ban(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhooks[url].Banned = true
+}
+
+// slowSubscriber calls onSubscribe before the webhook is subscribed, like the updates made while the subscription is in progress
+type slowSubscriber struct {
+	*spvWalletMock
+	onSubscribe func()
+}
+
+func (s *slowSubscriber) AdminSubscribeWebhook(ctx context.Context, url, tokenHeader, tokenValue string) error {
+	s.onSubscribe()
+	return s.spvWalletMock.AdminSubscribeWebhook(ctx, url, tokenHeader, tokenValue)
+}
+
+type gapRecorder struct {
+	gaps [][2]time.Time
+}
+
+func (g *gapRecorder) FillGap(_ context.Context, from, to time.Time) error {
+	g.gaps = append(g.gaps, [2]time.Time{from, to})
+	return nil
+}
+
+func TestWebhook_Monitor(t *testing.T) {
+	const url = "http://localhost/webhook"
+
+	newMonitor := func(t *testing.T, spvWallet *spvWalletMock, opts *MonitorOptions) (*subscriptionMonitor, *[]*SubscriptionChange) {
+		var changes []*SubscriptionChange
+		opts.OnStateChange = func(_ context.Context, change *SubscriptionChange) {
+			changes = append(changes, change)
+		}
+		wh := NewWebhook(spvWallet, url, WithProcessors(0))
+		counter, err := sdkmetric.NewMeterProvider().Meter("test").Int64Counter("changes")
+		require.NoError(t, err)
+		return &subscriptionMonitor{webhook: wh, lister: spvWallet, options: opts, changes: counter}, &changes
+	}
+
+	t.Run("resubscribes a banned webhook and fills the gap", func(t *testing.T) {
+		spvWallet := &spvWalletMock{webhooks: map[string]*models.Webhook{url: {URL: url}}}
+		gaps := &gapRecorder{}
+		monitor, changes := newMonitor(t, spvWallet, &MonitorOptions{GapFiller: gaps})
+		ctx := context.Background()
+
+		monitor.check(ctx)
+		coveredUntil := monitor.coveredUntil
+		spvWallet.ban(url)
+		monitor.check(ctx)
+
+		require.Equal(t, SubscriptionActive, monitor.state)
+		webhooks, err := spvWallet.AdminGetWebhooks(ctx)
+		require.NoError(t, err)
+		require.False(t, webhooks[0].Banned)
+
+		var states []SubscriptionState
+		for _, change := range *changes {
+			states = append(states, change.Current)
+		}
+		require.Equal(t, []SubscriptionState{SubscriptionActive, SubscriptionBanned, SubscriptionActive}, states)
+
+		require.Len(t, gaps.gaps, 1)
+		require.Equal(t, coveredUntil, gaps.gaps[0][0])
+	})
+
+	t.Run("recovers the events updated while the webhook is resubscribed", func(t *testing.T) {
+		spvWallet := &spvWalletMock{webhooks: map[string]*models.Webhook{url: {URL: url}}}
+		subscriber := &slowSubscriber{spvWalletMock: spvWallet, onSubscribe: func() {}}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		wh := NewWebhook(subscriber, url, WithRootContext(ctx), WithProcessors(1))
+		var received []string
+		require.NoError(t, RegisterHandler(wh, func(event *models.TransactionEvent) {
+			received = append(received, event.TransactionID)
+		}))
+		counter, err := sdkmetric.NewMeterProvider().Meter("test").Int64Counter("changes")
+		require.NoError(t, err)
+		monitor := &subscriptionMonitor{webhook: wh, lister: spvWallet, changes: counter,
+			options: &MonitorOptions{GapFiller: NewTransactionGapFiller(wh, spvWallet, "xpub")}}
+
+		monitor.check(ctx)
+		spvWallet.ban(url)
+		subscriber.onSubscribe = func() {
+			time.Sleep(time.Millisecond)
+			spvWallet.transactions = append(spvWallet.transactions, polledTransaction("during", time.Now()))
+		}
+		monitor.check(ctx)
+		_, err = wh.Shutdown(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, SubscriptionActive, monitor.state)
+		require.Equal(t, []string{"during"}, received)
+	})
+
+	t.Run("subscribes a missing webhook without a gap on the first check", func(t *testing.T) {
+		spvWallet := &spvWalletMock{webhooks: map[string]*models.Webhook{}}
+		gaps := &gapRecorder{}
+		monitor, changes := newMonitor(t, spvWallet, &MonitorOptions{GapFiller: gaps})
+
+		monitor.check(context.Background())
+
+		require.Len(t, spvWallet.webhooks, 1)
+		require.Len(t, *changes, 2)
+		require.Equal(t, SubscriptionMissing, (*changes)[0].Current)
+		require.Empty(t, gaps.gaps)
+	})
+
+	t.Run("keeps the state when the webhooks cannot be listed", func(t *testing.T) {
+		spvWallet := &spvWalletMock{webhooks: map[string]*models.Webhook{url: {URL: url}}}
+		monitor, changes := newMonitor(t, spvWallet, &MonitorOptions{})
+		ctx := context.Background()
+
+		monitor.check(ctx)
+		spvWallet.listErr = errors.New("spv-wallet unavailable")
+		monitor.check(ctx)
+
+		require.Equal(t, SubscriptionActive, monitor.state)
+		require.Len(t, *changes, 1)
+	})
+
+	t.Run("records the state changes metric", func(t *testing.T) {
+		spvWallet := &spvWalletMock{webhooks: map[string]*models.Webhook{}}
+		reader := sdkmetric.NewManualReader()
+		wh := NewWebhook(spvWallet, url, WithProcessors(0))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, wh.Monitor(ctx, spvWallet, &MonitorOptions{MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))}))
+
+		var metrics metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &metrics))
+		sum := metrics.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+		require.Len(t, sum.DataPoints, 2)
+	})
+}
+
+func TestTransactionGapFiller(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("queues the transactions updated during the gap", func(t *testing.T) {
+		spvWallet := &spvWalletMock{}
+		for i, status := range []string{"MINED", "SEEN_ON_NETWORK", "MINED"} {
+			tx := &models.Transaction{ID: string(rune('a' + i)), Status: status, OutputValue: int64(i + 1)}
+			tx.UpdatedAt = base.Add(time.Duration(i) * time.Minute)
+			spvWallet.transactions = append(spvWallet.transactions, tx)
+		}
+
+		wh := newTestWebhook(t)
+		var received []*models.TransactionEvent
+		require.NoError(t, RegisterHandler(wh, func(event *models.TransactionEvent) {
+			received = append(received, event)
+		}))
+		filler := NewTransactionGapFiller(wh, spvWallet, "xpub")
+		filler.pageSize = 1
+
+		require.NoError(t, filler.FillGap(context.Background(), base.Add(30*time.Second), base.Add(3*time.Minute)))
+		_, err := wh.Shutdown(context.Background())
+		require.NoError(t, err)
+
+		require.Len(t, received, 2)
+		require.Equal(t, "b", received[0].TransactionID)
+		require.Equal(t, "xpub", received[0].XPubID)
+		require.Equal(t, map[string]int64{"xpub": 2}, received[0].XpubOutputValue)
+		require.Equal(t, "c", received[1].TransactionID)
+	})
+
+	t.Run("does not skip transactions when one is updated during the fill", func(t *testing.T) {
+		spvWallet := &spvWalletMock{transactions: []*models.Transaction{
+			polledTransaction("a", base),
+			polledTransaction("b", base.Add(time.Minute)),
+			polledTransaction("c", base.Add(2*time.Minute)),
+			polledTransaction("d", base.Add(3*time.Minute)),
+		}}
+		getter := &updatingTransactions{spvWalletMock: spvWallet, afterFirstPage: func() {
+			spvWallet.transactions[0] = polledTransaction("a", base.Add(time.Hour))
+		}}
+
+		wh := newTestWebhook(t)
+		var received []string
+		require.NoError(t, RegisterHandler(wh, func(event *models.TransactionEvent) {
+			received = append(received, event.TransactionID)
+		}))
+		filler := NewTransactionGapFiller(wh, getter, "xpub")
+		filler.pageSize = 2
+
+		require.NoError(t, filler.FillGap(context.Background(), base, base.Add(3*time.Minute)))
+		_, err := wh.Shutdown(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"a", "b", "c", "d"}, received)
+	})
+}
@@ -2,7 +2,25 @@ package notifications
 
 import (
 	"context"
+	"io"
+	"log/slog"
 	"runtime"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// FullBufferPolicy - what the webhook does with a delivered event when its buffer is full
+type FullBufferPolicy int
+
+const (
+	// FullBufferBlock waits up to the block timeout for room in the buffer, then rejects the event
+	FullBufferBlock FullBufferPolicy = iota
+	// FullBufferDropOldest makes room by sending the oldest buffered event to the dead-letter sink
+	FullBufferDropOldest
+	// FullBufferReject rejects the event right away
+	FullBufferReject
 )
 
 // WebhookOptions - options for the webhook
@@ -12,6 +30,38 @@ type WebhookOptions struct {
 	BufferSize  int
 	RootContext context.Context
 	Processors  int
+	// HMACSecret enables the HMAC verification of the deliveries, see SignatureHeader and TimestampHeader
+	HMACSecret string
+	// HMACPreviousSecret is also accepted by the HMAC verification, while the spv-wallet switches to a new secret
+	HMACPreviousSecret string
+	// HMACTolerance is how far the timestamp of a delivery can be from the current time; DefaultHMACTolerance when zero
+	HMACTolerance time.Duration
+	// TracerProvider creates the spans of the processed events; tracing is disabled by default
+	TracerProvider trace.TracerProvider
+	// Logger logs the subscription lifecycle and the dropped events; nothing is logged by default
+	Logger *slog.Logger
+	// EventAttempts is the number of times a handler is called for an event before the event is dead-lettered
+	EventAttempts int
+	// EventRetryBackoff is the delay between the attempts to handle an event
+	EventRetryBackoff time.Duration
+	// Queue buffers the received events until they are processed; by default an in-memory queue of BufferSize events
+	Queue Queue
+	// EventKey enables the ordered processing: the events with the same key are processed sequentially, by the same processor
+	EventKey EventKeyFunc
+	// DedupStore enables the deduplication: the events already processed are skipped; Replay is not deduplicated
+	DedupStore DedupStore
+	// EventID returns the identity of the deduplicated events; DefaultEventID when nil
+	EventID EventIDFunc
+	// FullBufferPolicy decides what happens to the delivered events when the buffer is full
+	FullBufferPolicy FullBufferPolicy
+	// BlockTimeout is how long the FullBufferBlock policy waits for room in the buffer
+	BlockTimeout time.Duration
+	// RetryAfter is sent to the spv-wallet in the Retry-After header when some events are rejected
+	RetryAfter time.Duration
+	// UnsubscribeOnShutdown makes Shutdown unsubscribe the webhook from the spv-wallet first
+	UnsubscribeOnShutdown bool
+	// DeadLetterSink receives the events which could not be processed; failed events are only logged when nil
+	DeadLetterSink DeadLetterSink
 }
 
 // NewWebhookOptions - creates a new webhook options
@@ -22,6 +72,16 @@ func NewWebhookOptions() *WebhookOptions {
 		BufferSize:  100,
 		Processors:  runtime.NumCPU(),
 		RootContext: context.Background(),
+
+		TracerProvider: noop.NewTracerProvider(),
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+
+		EventAttempts:     1,
+		EventRetryBackoff: 100 * time.Millisecond,
+
+		FullBufferPolicy: FullBufferBlock,
+		BlockTimeout:     1 * time.Second,
+		RetryAfter:       5 * time.Second,
 	}
 }
 
@@ -36,6 +96,16 @@ func WithToken(tokenHeader, tokenValue string) WebhookOpts {
 	}
 }
 
+// WithHMACVerification - accepts only the deliveries signed with the secret, or the previous one while it is rotated,
+// and whose timestamp is within the tolerance of the current time, which limits their replay
+func WithHMACVerification(secret, previousSecret string, tolerance time.Duration) WebhookOpts {
+	return func(w *WebhookOptions) {
+		w.HMACSecret = secret
+		w.HMACPreviousSecret = previousSecret
+		w.HMACTolerance = tolerance
+	}
+}
+
 // WithBufferSize - sets the buffer size
 func WithBufferSize(size int) WebhookOpts {
 	return func(w *WebhookOptions) {
@@ -56,3 +126,85 @@ func WithProcessors(count int) WebhookOpts {
 		w.Processors = count
 	}
 }
+
+// WithTracerProvider - sets the OpenTelemetry tracer provider used to create a span for each processed event
+func WithTracerProvider(provider trace.TracerProvider) WebhookOpts {
+	return func(w *WebhookOptions) {
+		w.TracerProvider = provider
+	}
+}
+
+// WithLogger - sets the logger of the webhook
+func WithLogger(logger *slog.Logger) WebhookOpts {
+	return func(w *WebhookOptions) {
+		w.Logger = logger
+	}
+}
+
+// WithEventRetry - sets how many times a failing handler is called for an event, and the delay between the attempts
+func WithEventRetry(attempts int, backoff time.Duration) WebhookOpts {
+	return func(w *WebhookOptions) {
+		w.EventAttempts = attempts
+		w.EventRetryBackoff = backoff
+	}
+}
+
+// WithDeadLetterSink - sets the sink receiving the events which could not be processed
+func WithDeadLetterSink(sink DeadLetterSink) WebhookOpts {
+	return func(w *WebhookOptions) {
+		w.DeadLetterSink = sink
+	}
+}
+
+// WithQueue - sets the queue buffering the received events, e.g. a FileQueue to keep them across restarts; BufferSize is then ignored
+func WithQueue(queue Queue) WebhookOpts {
+	return func(w *WebhookOptions) {
+		w.Queue = queue
+	}
+}
+
+// WithOrdering - processes the events with the same key sequentially, in the order they were received,
+// while the events with different keys are still processed in parallel; DefaultEventKey is used when key is nil
+func WithOrdering(key EventKeyFunc) WebhookOpts {
+	return func(w *WebhookOptions) {
+		if key == nil {
+			key = DefaultEventKey
+		}
+		w.EventKey = key
+	}
+}
+
+// WithDeduplication - processes the events with the same identity once, e.g. when the spv-wallet delivers a batch again after a timeout;
+// a MemoryDedupStore with DefaultDedupTTL is used when store is nil, and DefaultEventID when id is nil
+func WithDeduplication(store DedupStore, id EventIDFunc) WebhookOpts {
+	return func(w *WebhookOptions) {
+		if store == nil {
+			store = NewMemoryDedupStore(DefaultDedupTTL)
+		}
+		w.DedupStore = store
+		w.EventID = id
+	}
+}
+
+// WithFullBufferPolicy - sets what happens to the delivered events when the buffer is full;
+// blockTimeout is how long the FullBufferBlock policy waits for room in the buffer
+func WithFullBufferPolicy(policy FullBufferPolicy, blockTimeout time.Duration) WebhookOpts {
+	return func(w *WebhookOptions) {
+		w.FullBufferPolicy = policy
+		w.BlockTimeout = blockTimeout
+	}
+}
+
+// WithRetryAfter - sets the delay sent to the spv-wallet in the Retry-After header when some events are rejected
+func WithRetryAfter(delay time.Duration) WebhookOpts {
+	return func(w *WebhookOptions) {
+		w.RetryAfter = delay
+	}
+}
+
+// WithUnsubscribeOnShutdown - makes Shutdown unsubscribe the webhook from the spv-wallet before draining the events
+func WithUnsubscribeOnShutdown() WebhookOpts {
+	return func(w *WebhookOptions) {
+		w.UnsubscribeOnShutdown = true
+	}
+}
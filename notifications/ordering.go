@@ -0,0 +1,86 @@
+package notifications
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"reflect"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// EventKeyFunc - returns the ordering key of an event; the events with the same key are processed sequentially,
+// in the order they were received. An empty key means the event can be processed in any order.
+type EventKeyFunc func(event *models.RawEvent) string
+
+// DefaultEventKey - the ordering key of the known events: the XPubID of a TransactionEvent, or its TransactionID
+// when it has no XPubID; the other events have no key
+func DefaultEventKey(event *models.RawEvent) string {
+	if event.Type != reflect.TypeFor[models.TransactionEvent]().Name() {
+		return ""
+	}
+	var content models.TransactionEvent
+	if err := json.Unmarshal(event.Content, &content); err != nil {
+		return ""
+	}
+	if content.XPubID != "" {
+		return content.XPubID
+	}
+	return content.TransactionID
+}
+
+// processOrdered dispatches the queued events to one loop per processor, by the hash of their key,
+// so the events with the same key are processed sequentially while the ones with different keys run in parallel
+func (w *Webhook) processOrdered() {
+	defer w.processors.Done()
+
+	shards := make([]chan *QueuedEvent, max(w.options.Processors, 1))
+	w.processors.Add(len(shards))
+	for i := range shards {
+		shards[i] = make(chan *QueuedEvent, max(w.options.BufferSize/len(shards), 1))
+		go w.processShard(shards[i])
+	}
+	defer func() {
+		for _, shard := range shards {
+			close(shard)
+		}
+	}()
+
+	var next int
+	for {
+		queued, ok := w.dequeue()
+		if !ok {
+			return
+		}
+
+		shard := next
+		if key := w.options.EventKey(queued.Event); key != "" {
+			shard = shardIndex(key, len(shards))
+		} else {
+			next = (next + 1) % len(shards)
+		}
+
+		select {
+		case shards[shard] <- queued:
+		case <-w.options.RootContext.Done():
+			return
+		}
+	}
+}
+
+// processShard processes the events of a shard sequentially
+func (w *Webhook) processShard(shard <-chan *QueuedEvent) {
+	defer w.processors.Done()
+	for queued := range shard {
+		if w.options.RootContext.Err() != nil {
+			return
+		}
+		w.complete(queued)
+	}
+}
+
+// shardIndex returns the shard of the key
+func shardIndex(key string, shards int) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return int(hash.Sum32() % uint32(shards))
+}
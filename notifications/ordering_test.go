@@ -0,0 +1,91 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+func transactionEvent(xpubID, txID, status string) *models.TransactionEvent {
+	return &models.TransactionEvent{UserEvent: models.UserEvent{XPubID: xpubID}, TransactionID: txID, Status: status}
+}
+
+func TestDefaultEventKey(t *testing.T) {
+	require.Equal(t, "xpub", DefaultEventKey(rawEvent(t, transactionEvent("xpub", "tx", "MINED"))))
+	require.Equal(t, "tx", DefaultEventKey(rawEvent(t, transactionEvent("", "tx", "MINED"))))
+	require.Empty(t, DefaultEventKey(rawEvent(t, &models.StringEvent{Value: "hello"})))
+}
+
+func TestWebhook_Ordering(t *testing.T) {
+	t.Run("processes the events with the same key in order", func(t *testing.T) {
+		wh := newTestWebhook(t, WithProcessors(4), WithBufferSize(200), WithOrdering(nil))
+
+		var (
+			mu       sync.Mutex
+			statuses = make(map[string][]string)
+			wg       sync.WaitGroup
+		)
+		require.NoError(t, RegisterHandler(wh, func(event *models.TransactionEvent) {
+			defer wg.Done()
+			time.Sleep(time.Duration(len(event.Status)%3) * time.Millisecond)
+			mu.Lock()
+			statuses[event.XPubID] = append(statuses[event.XPubID], event.Status)
+			mu.Unlock()
+		}))
+
+		var events []*models.RawEvent
+		for i := 0; i < 20; i++ {
+			for _, xpubID := range []string{"alice", "bob", "carol"} {
+				events = append(events, rawEvent(t, transactionEvent(xpubID, "tx", fmt.Sprint(i))))
+			}
+		}
+		wg.Add(len(events))
+		require.Equal(t, http.StatusOK, deliver(t, wh, events...).Code)
+		wg.Wait()
+
+		for _, xpubID := range []string{"alice", "bob", "carol"} {
+			for i, status := range statuses[xpubID] {
+				require.Equal(t, fmt.Sprint(i), status, xpubID)
+			}
+			require.Len(t, statuses[xpubID], 20)
+		}
+	})
+
+	t.Run("processes the events with different keys in parallel", func(t *testing.T) {
+		wh := newTestWebhook(t, WithProcessors(2), WithOrdering(func(event *models.RawEvent) string {
+			return string(event.Content)
+		}))
+
+		started := make(chan struct{}, 2)
+		release := make(chan struct{})
+		require.NoError(t, RegisterHandler(wh, func(event *models.StringEvent) {
+			started <- struct{}{}
+			<-release
+		}))
+		defer close(release)
+
+		// find two keys which hash to different shards of two
+		first := rawEvent(t, &models.StringEvent{Value: "a"})
+		second := first
+		for i := 0; shardIndex(string(second.Content), 2) == shardIndex(string(first.Content), 2); i++ {
+			second = rawEvent(t, &models.StringEvent{Value: fmt.Sprint(i)})
+		}
+		deliver(t, wh, first, second)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		for i := 0; i < 2; i++ {
+			select {
+			case <-started:
+			case <-ctx.Done():
+				t.Fatal("the events with different keys are not processed in parallel")
+			}
+		}
+	})
+}
@@ -0,0 +1,281 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/bitcoin-sv/spv-wallet/models/filter"
+)
+
+// defaultPollPageSize is the number of records fetched per request by the Poller
+const defaultPollPageSize = 100
+
+// DefaultPollInterval is how often the Poller queries the spv-wallet when no interval is given
+const DefaultPollInterval = 30 * time.Second
+
+// Types of the polled events which are not models.Events; they are passed to the catch-all handlers
+const (
+	// ContactEventType is the type of the events of the updated contacts, their content is a models.Contact
+	ContactEventType = "ContactEvent"
+	// UtxoEventType is the type of the events of the updated utxos, their content is a models.Utxo
+	UtxoEventType = "UtxoEvent"
+)
+
+// Names of the poll sources, under which their cursors are stored
+const (
+	transactionsSource = "transactions"
+	contactsSource     = "contacts"
+	utxosSource        = "utxos"
+)
+
+// ContactsGetter - searches the contacts of a user; the WalletClient implements it
+type ContactsGetter interface {
+	GetContacts(ctx context.Context, conditions *filter.ContactFilter, metadata map[string]any, queryParams *filter.QueryParams) (*models.SearchContactsResponse, error)
+}
+
+// UtxosGetter - searches the utxos of a user; the WalletClient implements it
+type UtxosGetter interface {
+	GetUtxos(ctx context.Context, conditions *filter.UtxoFilter, metadata map[string]any, queryParams *filter.QueryParams) ([]*models.Utxo, error)
+}
+
+// polledRecord is a record updated in the spv-wallet, with its event
+type polledRecord struct {
+	id        string
+	updatedAt time.Time
+	event     *models.RawEvent
+}
+
+// pollSource fetches a page of the records updated between from and to, oldest first
+type pollSource struct {
+	name  string
+	fetch func(ctx context.Context, updated *filter.TimeRange, queryParams *filter.QueryParams) ([]*polledRecord, error)
+}
+
+// PollerOptions - options of the Poller
+type PollerOptions struct {
+	// Interval is how often the spv-wallet is queried; DefaultPollInterval when zero
+	Interval time.Duration
+	// PageSize is the number of records fetched per request
+	PageSize int
+	// Cursors persists the position of the poller, so it resumes where it stopped; a MemoryCursorStore when nil
+	Cursors CursorStore
+	// Since is where the poller starts when no cursor is stored; the time of the first poll when zero
+	Since time.Time
+	// Contacts, when set, makes the poller emit a ContactEventType event for each updated contact
+	Contacts ContactsGetter
+	// Utxos, when set, makes the poller emit a UtxoEventType event for each updated utxo
+	Utxos UtxosGetter
+}
+
+// Poller - an event source for the deployments which cannot expose the webhook endpoint: it queries the spv-wallet periodically
+// for the records of a user updated since the last poll, synthesizes their events and queues them in the webhook,
+// to be processed by its handlers like the delivered events. The webhook does not need to be subscribed.
+type Poller struct {
+	webhook *Webhook
+	sources []*pollSource
+	options *PollerOptions
+}
+
+// NewPoller - creates a poller of the transactions of the user with xpubID, and of its contacts and utxos if set in the options;
+// the getters must be clients of the same user
+func NewPoller(webhook *Webhook, transactions TransactionsGetter, xpubID string, opts *PollerOptions) *Poller {
+	if opts == nil {
+		opts = &PollerOptions{}
+	}
+	options := *opts
+	if options.Interval <= 0 {
+		options.Interval = DefaultPollInterval
+	}
+	if options.PageSize <= 0 {
+		options.PageSize = defaultPollPageSize
+	}
+	if options.Cursors == nil {
+		options.Cursors = NewMemoryCursorStore()
+	}
+
+	sources := []*pollSource{transactionPollSource(transactions, xpubID)}
+	if options.Contacts != nil {
+		sources = append(sources, contactPollSource(options.Contacts))
+	}
+	if options.Utxos != nil {
+		sources = append(sources, utxoPollSource(options.Utxos))
+	}
+	return &Poller{webhook: webhook, sources: sources, options: &options}
+}
+
+// Run - polls the spv-wallet every interval until ctx is done; the failed polls are logged and retried at the next interval
+func (p *Poller) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.options.Interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Poll(ctx); err != nil && ctx.Err() == nil {
+			p.webhook.options.Logger.WarnContext(ctx, "cannot poll the spv-wallet", slog.String("error", err.Error()))
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Poll - queues the events of the records updated since the stored cursors, and moves the cursors forward
+func (p *Poller) Poll(ctx context.Context) error {
+	now := time.Now()
+	for _, source := range p.sources {
+		cursor, err := p.options.Cursors.Load(ctx, source.name)
+		if err != nil {
+			return err
+		}
+		if cursor == nil {
+			since := p.options.Since
+			if since.IsZero() {
+				since = now
+			}
+			cursor = &PollCursor{UpdatedAt: since}
+		}
+
+		_, err = pollRange(ctx, p.webhook, source, cursor, now, p.options.PageSize, func(cursor *PollCursor) error {
+			return p.options.Cursors.Save(ctx, source.name, cursor)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pollRange queues the events of the records of the source updated from the cursor to the time to, and returns the moved cursor.
+// It pages by keyset on the update time and the id: every request starts at the update time of the cursor, and the records
+// at that time which it already holds are skipped, so a record updated during the poll, which leaves the range, does not shift
+// the records after it out of the next page. The cursor moves with every queued record; it is saved after each page,
+// and when the poll fails, if save is set.
+func pollRange(ctx context.Context, webhook *Webhook, source *pollSource, cursor *PollCursor, to time.Time, pageSize int, save func(*PollCursor) error) (*PollCursor, error) {
+	webhook.startProcessors()
+
+	saved := cursor
+	saveMoved := func(err error) (*PollCursor, error) {
+		if save == nil || cursor == saved {
+			return cursor, err
+		}
+		if saveErr := save(cursor); saveErr != nil {
+			return cursor, errors.Join(err, saveErr)
+		}
+		saved = cursor
+		return cursor, err
+	}
+
+	// the records sharing the update time of the cursor are paged by offset only when they fill whole pages
+	page := 1
+	for {
+		from := cursor.UpdatedAt
+		records, err := source.fetch(ctx, &filter.TimeRange{From: &from, To: &to}, &filter.QueryParams{
+			Page:          page,
+			PageSize:      pageSize,
+			OrderByField:  "updated_at",
+			SortDirection: "asc",
+		})
+		if err != nil {
+			return saveMoved(err)
+		}
+
+		moved := false
+		for _, record := range records {
+			if record.updatedAt.Before(cursor.UpdatedAt) || (record.updatedAt.Equal(cursor.UpdatedAt) && slices.Contains(cursor.IDs, record.id)) {
+				continue
+			}
+			if err = webhook.admit(ctx, record.event); err != nil {
+				return saveMoved(err)
+			}
+			cursor = cursor.next(record.id, record.updatedAt)
+			moved = true
+		}
+		if _, err = saveMoved(nil); err != nil {
+			return cursor, err
+		}
+		if len(records) < pageSize {
+			return cursor, nil
+		}
+		if moved {
+			page = 1
+		} else {
+			page++
+		}
+	}
+}
+
+func transactionPollSource(getter TransactionsGetter, xpubID string) *pollSource {
+	return &pollSource{
+		name: transactionsSource,
+		fetch: func(ctx context.Context, updated *filter.TimeRange, queryParams *filter.QueryParams) ([]*polledRecord, error) {
+			transactions, err := getter.GetTransactions(ctx, &filter.TransactionFilter{ModelFilter: filter.ModelFilter{UpdatedRange: updated}}, nil, queryParams)
+			if err != nil {
+				return nil, err
+			}
+			records := make([]*polledRecord, 0, len(transactions))
+			for _, tx := range transactions {
+				event, err := synthesizedTransactionEvent(xpubID, tx)
+				if err != nil {
+					return nil, err
+				}
+				records = append(records, &polledRecord{id: tx.ID, updatedAt: tx.UpdatedAt, event: event})
+			}
+			return records, nil
+		},
+	}
+}
+
+func contactPollSource(getter ContactsGetter) *pollSource {
+	return &pollSource{
+		name: contactsSource,
+		fetch: func(ctx context.Context, updated *filter.TimeRange, queryParams *filter.QueryParams) ([]*polledRecord, error) {
+			response, err := getter.GetContacts(ctx, &filter.ContactFilter{ModelFilter: filter.ModelFilter{UpdatedRange: updated}}, nil, queryParams)
+			if err != nil {
+				return nil, err
+			}
+			records := make([]*polledRecord, 0, len(response.Content))
+			for _, contact := range response.Content {
+				event, err := polledEvent(ContactEventType, contact)
+				if err != nil {
+					return nil, err
+				}
+				records = append(records, &polledRecord{id: contact.ID, updatedAt: contact.UpdatedAt, event: event})
+			}
+			return records, nil
+		},
+	}
+}
+
+func utxoPollSource(getter UtxosGetter) *pollSource {
+	return &pollSource{
+		name: utxosSource,
+		fetch: func(ctx context.Context, updated *filter.TimeRange, queryParams *filter.QueryParams) ([]*polledRecord, error) {
+			utxos, err := getter.GetUtxos(ctx, &filter.UtxoFilter{ModelFilter: filter.ModelFilter{UpdatedRange: updated}}, nil, queryParams)
+			if err != nil {
+				return nil, err
+			}
+			records := make([]*polledRecord, 0, len(utxos))
+			for _, utxo := range utxos {
+				event, err := polledEvent(UtxoEventType, utxo)
+				if err != nil {
+					return nil, err
+				}
+				records = append(records, &polledRecord{id: utxo.ID, updatedAt: utxo.UpdatedAt, event: event})
+			}
+			return records, nil
+		},
+	}
+}
+
+func polledEvent(eventType string, content any) (*models.RawEvent, error) {
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	return &models.RawEvent{Type: eventType, Content: raw}, nil
+}
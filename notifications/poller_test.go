@@ -0,0 +1,156 @@
+package notifications
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/bitcoin-sv/spv-wallet/models/filter"
+	"github.com/stretchr/testify/require"
+)
+
+type contactsAndUtxosMock struct {
+	contacts []*models.Contact
+	utxos    []*models.Utxo
+}
+
+func (m *contactsAndUtxosMock) GetContacts(_ context.Context, _ *filter.ContactFilter, _ map[string]any, queryParams *filter.QueryParams) (*models.SearchContactsResponse, error) {
+	if queryParams.Page > 1 {
+		return &models.SearchContactsResponse{}, nil
+	}
+	return &models.SearchContactsResponse{Content: m.contacts}, nil
+}
+
+func (m *contactsAndUtxosMock) GetUtxos(_ context.Context, _ *filter.UtxoFilter, _ map[string]any, queryParams *filter.QueryParams) ([]*models.Utxo, error) {
+	if queryParams.Page > 1 {
+		return nil, nil
+	}
+	return m.utxos, nil
+}
+
+// updatingTransactions calls afterFirstPage once the first page of transactions is returned
+type updatingTransactions struct {
+	*spvWalletMock
+	afterFirstPage func()
+	pages          int
+}
+
+func (u *updatingTransactions) GetTransactions(ctx context.Context, conditions *filter.TransactionFilter, metadata map[string]any, queryParams *filter.QueryParams) ([]*models.Transaction, error) {
+	transactions, err := u.spvWalletMock.GetTransactions(ctx, conditions, metadata, queryParams)
+	u.pages++
+	if u.pages == 1 {
+		u.afterFirstPage()
+	}
+	return transactions, err
+}
+
+func polledTransaction(id string, updatedAt time.Time) *models.Transaction {
+	tx := &models.Transaction{ID: id, Status: "MINED", OutputValue: 1}
+	tx.UpdatedAt = updatedAt
+	return tx
+}
+
+func TestPoller(t *testing.T) {
+	base := time.Now().Add(-time.Hour).UTC()
+
+	t.Run("resumes from the persisted cursor", func(t *testing.T) {
+		spvWallet := &spvWalletMock{transactions: []*models.Transaction{
+			polledTransaction("a", base),
+			polledTransaction("b", base.Add(time.Minute)),
+			polledTransaction("c", base.Add(time.Minute)),
+		}}
+		cursors := NewFileCursorStore(filepath.Join(t.TempDir(), "cursors.json"))
+
+		poll := func() []string {
+			wh := newTestWebhook(t)
+			var (
+				mu       sync.Mutex
+				received []string
+			)
+			require.NoError(t, RegisterHandler(wh, func(event *models.TransactionEvent) {
+				mu.Lock()
+				defer mu.Unlock()
+				received = append(received, event.TransactionID)
+			}))
+			poller := NewPoller(wh, spvWallet, "xpub", &PollerOptions{Cursors: cursors, Since: base, PageSize: 2})
+			require.NoError(t, poller.Poll(context.Background()))
+			_, err := wh.Shutdown(context.Background())
+			require.NoError(t, err)
+			return received
+		}
+
+		require.Equal(t, []string{"a", "b", "c"}, poll())
+
+		spvWallet.transactions = append(spvWallet.transactions,
+			polledTransaction("d", base.Add(time.Minute)),
+			polledTransaction("e", base.Add(2*time.Minute)),
+		)
+		require.Equal(t, []string{"d", "e"}, poll())
+		require.Empty(t, poll())
+
+		cursor, err := cursors.Load(context.Background(), transactionsSource)
+		require.NoError(t, err)
+		require.True(t, cursor.UpdatedAt.Equal(base.Add(2*time.Minute)))
+		require.Equal(t, []string{"e"}, cursor.IDs)
+	})
+
+	t.Run("emits the updated contacts and utxos to the catch-all handlers", func(t *testing.T) {
+		contact := &models.Contact{ID: "contact", Paymail: "alice@example.com"}
+		contact.UpdatedAt = base
+		utxo := &models.Utxo{ID: "utxo", Satoshis: 100}
+		utxo.UpdatedAt = base
+		getters := &contactsAndUtxosMock{contacts: []*models.Contact{contact}, utxos: []*models.Utxo{utxo}}
+
+		wh := newTestWebhook(t)
+		var types []string
+		require.NoError(t, RegisterCatchAllHandler(wh, func(_ context.Context, event *models.RawEvent) error {
+			types = append(types, event.Type)
+			return nil
+		}))
+		poller := NewPoller(wh, &spvWalletMock{}, "xpub", &PollerOptions{Since: base, Contacts: getters, Utxos: getters})
+
+		require.NoError(t, poller.Poll(context.Background()))
+		_, err := wh.Shutdown(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, []string{ContactEventType, UtxoEventType}, types)
+	})
+	t.Run("does not queue events once the webhook is shut down", func(t *testing.T) {
+		spvWallet := &spvWalletMock{transactions: []*models.Transaction{polledTransaction("a", base)}}
+		wh := newTestWebhook(t)
+		_, err := wh.Shutdown(context.Background())
+		require.NoError(t, err)
+		poller := NewPoller(wh, spvWallet, "xpub", &PollerOptions{Since: base})
+
+		err = poller.Poll(context.Background())
+
+		require.ErrorIs(t, err, ErrWebhookStopped)
+		require.Zero(t, wh.queue.Len())
+	})
+	t.Run("does not skip records when a polled one is updated during the poll", func(t *testing.T) {
+		spvWallet := &spvWalletMock{transactions: []*models.Transaction{
+			polledTransaction("a", base),
+			polledTransaction("b", base.Add(time.Minute)),
+			polledTransaction("c", base.Add(2*time.Minute)),
+			polledTransaction("d", base.Add(3*time.Minute)),
+		}}
+		getter := &updatingTransactions{spvWalletMock: spvWallet, afterFirstPage: func() {
+			spvWallet.transactions[0] = polledTransaction("a", time.Now().Add(time.Hour))
+		}}
+		wh := newTestWebhook(t)
+		var received []string
+		require.NoError(t, RegisterHandler(wh, func(event *models.TransactionEvent) {
+			received = append(received, event.TransactionID)
+		}))
+		poller := NewPoller(wh, getter, "xpub", &PollerOptions{Since: base, PageSize: 2})
+
+		require.NoError(t, poller.Poll(context.Background()))
+		_, err := wh.Shutdown(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"a", "b", "c", "d"}, received)
+	})
+}
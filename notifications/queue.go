@@ -0,0 +1,471 @@
+package notifications
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// QueuedEvent - an event taken from the queue; it must be marked as done once it is processed
+type QueuedEvent struct {
+	ID    uint64
+	Event *models.RawEvent
+}
+
+// Queue - buffers the received events until they are processed.
+// The webhook acknowledges the events only once Enqueue returns, and marks them as done once they are handled or dead-lettered,
+// so a persistent queue gives at-least-once delivery across restarts.
+type Queue interface {
+	// Enqueue stores the event; it blocks while the queue is full, until the context is done
+	Enqueue(ctx context.Context, event *models.RawEvent) error
+	// TryEnqueue stores the event if the queue is not full, otherwise it returns ErrBufferFull
+	TryEnqueue(event *models.RawEvent) error
+	// DropOldest removes the oldest event which is not being processed, to make room for a new one; it returns nil if there is none
+	DropOldest() (*QueuedEvent, error)
+	// Dequeue takes the oldest event which is not being processed; it blocks while the queue is empty, until the context is done
+	Dequeue(ctx context.Context) (*QueuedEvent, error)
+	// Done removes the processed event from the queue
+	Done(id uint64) error
+	// Len returns the number of events waiting to be dequeued
+	Len() int
+}
+
+// MemoryQueue - keeps the events in a bounded channel; the events are lost if the process stops
+type MemoryQueue struct {
+	mu     sync.Mutex
+	nextID uint64
+	events chan *QueuedEvent
+}
+
+// NewMemoryQueue - creates an in-memory queue holding up to size events
+func NewMemoryQueue(size int) *MemoryQueue {
+	return &MemoryQueue{events: make(chan *QueuedEvent, size)}
+}
+
+// Enqueue - adds the event to the queue
+func (q *MemoryQueue) Enqueue(ctx context.Context, event *models.RawEvent) error {
+	select {
+	case q.events <- q.wrap(event):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TryEnqueue - adds the event to the queue if it is not full
+func (q *MemoryQueue) TryEnqueue(event *models.RawEvent) error {
+	select {
+	case q.events <- q.wrap(event):
+		return nil
+	default:
+		return ErrBufferFull
+	}
+}
+
+// DropOldest - removes the oldest event from the queue
+func (q *MemoryQueue) DropOldest() (*QueuedEvent, error) {
+	select {
+	case queued := <-q.events:
+		return queued, nil
+	default:
+		return nil, nil
+	}
+}
+
+// Dequeue - takes the oldest event from the queue
+func (q *MemoryQueue) Dequeue(ctx context.Context) (*QueuedEvent, error) {
+	select {
+	case queued := <-q.events:
+		return queued, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Done - does nothing, the event is removed from the queue by Dequeue
+func (q *MemoryQueue) Done(uint64) error {
+	return nil
+}
+
+// Len - returns the number of events in the queue
+func (q *MemoryQueue) Len() int {
+	return len(q.events)
+}
+
+func (q *MemoryQueue) wrap(event *models.RawEvent) *QueuedEvent {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.nextID++
+	return &QueuedEvent{ID: q.nextID, Event: event}
+}
+
+// journal operations of the FileQueue
+const (
+	journalEnqueue = "enqueue"
+	journalDone    = "done"
+)
+
+// journalRecord - a line of the journal of the FileQueue
+type journalRecord struct {
+	Op    string           `json:"op"`
+	ID    uint64           `json:"id"`
+	Event *models.RawEvent `json:"event,omitempty"`
+}
+
+// journalCompactThreshold - the number of done records after which the journal of the FileQueue is compacted
+const journalCompactThreshold = 1000
+
+// FileQueueOpt - an option of the FileQueue
+type FileQueueOpt func(q *FileQueue)
+
+// WithJournalLogger - sets the logger of the FileQueue, which logs the corrupt records skipped in the journal; nothing is logged by default
+func WithJournalLogger(logger *slog.Logger) FileQueueOpt {
+	return func(q *FileQueue) {
+		q.logger = logger
+	}
+}
+
+// FileQueue - a persistent queue journaling the events to an append-only file, one JSON document per line.
+// Enqueued events are synced to the disk before Enqueue returns; the events which are not marked as done
+// when the process stops are dequeued again, oldest first, once the queue is reopened.
+// A failed write is truncated from the journal; the corrupt records found when it is reopened are logged and skipped.
+// The journal is truncated whenever all the events are done, and compacted to the events which are not done
+// once it holds journalCompactThreshold done records, so it does not grow under a steady load.
+type FileQueue struct {
+	path         string
+	size         int
+	compactAfter int
+	logger       *slog.Logger
+	notify       chan struct{}
+	space        chan struct{}
+
+	mu          sync.Mutex
+	file        *os.File
+	nextID      uint64
+	pending     []*QueuedEvent
+	inFlight    map[uint64]*QueuedEvent
+	doneRecords int
+}
+
+// NewFileQueue - opens the queue journaled at path, loading the events which were not processed; the file is created if it does not exist.
+// The queue holds up to size events waiting to be processed, or is unbounded if size is not positive;
+// the loaded events are kept even if there are more.
+func NewFileQueue(path string, size int, opts ...FileQueueOpt) (*FileQueue, error) {
+	q := &FileQueue{
+		path:         path,
+		size:         size,
+		compactAfter: journalCompactThreshold,
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		notify:       make(chan struct{}, 1),
+		space:        make(chan struct{}, 1),
+		inFlight:     make(map[uint64]*QueuedEvent),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	pending, nextID, err := readJournal(path, q.logger)
+	if err != nil {
+		return nil, err
+	}
+	if q.file, err = writeJournal(path, pending); err != nil {
+		return nil, err
+	}
+	q.nextID = nextID
+	q.pending = pending
+	if len(pending) > 0 {
+		signal(q.notify)
+	}
+	return q, nil
+}
+
+// Enqueue - appends the event to the journal and syncs it to the disk
+func (q *FileQueue) Enqueue(ctx context.Context, event *models.RawEvent) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := q.TryEnqueue(event); !errors.Is(err, ErrBufferFull) {
+			return err
+		}
+		select {
+		case <-q.space:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TryEnqueue - appends the event to the journal and syncs it to the disk if the queue is not full
+func (q *FileQueue) TryEnqueue(event *models.RawEvent) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.size > 0 && len(q.pending) >= q.size {
+		return ErrBufferFull
+	}
+
+	q.nextID++
+	queued := &QueuedEvent{ID: q.nextID, Event: event}
+	if err := q.append(&journalRecord{Op: journalEnqueue, ID: queued.ID, Event: event}); err != nil {
+		return err
+	}
+	if err := q.file.Sync(); err != nil {
+		return err
+	}
+	q.pending = append(q.pending, queued)
+	signal(q.notify)
+	if q.size <= 0 || len(q.pending) < q.size {
+		// pass the room left on to another blocked Enqueue
+		signal(q.space)
+	}
+	return nil
+}
+
+// DropOldest - removes the oldest event which is not being processed and records it as done in the journal
+func (q *FileQueue) DropOldest() (*QueuedEvent, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) == 0 {
+		return nil, nil
+	}
+	if err := q.append(&journalRecord{Op: journalDone, ID: q.pending[0].ID}); err != nil {
+		return nil, err
+	}
+	q.doneRecords++
+	return q.pop(), nil
+}
+
+// Dequeue - takes the oldest event which is not being processed
+func (q *FileQueue) Dequeue(ctx context.Context) (*QueuedEvent, error) {
+	for {
+		if queued := q.take(); queued != nil {
+			return queued, nil
+		}
+		select {
+		case <-q.notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Done - records in the journal that the event is processed; it is not dequeued again
+func (q *FileQueue) Done(id uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.inFlight[id]; !ok {
+		return nil
+	}
+	delete(q.inFlight, id)
+	if len(q.pending) == 0 && len(q.inFlight) == 0 {
+		q.doneRecords = 0
+		return q.file.Truncate(0)
+	}
+	if err := q.append(&journalRecord{Op: journalDone, ID: id}); err != nil {
+		return err
+	}
+	q.doneRecords++
+	if q.doneRecords >= q.compactAfter {
+		return q.compact()
+	}
+	return nil
+}
+
+// Len - returns the number of events waiting to be dequeued
+func (q *FileQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.pending)
+}
+
+// Close - closes the journal; the events which are not done are loaded again by NewFileQueue
+func (q *FileQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.file.Close()
+}
+
+func (q *FileQueue) take() *QueuedEvent {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) == 0 {
+		return nil
+	}
+	queued := q.pop()
+	q.inFlight[queued.ID] = queued
+	if len(q.pending) > 0 {
+		// wake up another processor for the remaining events
+		signal(q.notify)
+	}
+	return queued
+}
+
+// pop removes the oldest pending event and wakes up a blocked Enqueue
+func (q *FileQueue) pop() *QueuedEvent {
+	queued := q.pending[0]
+	q.pending[0] = nil
+	q.pending = q.pending[1:]
+	signal(q.space)
+	return queued
+}
+
+// signal wakes up one of the goroutines waiting on the channel, if any
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// compact replaces the journal with one holding only the events which are not done, the ones in flight first;
+// the current journal is kept if it fails
+func (q *FileQueue) compact() error {
+	events := make([]*QueuedEvent, 0, len(q.inFlight)+len(q.pending))
+	for _, queued := range q.inFlight {
+		events = append(events, queued)
+	}
+	slices.SortFunc(events, func(a, b *QueuedEvent) int { return cmp.Compare(a.ID, b.ID) })
+	events = append(events, q.pending...)
+
+	file, err := writeJournal(q.path, events)
+	if err != nil {
+		return err
+	}
+	_ = q.file.Close()
+	q.file = file
+	q.doneRecords = 0
+	return nil
+}
+
+// append writes the record to the journal; a partly written record is truncated, so the next ones are not written after it
+func (q *FileQueue) append(record *journalRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	info, err := q.file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err = q.file.Write(append(line, '\n')); err != nil {
+		return errors.Join(err, q.file.Truncate(info.Size()))
+	}
+	return nil
+}
+
+// readJournal returns the events of the journal which are not done, in the order they were enqueued, and the last used id;
+// the corrupt records are logged and skipped
+func readJournal(path string, logger *slog.Logger) ([]*QueuedEvent, uint64, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var (
+		pending []*QueuedEvent
+		done    = make(map[uint64]struct{})
+		lastID  uint64
+	)
+	reader := bufio.NewReader(file)
+	for number := 1; ; number++ {
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			record, err := decodeJournalRecord(line)
+			if err != nil {
+				logger.Warn("skipping a corrupt record of the event queue journal",
+					slog.String("path", path), slog.Int("line", number), slog.String("error", err.Error()))
+				continue
+			}
+			lastID = max(lastID, record.ID)
+			switch record.Op {
+			case journalEnqueue:
+				pending = append(pending, &QueuedEvent{ID: record.ID, Event: record.Event})
+			case journalDone:
+				done[record.ID] = struct{}{}
+			}
+		}
+		// a last line without a newline is a write interrupted by a crash; its event was never acknowledged
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return nil, 0, readErr
+		}
+	}
+
+	unprocessed := pending[:0]
+	for _, queued := range pending {
+		if _, ok := done[queued.ID]; !ok {
+			unprocessed = append(unprocessed, queued)
+		}
+	}
+	return unprocessed, lastID, nil
+}
+
+// decodeJournalRecord decodes a line of the journal, checking that it is a record the FileQueue writes
+func decodeJournalRecord(line []byte) (*journalRecord, error) {
+	record := new(journalRecord)
+	if err := json.Unmarshal(line, record); err != nil {
+		return nil, err
+	}
+	switch {
+	case record.Op == journalEnqueue && record.Event == nil:
+		return nil, fmt.Errorf("enqueue record %d has no event", record.ID)
+	case record.Op != journalEnqueue && record.Op != journalDone:
+		return nil, fmt.Errorf("unknown journal operation %q", record.Op)
+	}
+	return record, nil
+}
+
+// writeJournal replaces the journal with one holding only the given events, through a temporary file renamed over it;
+// it returns the new journal opened for appending
+func writeJournal(path string, events []*QueuedEvent) (*os.File, error) {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, queued := range events {
+		if err = encoder.Encode(&journalRecord{Op: journalEnqueue, ID: queued.ID, Event: queued.Event}); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if err = file.Sync(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,181 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileQueue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("replays the events which are not done after reopening", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.journal")
+		queue, err := NewFileQueue(path, 0)
+		require.NoError(t, err)
+
+		for _, value := range []string{"first", "second", "third"} {
+			require.NoError(t, queue.Enqueue(ctx, rawEvent(t, &models.StringEvent{Value: value})))
+		}
+		first, err := queue.Dequeue(ctx)
+		require.NoError(t, err)
+		require.NoError(t, queue.Done(first.ID))
+		_, err = queue.Dequeue(ctx) // in flight when the process stops
+		require.NoError(t, err)
+		require.NoError(t, queue.Close())
+
+		queue, err = NewFileQueue(path, 0)
+		require.NoError(t, err)
+		defer queue.Close()
+		require.Equal(t, 2, queue.Len())
+
+		second, err := queue.Dequeue(ctx)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"value":"second"}`, string(second.Event.Content))
+		third, err := queue.Dequeue(ctx)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"value":"third"}`, string(third.Event.Content))
+		require.Greater(t, third.ID, second.ID)
+	})
+
+	t.Run("truncates the journal once all the events are done", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.journal")
+		queue, err := NewFileQueue(path, 0)
+		require.NoError(t, err)
+		defer queue.Close()
+
+		require.NoError(t, queue.Enqueue(ctx, rawEvent(t, &models.StringEvent{Value: "hello"})))
+		queued, err := queue.Dequeue(ctx)
+		require.NoError(t, err)
+		require.NoError(t, queue.Done(queued.ID))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Zero(t, info.Size())
+	})
+
+	t.Run("compacts the journal under a steady load", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.journal")
+		queue, err := NewFileQueue(path, 0)
+		require.NoError(t, err)
+		queue.compactAfter = 3
+
+		require.NoError(t, queue.Enqueue(ctx, rawEvent(t, &models.StringEvent{Value: "stuck"})))
+		stuck, err := queue.Dequeue(ctx)
+		require.NoError(t, err)
+		for i := 0; i < 10; i++ {
+			require.NoError(t, queue.Enqueue(ctx, rawEvent(t, &models.StringEvent{Value: "processed"})))
+			require.NoError(t, queue.Enqueue(ctx, rawEvent(t, &models.StringEvent{Value: "waiting"})))
+			queued, err := queue.Dequeue(ctx)
+			require.NoError(t, err)
+			require.NoError(t, queue.Done(queued.ID))
+		}
+
+		journal, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Less(t, bytes.Count(journal, []byte("\n")), 20)
+		require.NoError(t, queue.Close())
+
+		queue, err = NewFileQueue(path, 0)
+		require.NoError(t, err)
+		defer queue.Close()
+		require.Equal(t, 11, queue.Len())
+		replayed, err := queue.Dequeue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, stuck.ID, replayed.ID)
+		require.JSONEq(t, `{"value":"stuck"}`, string(replayed.Event.Content))
+	})
+
+	t.Run("ignores a write interrupted by a crash", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.journal")
+		journal := `{"op":"enqueue","id":1,"event":{"type":"StringEvent","content":{"value":"hello"}}}` + "\n" +
+			`{"op":"enqueue","id":2,"event":{"type":"Str`
+		require.NoError(t, os.WriteFile(path, []byte(journal), 0o600))
+
+		queue, err := NewFileQueue(path, 0)
+		require.NoError(t, err)
+		defer queue.Close()
+		require.Equal(t, 1, queue.Len())
+	})
+
+	t.Run("skips a corrupt record in the middle of the journal", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.journal")
+		journal := `{"op":"enqueue","id":1,"event":{"type":"StringEvent","content":{"value":"first"}}}` + "\n" +
+			`{"op":"enqueue","id":2,"event":{"type":"Str` + "\n" +
+			`{"op":"enqueue","id":3,"event":{"type":"StringEvent","content":{"value":"third"}}}` + "\n" +
+			`{"op":"done","id":1}` + "\n"
+		require.NoError(t, os.WriteFile(path, []byte(journal), 0o600))
+		var logs bytes.Buffer
+
+		queue, err := NewFileQueue(path, 0, WithJournalLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+		require.NoError(t, err)
+		defer queue.Close()
+
+		require.Equal(t, 1, queue.Len())
+		queued, err := queue.Dequeue(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), queued.ID)
+		require.Contains(t, logs.String(), "line=2")
+	})
+
+	t.Run("bounds the events waiting to be processed", func(t *testing.T) {
+		queue, err := NewFileQueue(filepath.Join(t.TempDir(), "events.journal"), 1)
+		require.NoError(t, err)
+		defer queue.Close()
+
+		require.NoError(t, queue.TryEnqueue(rawEvent(t, &models.StringEvent{Value: "first"})))
+		require.ErrorIs(t, queue.TryEnqueue(rawEvent(t, &models.StringEvent{Value: "second"})), ErrBufferFull)
+
+		oldest, err := queue.DropOldest()
+		require.NoError(t, err)
+		require.JSONEq(t, `{"value":"first"}`, string(oldest.Event.Content))
+		require.NoError(t, queue.TryEnqueue(rawEvent(t, &models.StringEvent{Value: "second"})))
+	})
+
+	t.Run("blocks on an empty queue until the context is done", func(t *testing.T) {
+		queue, err := NewFileQueue(filepath.Join(t.TempDir(), "events.journal"), 0)
+		require.NoError(t, err)
+		defer queue.Close()
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = queue.Dequeue(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestWebhook_Queue(t *testing.T) {
+	t.Run("processes the events left in the journal", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.journal")
+		queue, err := NewFileQueue(path, 0)
+		require.NoError(t, err)
+		require.NoError(t, queue.Enqueue(context.Background(), rawEvent(t, &models.StringEvent{Value: "left over"})))
+		require.NoError(t, queue.Close())
+
+		queue, err = NewFileQueue(path, 0)
+		require.NoError(t, err)
+		defer queue.Close()
+		wh := newTestWebhook(t, WithQueue(queue))
+
+		received := make(chan string, 2)
+		require.NoError(t, RegisterHandler(wh, func(event *models.StringEvent) {
+			received <- event.Value
+		}))
+		require.Equal(t, http.StatusOK, deliver(t, wh, rawEvent(t, &models.StringEvent{Value: "new"})).Code)
+
+		require.Equal(t, "left over", <-received)
+		require.Equal(t, "new", <-received)
+		require.Eventually(t, func() bool {
+			info, err := os.Stat(path)
+			return err == nil && info.Size() == 0
+		}, time.Second, time.Millisecond)
+	})
+}
@@ -1,27 +1,117 @@
 package notifications
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"reflect"
 
 	"github.com/bitcoin-sv/spv-wallet/models"
 )
 
 type eventHandler struct {
-	Caller    reflect.Value
 	ModelType reflect.Type
+	handle    func(ctx context.Context, event *models.RawEvent) error
 }
 
-// RegisterHandler - registers a handler for a specific event type
+// call decodes the content of the event into the model of the handler and calls it; a panic of the handler is returned as an error
+func (h *eventHandler) call(ctx context.Context, event *models.RawEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = ErrHandlerPanic.Wrap(fmt.Errorf("%v", r))
+		}
+	}()
+	return h.handle(ctx, event)
+}
+
+// RegisterHandler - registers a handler for a specific event type; several handlers can be registered for the same type,
+// they are called in the order they are registered
 func RegisterHandler[EventType models.Events](nd *Webhook, handlerFunction func(event *EventType)) error {
-	handlerValue := reflect.ValueOf(handlerFunction)
+	SubscribeHandler(nd, handlerFunction)
+	return nil
+}
+
+// SubscribeHandler - registers a handler like RegisterHandler and returns the function removing it, leaving the other handlers registered
+func SubscribeHandler[EventType models.Events](nd *Webhook, handlerFunction func(event *EventType)) (unregister func()) {
+	return SubscribeHandlerWithError(nd, func(_ context.Context, event *EventType) error {
+		handlerFunction(event)
+		return nil
+	})
+}
 
-	modelType := handlerValue.Type().In(0).Elem()
+// RegisterHandlerWithError - registers a handler for a specific event type which can fail;
+// a failed event is retried according to the webhook options and then sent to the dead-letter sink
+func RegisterHandlerWithError[EventType models.Events](nd *Webhook, handlerFunction func(ctx context.Context, event *EventType) error) error {
+	SubscribeHandlerWithError(nd, handlerFunction)
+	return nil
+}
+
+// SubscribeHandlerWithError - registers a handler like RegisterHandlerWithError and returns the function removing it
+func SubscribeHandlerWithError[EventType models.Events](nd *Webhook, handlerFunction func(ctx context.Context, event *EventType) error) (unregister func()) {
+	modelType := reflect.TypeFor[EventType]()
 	name := modelType.Name()
 
-	nd.handlers.store(name, &eventHandler{
-		Caller:    handlerValue,
+	return nd.handlers.add(name, &eventHandler{
 		ModelType: modelType,
+		handle: func(ctx context.Context, event *models.RawEvent) error {
+			model := new(EventType)
+			if err := json.Unmarshal(event.Content, model); err != nil {
+				return ErrEventDecode.Wrap(err)
+			}
+			return handlerFunction(ctx, model)
+		},
 	})
+}
+
+// BlockHeaderEventType is the type of the events of the new blocks, their content is a models.BlockHeader;
+// they are not models.Events, so they have their own handlers
+const BlockHeaderEventType = "BlockHeaderEvent"
 
+// RegisterBlockHeaderHandler - registers a handler for the BlockHeaderEventType events,
+// e.g. one triggering the MerkleRootsFollower of the wallet client to sync the new merkle root right away
+func RegisterBlockHeaderHandler(nd *Webhook, handlerFunction func(ctx context.Context, header *models.BlockHeader) error) error {
+	SubscribeBlockHeaderHandler(nd, handlerFunction)
 	return nil
 }
+
+// SubscribeBlockHeaderHandler - registers a handler like RegisterBlockHeaderHandler and returns the function removing it
+func SubscribeBlockHeaderHandler(nd *Webhook, handlerFunction func(ctx context.Context, header *models.BlockHeader) error) (unregister func()) {
+	return nd.handlers.add(BlockHeaderEventType, &eventHandler{
+		ModelType: reflect.TypeFor[models.BlockHeader](),
+		handle: func(ctx context.Context, event *models.RawEvent) error {
+			header := new(models.BlockHeader)
+			if err := json.Unmarshal(event.Content, header); err != nil {
+				return ErrEventDecode.Wrap(err)
+			}
+			return handlerFunction(ctx, header)
+		},
+	})
+}
+
+// RegisterCatchAllHandler - registers a handler for the events without a handler of their own type,
+// e.g. the event types added to the spv-wallet after this client; the handler receives the raw event.
+// The catch-all handlers are a fallback, not observers: they are not called for an event whose type has handlers,
+// so they start receiving a type once all its handlers are unregistered.
+func RegisterCatchAllHandler(nd *Webhook, handlerFunction func(ctx context.Context, event *models.RawEvent) error) error {
+	SubscribeCatchAllHandler(nd, handlerFunction)
+	return nil
+}
+
+// SubscribeCatchAllHandler - registers a catch-all handler like RegisterCatchAllHandler and returns the function removing it
+func SubscribeCatchAllHandler(nd *Webhook, handlerFunction func(ctx context.Context, event *models.RawEvent) error) (unregister func()) {
+	return nd.handlers.add(catchAllEvents, &eventHandler{
+		ModelType: reflect.TypeFor[models.RawEvent](),
+		handle:    handlerFunction,
+	})
+}
+
+// Unregister - removes all the handlers of a specific event type, the ones registered by other callers included;
+// its events then go to the catch-all handlers. A single handler is removed with the function returned by SubscribeHandler.
+func Unregister[EventType models.Events](nd *Webhook) {
+	nd.handlers.remove(reflect.TypeFor[EventType]().Name())
+}
+
+// UnregisterCatchAll - removes all the catch-all handlers, the ones registered by other callers included
+func UnregisterCatchAll(nd *Webhook) {
+	nd.handlers.remove(catchAllEvents)
+}
@@ -0,0 +1,141 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterHandler(t *testing.T) {
+	t.Run("calls all the handlers of the type in order", func(t *testing.T) {
+		wh := newTestWebhook(t)
+		var calls []string
+		require.NoError(t, RegisterHandler(wh, func(event *models.StringEvent) {
+			calls = append(calls, "business:"+event.Value)
+		}))
+		require.NoError(t, RegisterHandler(wh, func(event *models.StringEvent) {
+			calls = append(calls, "audit:"+event.Value)
+		}))
+
+		require.NoError(t, wh.processEvent(context.Background(), rawEvent(t, &models.StringEvent{Value: "hello"})))
+		require.Equal(t, []string{"business:hello", "audit:hello"}, calls)
+	})
+
+	t.Run("retries only the failing handler and dead-letters the event", func(t *testing.T) {
+		sink := NewMemoryDeadLetterSink()
+		wh := newTestWebhook(t, WithEventRetry(2, 0), WithDeadLetterSink(sink))
+		var succeeded, failed int
+		require.NoError(t, RegisterHandler(wh, func(event *models.StringEvent) {
+			succeeded++
+		}))
+		require.NoError(t, RegisterHandlerWithError(wh, func(_ context.Context, event *models.StringEvent) error {
+			failed++
+			return errors.New("audit log unavailable")
+		}))
+
+		err := wh.processEvent(context.Background(), rawEvent(t, &models.StringEvent{Value: "hello"}))
+
+		require.EqualError(t, err, "audit log unavailable")
+		require.Equal(t, 1, succeeded)
+		require.Equal(t, 2, failed)
+		require.Len(t, sink.Letters(), 1)
+		require.Equal(t, 2, sink.Letters()[0].Attempts)
+	})
+
+	t.Run("passes the events without a handler to the catch-all handlers", func(t *testing.T) {
+		wh := newTestWebhook(t)
+		var typed []string
+		require.NoError(t, RegisterHandler(wh, func(event *models.StringEvent) {
+			typed = append(typed, event.Value)
+		}))
+		var raw []string
+		require.NoError(t, RegisterCatchAllHandler(wh, func(_ context.Context, event *models.RawEvent) error {
+			raw = append(raw, event.Type)
+			return nil
+		}))
+
+		ctx := context.Background()
+		require.NoError(t, wh.processEvent(ctx, rawEvent(t, &models.StringEvent{Value: "hello"})))
+		require.NoError(t, wh.processEvent(ctx, &models.RawEvent{Type: "FutureEvent", Content: json.RawMessage(`{}`)}))
+
+		require.Equal(t, []string{"hello"}, typed)
+		require.Equal(t, []string{"FutureEvent"}, raw)
+	})
+
+	t.Run("unregisters the handlers", func(t *testing.T) {
+		wh := newTestWebhook(t)
+		var mu sync.Mutex
+		var calls []string
+		require.NoError(t, RegisterHandler(wh, func(event *models.StringEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, "typed")
+		}))
+		require.NoError(t, RegisterCatchAllHandler(wh, func(context.Context, *models.RawEvent) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, "catch-all")
+			return nil
+		}))
+		ctx := context.Background()
+		event := rawEvent(t, &models.StringEvent{Value: "hello"})
+
+		Unregister[models.StringEvent](wh)
+		require.NoError(t, wh.processEvent(ctx, event))
+		UnregisterCatchAll(wh)
+		require.ErrorIs(t, wh.processEvent(ctx, event), ErrNoHandler)
+
+		require.Equal(t, []string{"catch-all"}, calls)
+	})
+
+	t.Run("unregisters a single handler", func(t *testing.T) {
+		wh := newTestWebhook(t)
+		var calls []string
+		unregisterFirst := SubscribeHandler(wh, func(event *models.StringEvent) {
+			calls = append(calls, "first")
+		})
+		require.NoError(t, RegisterHandler(wh, func(event *models.StringEvent) {
+			calls = append(calls, "second")
+		}))
+		unregisterCatchAll := SubscribeCatchAllHandler(wh, func(context.Context, *models.RawEvent) error {
+			calls = append(calls, "catch-all")
+			return nil
+		})
+		ctx := context.Background()
+		event := rawEvent(t, &models.StringEvent{Value: "hello"})
+
+		unregisterFirst()
+		unregisterFirst()
+		require.NoError(t, wh.processEvent(ctx, event))
+		unregisterCatchAll()
+		require.NoError(t, wh.processEvent(ctx, event))
+
+		require.Equal(t, []string{"second", "second"}, calls)
+	})
+
+	t.Run("decodes the block header events for their handlers", func(t *testing.T) {
+		wh := newTestWebhook(t)
+		var heights []uint32
+		require.NoError(t, RegisterBlockHeaderHandler(wh, func(_ context.Context, header *models.BlockHeader) error {
+			heights = append(heights, header.Height)
+			return nil
+		}))
+		var raw []string
+		require.NoError(t, RegisterCatchAllHandler(wh, func(_ context.Context, event *models.RawEvent) error {
+			raw = append(raw, event.Type)
+			return nil
+		}))
+
+		content, err := json.Marshal(&models.BlockHeader{ID: "block", Height: 840000})
+		require.NoError(t, err)
+		require.NoError(t, wh.processEvent(context.Background(), &models.RawEvent{Type: BlockHeaderEventType, Content: content}))
+
+		require.Equal(t, []uint32{840000}, heights)
+		require.Empty(t, raw)
+	})
+}
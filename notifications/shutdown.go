@@ -0,0 +1,93 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// drainPollInterval is how often Shutdown checks whether the queue is drained
+const drainPollInterval = 10 * time.Millisecond
+
+// Shutdown - stops the webhook gracefully: it unsubscribes from the spv-wallet if WithUnsubscribeOnShutdown is set,
+// rejects the new deliveries, waits for the ongoing ones, processes the queued events and waits for the running handlers.
+// When ctx is done first, Shutdown returns the number of events left unprocessed, the queued and the running ones, with the error of ctx;
+// the events left in a persistent queue are processed once it is reopened.
+func (w *Webhook) Shutdown(ctx context.Context) (int, error) {
+	var errs []error
+	if w.options.UnsubscribeOnShutdown {
+		if err := w.Unsubscribe(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	w.lifecycle.Lock()
+	w.closing = true
+	w.lifecycle.Unlock()
+
+	// the events left in a persistent queue are drained too
+	w.startProcessors()
+
+	err := waitGroup(ctx, &w.deliveries)
+	if err == nil {
+		err = w.waitDrained(ctx)
+	}
+	// the queue is empty or the time is up: the idle processors stop, the running handlers complete
+	w.stopProcessing()
+	if err == nil {
+		err = waitGroup(ctx, &w.processors)
+	}
+
+	unprocessed := 0
+	if err != nil {
+		errs = append(errs, err)
+		unprocessed = w.queue.Len() + int(w.running.Load())
+	}
+	w.options.Logger.InfoContext(ctx, "webhook shut down", slog.String("url", w.URL), slog.Int("unprocessed", unprocessed))
+	return unprocessed, errors.Join(errs...)
+}
+
+// beginDelivery registers an ongoing delivery; it returns false once the webhook is shutting down
+func (w *Webhook) beginDelivery() bool {
+	w.lifecycle.Lock()
+	defer w.lifecycle.Unlock()
+
+	if w.closing {
+		return false
+	}
+	w.deliveries.Add(1)
+	return true
+}
+
+// waitDrained waits until all the queued events are dequeued
+func (w *Webhook) waitDrained(ctx context.Context) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for w.queue.Len() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// waitGroup waits for the wait group until ctx is done
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,100 @@
+package notifications
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+type unsubscribeRecorder struct {
+	subscriberMock
+	unsubscribed []string
+}
+
+func (r *unsubscribeRecorder) AdminUnsubscribeWebhook(_ context.Context, url string) error {
+	r.unsubscribed = append(r.unsubscribed, url)
+	return nil
+}
+
+func stringEvents(t *testing.T, values ...string) []*models.RawEvent {
+	events := make([]*models.RawEvent, 0, len(values))
+	for _, value := range values {
+		events = append(events, rawEvent(t, &models.StringEvent{Value: value}))
+	}
+	return events
+}
+
+func TestWebhook_Shutdown(t *testing.T) {
+	t.Run("processes the queued events before returning", func(t *testing.T) {
+		for name, opts := range map[string][]WebhookOpts{
+			"unordered": nil,
+			"ordered":   {WithOrdering(nil), WithProcessors(2)},
+		} {
+			t.Run(name, func(t *testing.T) {
+				wh := newTestWebhook(t, opts...)
+				var handled atomic.Int32
+				require.NoError(t, RegisterHandler(wh, func(event *models.StringEvent) {
+					time.Sleep(5 * time.Millisecond)
+					handled.Add(1)
+				}))
+				require.Equal(t, http.StatusOK, deliver(t, wh, stringEvents(t, "a", "b", "c", "d", "e")...).Code)
+
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				unprocessed, err := wh.Shutdown(ctx)
+
+				require.NoError(t, err)
+				require.Zero(t, unprocessed)
+				require.Equal(t, int32(5), handled.Load())
+			})
+		}
+	})
+
+	t.Run("rejects the deliveries once shutting down", func(t *testing.T) {
+		wh := newTestWebhook(t)
+		_, err := wh.Shutdown(context.Background())
+		require.NoError(t, err)
+
+		recorder := deliver(t, wh, stringEvents(t, "a", "b")...)
+
+		require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		require.Contains(t, recorder.Body.String(), ErrWebhookStopped.Error())
+	})
+
+	t.Run("reports the events left unprocessed when the context is done", func(t *testing.T) {
+		wh := newTestWebhook(t)
+		release := make(chan struct{})
+		defer close(release)
+		started := make(chan struct{}, 3)
+		require.NoError(t, RegisterHandler(wh, func(event *models.StringEvent) {
+			started <- struct{}{}
+			<-release
+		}))
+		deliver(t, wh, stringEvents(t, "a", "b", "c")...)
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		unprocessed, err := wh.Shutdown(ctx)
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, 3, unprocessed)
+	})
+
+	t.Run("unsubscribes from the spv-wallet", func(t *testing.T) {
+		subscriber := &unsubscribeRecorder{}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		wh := NewWebhook(subscriber, "http://localhost/webhook", WithRootContext(ctx), WithUnsubscribeOnShutdown())
+
+		_, err := wh.Shutdown(context.Background())
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"http://localhost/webhook"}, subscriber.unsubscribed)
+	})
+}
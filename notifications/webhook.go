@@ -2,21 +2,55 @@ package notifications
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
 	"net/http"
-	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bitcoin-sv/spv-wallet/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationName is the name of the tracer of the webhook
+const instrumentationName = "github.com/bitcoin-sv/spv-wallet-go-client/notifications"
+
+// Attributes recorded on the spans of the processed events
+const (
+	attributeEventType    = attribute.Key("spv_wallet.event.type")
+	attributeEventHandled = attribute.Key("spv_wallet.event.handled")
 )
 
 // Webhook - the webhook event receiver
 type Webhook struct {
 	URL        string
 	options    *WebhookOptions
-	buffer     chan *models.RawEvent
+	queue      Queue
 	subscriber WebhookSubscriber
 	handlers   *eventsMap
+	tracer     trace.Tracer
+	dedup      *deduplicator
+	hmac       *hmacVerifier
+	start      sync.Once
+
+	// processing is canceled by Shutdown once the queue is drained, to stop the idle processors
+	processing     context.Context
+	stopProcessing context.CancelFunc
+	processors     sync.WaitGroup
+	running        atomic.Int64
+
+	lifecycle  sync.Mutex
+	closing    bool
+	deliveries sync.WaitGroup
 }
 
 // NewWebhook - creates a new webhook
@@ -25,76 +59,382 @@ func NewWebhook(subscriber WebhookSubscriber, url string, opts ...WebhookOpts) *
 	for _, opt := range opts {
 		opt(options)
 	}
+	if options.TracerProvider == nil {
+		options.TracerProvider = noop.NewTracerProvider()
+	}
+	if options.Logger == nil {
+		options.Logger = NewWebhookOptions().Logger
+	}
 
-	wh := &Webhook{
-		URL:        url,
-		options:    options,
-		buffer:     make(chan *models.RawEvent, options.BufferSize),
-		subscriber: subscriber,
-		handlers:   newEventsMap(),
+	queue := options.Queue
+	if queue == nil {
+		queue = NewMemoryQueue(options.BufferSize)
 	}
-	for i := 0; i < options.Processors; i++ {
-		go wh.process()
+
+	var dedup *deduplicator
+	if options.DedupStore != nil {
+		eventID := options.EventID
+		if eventID == nil {
+			eventID = DefaultEventID
+		}
+		dedup = newDeduplicator(options.DedupStore, eventID, options.Logger)
+	}
+
+	var verifier *hmacVerifier
+	if options.HMACSecret != "" {
+		verifier = newHMACVerifier(options.HMACSecret, options.HMACPreviousSecret, options.HMACTolerance)
+	}
+
+	processing, stopProcessing := context.WithCancel(options.RootContext)
+	return &Webhook{
+		URL:            url,
+		options:        options,
+		queue:          queue,
+		subscriber:     subscriber,
+		handlers:       newEventsMap(),
+		tracer:         options.TracerProvider.Tracer(instrumentationName),
+		dedup:          dedup,
+		hmac:           verifier,
+		processing:     processing,
+		stopProcessing: stopProcessing,
 	}
-	return wh
 }
 
-// Subscribe - sends a subscription request to the spv-wallet
+// startProcessors starts the loops processing the queued events, once;
+// they are started lazily so the events left in a persistent queue are processed once the handlers are registered
+func (w *Webhook) startProcessors() {
+	w.start.Do(func() {
+		if w.options.EventKey != nil {
+			w.processors.Add(1)
+			go w.processOrdered()
+			return
+		}
+		w.processors.Add(w.options.Processors)
+		for i := 0; i < w.options.Processors; i++ {
+			go w.process()
+		}
+	})
+}
+
+// Subscribe - sends a subscription request to the spv-wallet and starts processing the events;
+// the handlers should be registered before, as the events left in a persistent queue are processed right away
 func (w *Webhook) Subscribe(ctx context.Context) error {
-	return w.subscriber.AdminSubscribeWebhook(ctx, w.URL, w.options.TokenHeader, w.options.TokenValue)
+	w.startProcessors()
+	if err := w.subscriber.AdminSubscribeWebhook(ctx, w.URL, w.options.TokenHeader, w.options.TokenValue); err != nil {
+		w.options.Logger.ErrorContext(ctx, "cannot subscribe the webhook", slog.String("url", w.URL), slog.String("error", err.Error()))
+		return err
+	}
+	w.options.Logger.InfoContext(ctx, "webhook subscribed", slog.String("url", w.URL))
+	return nil
 }
 
 // Unsubscribe - sends an unsubscription request to the spv-wallet
 func (w *Webhook) Unsubscribe(ctx context.Context) error {
-	return w.subscriber.AdminUnsubscribeWebhook(ctx, w.URL)
+	if err := w.subscriber.AdminUnsubscribeWebhook(ctx, w.URL); err != nil {
+		w.options.Logger.ErrorContext(ctx, "cannot unsubscribe the webhook", slog.String("url", w.URL), slog.String("error", err.Error()))
+		return err
+	}
+	w.options.Logger.InfoContext(ctx, "webhook unsubscribed", slog.String("url", w.URL))
+	return nil
 }
 
-// HTTPHandler - returns an http handler for the webhook; it should be registered with the http server
+// RejectedEvents - the body of the response of the http handler when some events of the delivery could not be enqueued
+type RejectedEvents struct {
+	// Indexes are the positions of the rejected events in the delivered batch; the events before the first one are enqueued
+	Indexes []int `json:"indexes"`
+	// Error is the reason the events were rejected
+	Error string `json:"error"`
+}
+
+// HTTPHandler - returns an http handler for the webhook; it should be registered with the http server.
+// The events are acknowledged only once they are all enqueued. Otherwise the handler responds with 503 Service Unavailable,
+// a Retry-After header and the RejectedEvents, so the spv-wallet delivers them again.
 func (w *Webhook) HTTPHandler() http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		if w.options.TokenHeader != "" && r.Header.Get(w.options.TokenHeader) != w.options.TokenValue {
+		w.startProcessors()
+		if w.options.TokenHeader != "" && !tokenMatches(r.Header.Get(w.options.TokenHeader), w.options.TokenValue) {
+			w.options.Logger.WarnContext(r.Context(), "rejected webhook request with an invalid token", slog.String("remoteAddr", r.RemoteAddr))
 			http.Error(rw, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.options.Logger.WarnContext(r.Context(), "cannot read webhook request", slog.String("error", err.Error()))
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if w.hmac != nil {
+			if err = w.hmac.verify(r.Header, body); err != nil {
+				w.options.Logger.WarnContext(r.Context(), "rejected webhook request with an invalid signature",
+					slog.String("remoteAddr", r.RemoteAddr), slog.String("error", errorDetail(err)))
+				http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
 		var events []*models.RawEvent
-		if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+		if err = json.Unmarshal(body, &events); err != nil {
+			w.options.Logger.WarnContext(r.Context(), "rejected webhook request with an invalid body", slog.String("error", err.Error()))
 			http.Error(rw, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		for _, event := range events {
-			select {
-			case w.buffer <- event:
-				// event sent
-			case <-r.Context().Done():
-				// request context canceled
-				return
-			case <-w.options.RootContext.Done():
-				// root context canceled - the whole event processing has been stopped
+		if !w.beginDelivery() {
+			w.respondRejected(r.Context(), rw, len(events), 0, ErrWebhookStopped)
+			return
+		}
+		defer w.deliveries.Done()
+
+		for i, event := range events {
+			if err := w.enqueue(r.Context(), event); err != nil {
+				// the following events are rejected too, so they are not processed before the rejected one when delivered again
+				w.respondRejected(r.Context(), rw, len(events), i, err)
 				return
-			case <-time.After(1 * time.Second):
-				// timeout, most probably the channel is full
 			}
 		}
 		rw.WriteHeader(http.StatusOK)
 	})
 }
 
+// tokenMatches compares the token in constant time
+func tokenMatches(token, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// RotateHMACSecret - makes the secret the current one of the HMAC verification; the current one stays valid as the previous one,
+// until the next rotation. It returns ErrHMACNotEnabled if the webhook is not created WithHMACVerification.
+func (w *Webhook) RotateHMACSecret(secret string) error {
+	if w.hmac == nil {
+		return ErrHMACNotEnabled
+	}
+	w.hmac.rotate(secret)
+	return nil
+}
+
+// respondRejected writes the events rejected from the index first with 503 Service Unavailable and the Retry-After header
+func (w *Webhook) respondRejected(ctx context.Context, rw http.ResponseWriter, delivered, first int, cause error) {
+	rejected := &RejectedEvents{Error: errorDetail(cause)}
+	for i := first; i < delivered; i++ {
+		rejected.Indexes = append(rejected.Indexes, i)
+	}
+	w.options.Logger.WarnContext(ctx, "rejected webhook events",
+		slog.Int("rejected", delivered-first), slog.Int("delivered", delivered), slog.String("error", rejected.Error))
+
+	retryAfter := int(math.Ceil(w.options.RetryAfter.Seconds()))
+	rw.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusServiceUnavailable)
+	_ = json.NewEncoder(rw).Encode(rejected)
+}
+
+// enqueue adds the event to the queue, applying the full buffer policy
+func (w *Webhook) enqueue(ctx context.Context, event *models.RawEvent) error {
+	if w.options.RootContext.Err() != nil {
+		return ErrWebhookStopped
+	}
+
+	switch w.options.FullBufferPolicy {
+	case FullBufferReject:
+		return w.queue.TryEnqueue(event)
+	case FullBufferDropOldest:
+		return w.enqueueDroppingOldest(ctx, event)
+	default:
+		return w.enqueueBlocking(ctx, event)
+	}
+}
+
+// admit enqueues an event which is not delivered over http, e.g. a polled one, through the admission of the deliveries:
+// it is rejected once the webhook is shutting down, and the full buffer policy applies
+func (w *Webhook) admit(ctx context.Context, event *models.RawEvent) error {
+	if !w.beginDelivery() {
+		return ErrWebhookStopped
+	}
+	defer w.deliveries.Done()
+
+	return w.enqueue(ctx, event)
+}
+
+// enqueueBlocking waits up to the block timeout while the queue is full
+func (w *Webhook) enqueueBlocking(ctx context.Context, event *models.RawEvent) error {
+	ctx, cancel := context.WithTimeoutCause(ctx, w.options.BlockTimeout, ErrBufferFull)
+	defer cancel()
+	stop := context.AfterFunc(w.options.RootContext, cancel)
+	defer stop()
+
+	err := w.queue.Enqueue(ctx, event)
+	switch {
+	case err == nil:
+		return nil
+	case w.options.RootContext.Err() != nil:
+		return ErrWebhookStopped
+	case ctx.Err() != nil:
+		return context.Cause(ctx)
+	default:
+		return err
+	}
+}
+
+// enqueueDroppingOldest makes room for the event by dead-lettering the oldest queued events
+func (w *Webhook) enqueueDroppingOldest(ctx context.Context, event *models.RawEvent) error {
+	for {
+		err := w.queue.TryEnqueue(event)
+		if !errors.Is(err, ErrBufferFull) {
+			return err
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		oldest, err := w.queue.DropOldest()
+		if err != nil {
+			return err
+		}
+		if oldest != nil {
+			w.deadLetter(ctx, oldest.Event, ErrBufferFull, 0)
+		}
+	}
+}
+
 func (w *Webhook) process() {
+	defer w.processors.Done()
 	for {
-		select {
-		case event := <-w.buffer:
-			handler, ok := w.handlers.load(event.Type)
-			if !ok {
-				continue
-			}
-			model := reflect.New(handler.ModelType).Interface()
-			if err := json.Unmarshal(event.Content, model); err != nil {
-				continue
-			}
-			handler.Caller.Call([]reflect.Value{reflect.ValueOf(model)})
-		case <-w.options.RootContext.Done():
+		queued, ok := w.dequeue()
+		if !ok {
 			return
 		}
+		w.complete(queued)
 	}
 }
+
+// dequeue takes the next event from the queue; it returns false once the webhook is stopped
+func (w *Webhook) dequeue() (*QueuedEvent, bool) {
+	queued, err := w.queue.Dequeue(w.processing)
+	if err != nil {
+		if w.processing.Err() == nil {
+			w.options.Logger.ErrorContext(w.options.RootContext, "cannot dequeue webhook event", slog.String("error", err.Error()))
+		}
+		return nil, false
+	}
+	w.running.Add(1)
+	return queued, true
+}
+
+// complete handles the queued event and marks it as done
+func (w *Webhook) complete(queued *QueuedEvent) {
+	defer w.running.Add(-1)
+	w.handle(queued.Event)
+	if err := w.queue.Done(queued.ID); err != nil {
+		w.options.Logger.ErrorContext(w.options.RootContext, "cannot mark webhook event as done",
+			slog.String("type", queued.Event.Type), slog.String("error", err.Error()))
+	}
+}
+
+// handle processes the event, unless it is a duplicate, and dead-letters it if it fails
+func (w *Webhook) handle(event *models.RawEvent) {
+	ctx := w.options.RootContext
+	if w.dedup != nil {
+		id, ok := w.dedup.claim(ctx, event)
+		if !ok {
+			return
+		}
+		defer w.dedup.release(ctx, id)
+	}
+	_ = w.processEvent(ctx, event)
+}
+
+// processEvent calls the handlers registered for the type of the event within a span, each retried according to the options;
+// an event for which a handler still fails is sent to the dead-letter sink
+func (w *Webhook) processEvent(ctx context.Context, event *models.RawEvent) error {
+	ctx, span := w.tracer.Start(ctx, "ProcessWebhookEvent",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attributeEventType.String(event.Type)),
+	)
+	defer span.End()
+
+	handlers, ok := w.handlers.load(event.Type)
+	span.SetAttributes(attributeEventHandled.Bool(ok))
+	if !ok {
+		w.deadLetter(ctx, event, ErrNoHandler, 1)
+		return ErrNoHandler
+	}
+
+	var (
+		errs     []error
+		attempts int
+	)
+	for _, handler := range handlers {
+		attempt, err := w.callHandler(ctx, handler, event)
+		attempts = max(attempts, attempt)
+		if err != nil {
+			span.RecordError(err)
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	err := errs[0]
+	if len(errs) > 1 {
+		err = errors.Join(errs...)
+	}
+	w.deadLetter(ctx, event, err, attempts)
+	span.SetStatus(codes.Error, err.Error())
+	return err
+}
+
+// callHandler calls the handler until it succeeds, up to the configured attempts; it returns the number of attempts made
+func (w *Webhook) callHandler(ctx context.Context, handler *eventHandler, event *models.RawEvent) (int, error) {
+	attempts := max(w.options.EventAttempts, 1)
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = handler.call(ctx, event); err == nil {
+			return attempt, nil
+		}
+		if errors.Is(err, ErrEventDecode) || attempt == attempts {
+			return attempt, err
+		}
+
+		w.options.Logger.InfoContext(ctx, "retrying webhook event",
+			slog.String("type", event.Type), slog.Int("attempt", attempt), slog.String("error", err.Error()))
+		select {
+		case <-time.After(w.options.EventRetryBackoff):
+		case <-ctx.Done():
+			return attempt, err
+		}
+	}
+	return attempts, err
+}
+
+// deadLetter sends the failed event to the dead-letter sink
+func (w *Webhook) deadLetter(ctx context.Context, event *models.RawEvent, cause error, attempts int) {
+	if w.options.DeadLetterSink == nil {
+		w.dropped(ctx, event, errorDetail(cause), slog.Int("attempts", attempts))
+		return
+	}
+
+	letter := &DeadLetter{Event: event, Error: errorDetail(cause), Attempts: attempts, FailedAt: time.Now().UTC()}
+	if err := w.options.DeadLetterSink.Put(context.WithoutCancel(ctx), letter); err != nil {
+		w.dropped(ctx, event, errorDetail(cause), slog.Int("attempts", attempts), slog.String("deadLetterError", err.Error()))
+		return
+	}
+	w.options.Logger.WarnContext(ctx, "dead-lettered webhook event",
+		slog.String("type", event.Type), slog.String("error", cause.Error()), slog.Int("attempts", attempts))
+}
+
+// Replay - processes the events of the dead letters again, e.g. once the cause of their failure is fixed;
+// all the handlers of an event are called again, the ones which succeeded before included.
+// The events failing again are sent back to the dead-letter sink and their errors are returned
+func (w *Webhook) Replay(ctx context.Context, letters ...*DeadLetter) error {
+	var errs []error
+	for _, letter := range letters {
+		if err := w.processEvent(ctx, letter.Event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dropped logs an event which is not handled
+func (w *Webhook) dropped(ctx context.Context, event *models.RawEvent, reason string, attrs ...any) {
+	attrs = append([]any{slog.String("type", event.Type), slog.String("reason", reason)}, attrs...)
+	w.options.Logger.WarnContext(ctx, "dropped webhook event", attrs...)
+}
@@ -0,0 +1,213 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+type subscriberMock struct{}
+
+func (subscriberMock) AdminSubscribeWebhook(context.Context, string, string, string) error {
+	return nil
+}
+
+func (subscriberMock) AdminUnsubscribeWebhook(context.Context, string) error {
+	return nil
+}
+
+func newTestWebhook(t *testing.T, opts ...WebhookOpts) *Webhook {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return NewWebhook(subscriberMock{}, "http://localhost/webhook", append([]WebhookOpts{WithRootContext(ctx), WithProcessors(1)}, opts...)...)
+}
+
+func rawEvent(t *testing.T, event any) *models.RawEvent {
+	content, err := json.Marshal(event)
+	require.NoError(t, err)
+	name := "StringEvent"
+	if _, ok := event.(*models.TransactionEvent); ok {
+		name = "TransactionEvent"
+	}
+	return &models.RawEvent{Type: name, Content: content}
+}
+
+func deliver(t *testing.T, wh *Webhook, events ...*models.RawEvent) *httptest.ResponseRecorder {
+	body, err := json.Marshal(events)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	wh.HTTPHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body)))
+	return recorder
+}
+
+func TestWebhook_DeadLetters(t *testing.T) {
+	t.Run("retries a failing handler and dead-letters the event", func(t *testing.T) {
+		sink := NewMemoryDeadLetterSink()
+		wh := newTestWebhook(t, WithEventRetry(3, time.Millisecond), WithDeadLetterSink(sink))
+
+		var calls atomic.Int32
+		require.NoError(t, RegisterHandlerWithError(wh, func(_ context.Context, event *models.StringEvent) error {
+			calls.Add(1)
+			return errors.New("downstream unavailable")
+		}))
+
+		deliver(t, wh, rawEvent(t, &models.StringEvent{Value: "hello"}))
+
+		require.Eventually(t, func() bool { return len(sink.Letters()) == 1 }, time.Second, time.Millisecond)
+		letter := sink.Letters()[0]
+		require.Equal(t, int32(3), calls.Load())
+		require.Equal(t, 3, letter.Attempts)
+		require.Equal(t, "downstream unavailable", letter.Error)
+		require.Equal(t, "StringEvent", letter.Event.Type)
+	})
+
+	t.Run("dead-letters unknown and undecodable events without retrying them", func(t *testing.T) {
+		sink := NewMemoryDeadLetterSink()
+		wh := newTestWebhook(t, WithEventRetry(3, time.Millisecond), WithDeadLetterSink(sink))
+		require.NoError(t, RegisterHandler(wh, func(event *models.StringEvent) {}))
+
+		deliver(t, wh,
+			&models.RawEvent{Type: "FutureEvent", Content: json.RawMessage(`{}`)},
+			&models.RawEvent{Type: "StringEvent", Content: json.RawMessage(`{"value":1}`)},
+		)
+
+		require.Eventually(t, func() bool { return len(sink.Letters()) == 2 }, time.Second, time.Millisecond)
+		letters := sink.Letters()
+		require.Equal(t, ErrNoHandler.Error(), letters[0].Error)
+		require.Equal(t, 1, letters[1].Attempts)
+	})
+
+	t.Run("recovers from a panicking handler", func(t *testing.T) {
+		sink := NewMemoryDeadLetterSink()
+		wh := newTestWebhook(t, WithDeadLetterSink(sink))
+		require.NoError(t, RegisterHandler(wh, func(event *models.StringEvent) {
+			panic("boom")
+		}))
+
+		deliver(t, wh, rawEvent(t, &models.StringEvent{Value: "hello"}))
+
+		require.Eventually(t, func() bool { return len(sink.Letters()) == 1 }, time.Second, time.Millisecond)
+		require.Contains(t, sink.Letters()[0].Error, "boom")
+	})
+
+	t.Run("replays the dead letters", func(t *testing.T) {
+		sink := NewMemoryDeadLetterSink()
+		wh := newTestWebhook(t, WithDeadLetterSink(sink))
+
+		var failing atomic.Bool
+		failing.Store(true)
+		var handled atomic.Int32
+		require.NoError(t, RegisterHandlerWithError(wh, func(_ context.Context, event *models.StringEvent) error {
+			if failing.Load() {
+				return errors.New("not yet")
+			}
+			handled.Add(1)
+			return nil
+		}))
+
+		deliver(t, wh, rawEvent(t, &models.StringEvent{Value: "hello"}))
+		require.Eventually(t, func() bool { return len(sink.Letters()) == 1 }, time.Second, time.Millisecond)
+
+		failing.Store(false)
+		require.NoError(t, wh.Replay(context.Background(), sink.Drain()...))
+		require.Equal(t, int32(1), handled.Load())
+		require.Empty(t, sink.Letters())
+	})
+}
+
+func TestFileDeadLetterSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letters.jsonl")
+	sink, err := NewFileDeadLetterSink(path)
+	require.NoError(t, err)
+	defer sink.Close()
+
+	event := rawEvent(t, &models.StringEvent{Value: "hello"})
+	require.NoError(t, sink.Put(context.Background(), &DeadLetter{Event: event, Error: "failed", Attempts: 2}))
+	require.NoError(t, sink.Put(context.Background(), &DeadLetter{Event: event, Error: "failed again", Attempts: 1}))
+
+	letters, err := sink.Letters()
+	require.NoError(t, err)
+	require.Len(t, letters, 2)
+	require.Equal(t, "failed", letters[0].Error)
+	require.JSONEq(t, string(event.Content), string(letters[0].Event.Content))
+
+	drained, err := sink.Drain()
+	require.NoError(t, err)
+	require.Len(t, drained, 2)
+
+	letters, err = sink.Letters()
+	require.NoError(t, err)
+	require.Empty(t, letters)
+}
+
+func TestWebhook_Backpressure(t *testing.T) {
+	events := func(t *testing.T) []*models.RawEvent {
+		return []*models.RawEvent{
+			rawEvent(t, &models.StringEvent{Value: "first"}),
+			rawEvent(t, &models.StringEvent{Value: "second"}),
+			rawEvent(t, &models.StringEvent{Value: "third"}),
+		}
+	}
+
+	t.Run("rejects the events which do not fit in the buffer", func(t *testing.T) {
+		wh := newTestWebhook(t, WithQueue(NewMemoryQueue(1)), WithProcessors(0),
+			WithFullBufferPolicy(FullBufferReject, 0), WithRetryAfter(1500*time.Millisecond))
+
+		recorder := deliver(t, wh, events(t)...)
+
+		require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		require.Equal(t, "2", recorder.Header().Get("Retry-After"))
+		var rejected RejectedEvents
+		require.NoError(t, json.NewDecoder(recorder.Body).Decode(&rejected))
+		require.Equal(t, []int{1, 2}, rejected.Indexes)
+		require.Equal(t, ErrBufferFull.Error(), rejected.Error)
+	})
+
+	t.Run("rejects the events once the block timeout expires", func(t *testing.T) {
+		wh := newTestWebhook(t, WithQueue(NewMemoryQueue(2)), WithProcessors(0),
+			WithFullBufferPolicy(FullBufferBlock, 10*time.Millisecond))
+
+		recorder := deliver(t, wh, events(t)...)
+
+		require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		var rejected RejectedEvents
+		require.NoError(t, json.NewDecoder(recorder.Body).Decode(&rejected))
+		require.Equal(t, []int{2}, rejected.Indexes)
+	})
+
+	t.Run("dead-letters the oldest events to make room", func(t *testing.T) {
+		sink := NewMemoryDeadLetterSink()
+		queue := NewMemoryQueue(1)
+		wh := newTestWebhook(t, WithQueue(queue), WithProcessors(0),
+			WithFullBufferPolicy(FullBufferDropOldest, 0), WithDeadLetterSink(sink))
+
+		recorder := deliver(t, wh, events(t)...)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Len(t, sink.Letters(), 2)
+		require.JSONEq(t, `{"value":"first"}`, string(sink.Letters()[0].Event.Content))
+		queued, err := queue.Dequeue(context.Background())
+		require.NoError(t, err)
+		require.JSONEq(t, `{"value":"third"}`, string(queued.Event.Content))
+	})
+
+	t.Run("rejects the events once the webhook is stopped", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		wh := newTestWebhook(t, WithRootContext(ctx))
+		cancel()
+
+		recorder := deliver(t, wh, events(t)...)
+
+		require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	})
+}
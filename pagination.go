@@ -0,0 +1,309 @@
+package walletclient
+
+import (
+	"context"
+	"iter"
+
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/bitcoin-sv/spv-wallet/models/filter"
+)
+
+// DefaultPageSize is the page size used by the iterators when none is provided
+const DefaultPageSize = 50
+
+// PageFetcher fetches a single page of items for the given query params.
+type PageFetcher[T any] func(ctx context.Context, queryParams *filter.QueryParams) ([]T, error)
+
+// PaginationOptions configures how the iterators walk through the pages of a search.
+type PaginationOptions struct {
+	// PageSize is the number of items requested per page; a page shorter than this ends the iteration.
+	PageSize int
+	// StartPage is the first page to fetch (1-based).
+	StartPage int
+	// OrderByField is the field by which the results are ordered.
+	OrderByField string
+	// SortDirection is the direction of the ordering (asc/desc).
+	SortDirection string
+	// Prefetch fetches the next page in the background while the current one is being consumed.
+	Prefetch bool
+}
+
+// DefaultPaginationOptions returns the default pagination options
+func DefaultPaginationOptions() *PaginationOptions {
+	return &PaginationOptions{
+		PageSize:  DefaultPageSize,
+		StartPage: 1,
+	}
+}
+
+func (o *PaginationOptions) queryParams(page int) *filter.QueryParams {
+	return &filter.QueryParams{
+		Page:          page,
+		PageSize:      o.PageSize,
+		OrderByField:  o.OrderByField,
+		SortDirection: o.SortDirection,
+	}
+}
+
+func normalizePaginationOptions(opts *PaginationOptions) *PaginationOptions {
+	normalized := DefaultPaginationOptions()
+	if opts == nil {
+		return normalized
+	}
+
+	*normalized = *opts
+	if normalized.PageSize <= 0 {
+		normalized.PageSize = DefaultPageSize
+	}
+	if normalized.StartPage <= 0 {
+		normalized.StartPage = 1
+	}
+	return normalized
+}
+
+type pageResult[T any] struct {
+	items []T
+	err   error
+}
+
+// Paginate returns an iterator over the items of all pages returned by the fetcher.
+// Pages are fetched lazily until a page shorter than the page size is returned, the context is done
+// or the consumer stops the iteration. An error is yielded once, after which the iteration ends.
+func Paginate[T any](ctx context.Context, fetch PageFetcher[T], opts *PaginationOptions) iter.Seq2[T, error] {
+	opts = normalizePaginationOptions(opts)
+
+	return func(yield func(T, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		fetchPage := func(page int) <-chan pageResult[T] {
+			result := make(chan pageResult[T], 1)
+			fetchNow := func() {
+				items, err := fetch(ctx, opts.queryParams(page))
+				result <- pageResult[T]{items: items, err: err}
+			}
+			if opts.Prefetch {
+				go fetchNow()
+			} else {
+				fetchNow()
+			}
+			return result
+		}
+
+		var zero T
+		var next <-chan pageResult[T]
+		for page := opts.StartPage; ; page++ {
+			if err := ctx.Err(); err != nil {
+				yield(zero, WrapError(err))
+				return
+			}
+
+			if next == nil {
+				next = fetchPage(page)
+			}
+			result := <-next
+			next = nil
+			if result.err != nil {
+				yield(zero, result.err)
+				return
+			}
+
+			lastPage := len(result.items) < opts.PageSize
+			if !lastPage && opts.Prefetch {
+				next = fetchPage(page + 1)
+			}
+
+			for _, item := range result.items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if lastPage {
+				return
+			}
+		}
+	}
+}
+
+// ForEach calls fn for every item of the iterator; it stops on the first error returned by the iterator or fn.
+// It is a callback alternative for the range-over-func loop.
+func ForEach[T any](seq iter.Seq2[T, error], fn func(item T) error) error {
+	var iterErr error
+	seq(func(item T, err error) bool {
+		if err != nil {
+			iterErr = err
+			return false
+		}
+		if err = fn(item); err != nil {
+			iterErr = err
+			return false
+		}
+		return true
+	})
+	return iterErr
+}
+
+// GetAccessKeysIter returns an iterator over all access keys matching the conditions, walking through every page of GetAccessKeys
+func (wc *WalletClient) GetAccessKeysIter(
+	ctx context.Context,
+	conditions *filter.AccessKeyFilter,
+	metadata map[string]any,
+	opts *PaginationOptions,
+) iter.Seq2[*models.AccessKey, error] {
+	return Paginate(ctx, func(ctx context.Context, queryParams *filter.QueryParams) ([]*models.AccessKey, error) {
+		return wc.GetAccessKeys(ctx, conditions, metadata, queryParams)
+	}, opts)
+}
+
+// GetDestinationsIter returns an iterator over all destinations matching the conditions, walking through every page of GetDestinations
+func (wc *WalletClient) GetDestinationsIter(
+	ctx context.Context,
+	conditions *filter.DestinationFilter,
+	metadata map[string]any,
+	opts *PaginationOptions,
+) iter.Seq2[*models.Destination, error] {
+	return Paginate(ctx, func(ctx context.Context, queryParams *filter.QueryParams) ([]*models.Destination, error) {
+		return wc.GetDestinations(ctx, conditions, metadata, queryParams)
+	}, opts)
+}
+
+// GetTransactionsIter returns an iterator over all transactions matching the conditions, walking through every page of GetTransactions
+func (wc *WalletClient) GetTransactionsIter(
+	ctx context.Context,
+	conditions *filter.TransactionFilter,
+	metadata map[string]any,
+	opts *PaginationOptions,
+) iter.Seq2[*models.Transaction, error] {
+	return Paginate(ctx, func(ctx context.Context, queryParams *filter.QueryParams) ([]*models.Transaction, error) {
+		return wc.GetTransactions(ctx, conditions, metadata, queryParams)
+	}, opts)
+}
+
+// GetUtxosIter returns an iterator over all utxos matching the conditions, walking through every page of GetUtxos
+func (wc *WalletClient) GetUtxosIter(
+	ctx context.Context,
+	conditions *filter.UtxoFilter,
+	metadata map[string]any,
+	opts *PaginationOptions,
+) iter.Seq2[*models.Utxo, error] {
+	return Paginate(ctx, func(ctx context.Context, queryParams *filter.QueryParams) ([]*models.Utxo, error) {
+		return wc.GetUtxos(ctx, conditions, metadata, queryParams)
+	}, opts)
+}
+
+// GetContactsIter returns an iterator over all contacts matching the conditions, walking through every page of GetContacts
+func (wc *WalletClient) GetContactsIter(
+	ctx context.Context,
+	conditions *filter.ContactFilter,
+	metadata map[string]any,
+	opts *PaginationOptions,
+) iter.Seq2[*models.Contact, error] {
+	return Paginate(ctx, func(ctx context.Context, queryParams *filter.QueryParams) ([]*models.Contact, error) {
+		resp, err := wc.GetContacts(ctx, conditions, metadata, queryParams)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Content, nil
+	}, opts)
+}
+
+// AdminGetAccessKeysIter returns an iterator over all access keys matching the conditions (admin), walking through every page of AdminGetAccessKeys
+func (wc *WalletClient) AdminGetAccessKeysIter(
+	ctx context.Context,
+	conditions *filter.AdminAccessKeyFilter,
+	metadata map[string]any,
+	opts *PaginationOptions,
+) iter.Seq2[*models.AccessKey, error] {
+	return Paginate(ctx, func(ctx context.Context, queryParams *filter.QueryParams) ([]*models.AccessKey, error) {
+		return wc.AdminGetAccessKeys(ctx, conditions, metadata, queryParams)
+	}, opts)
+}
+
+// AdminGetBlockHeadersIter returns an iterator over all block headers matching the conditions (admin), walking through every page of AdminGetBlockHeaders
+func (wc *WalletClient) AdminGetBlockHeadersIter(
+	ctx context.Context,
+	conditions map[string]interface{},
+	metadata map[string]any,
+	opts *PaginationOptions,
+) iter.Seq2[*models.BlockHeader, error] {
+	return Paginate(ctx, func(ctx context.Context, queryParams *filter.QueryParams) ([]*models.BlockHeader, error) {
+		return wc.AdminGetBlockHeaders(ctx, conditions, metadata, queryParams)
+	}, opts)
+}
+
+// AdminGetDestinationsIter returns an iterator over all destinations matching the conditions (admin), walking through every page of AdminGetDestinations
+func (wc *WalletClient) AdminGetDestinationsIter(
+	ctx context.Context,
+	conditions *filter.DestinationFilter,
+	metadata map[string]any,
+	opts *PaginationOptions,
+) iter.Seq2[*models.Destination, error] {
+	return Paginate(ctx, func(ctx context.Context, queryParams *filter.QueryParams) ([]*models.Destination, error) {
+		return wc.AdminGetDestinations(ctx, conditions, metadata, queryParams)
+	}, opts)
+}
+
+// AdminGetPaymailsIter returns an iterator over all paymails matching the conditions (admin), walking through every page of AdminGetPaymails
+func (wc *WalletClient) AdminGetPaymailsIter(
+	ctx context.Context,
+	conditions *filter.AdminPaymailFilter,
+	metadata map[string]any,
+	opts *PaginationOptions,
+) iter.Seq2[*models.PaymailAddress, error] {
+	return Paginate(ctx, func(ctx context.Context, queryParams *filter.QueryParams) ([]*models.PaymailAddress, error) {
+		return wc.AdminGetPaymails(ctx, conditions, metadata, queryParams)
+	}, opts)
+}
+
+// AdminGetTransactionsIter returns an iterator over all transactions matching the conditions (admin), walking through every page of AdminGetTransactions
+func (wc *WalletClient) AdminGetTransactionsIter(
+	ctx context.Context,
+	conditions *filter.TransactionFilter,
+	metadata map[string]any,
+	opts *PaginationOptions,
+) iter.Seq2[*models.Transaction, error] {
+	return Paginate(ctx, func(ctx context.Context, queryParams *filter.QueryParams) ([]*models.Transaction, error) {
+		return wc.AdminGetTransactions(ctx, conditions, metadata, queryParams)
+	}, opts)
+}
+
+// AdminGetUtxosIter returns an iterator over all utxos matching the conditions (admin), walking through every page of AdminGetUtxos
+func (wc *WalletClient) AdminGetUtxosIter(
+	ctx context.Context,
+	conditions *filter.AdminUtxoFilter,
+	metadata map[string]any,
+	opts *PaginationOptions,
+) iter.Seq2[*models.Utxo, error] {
+	return Paginate(ctx, func(ctx context.Context, queryParams *filter.QueryParams) ([]*models.Utxo, error) {
+		return wc.AdminGetUtxos(ctx, conditions, metadata, queryParams)
+	}, opts)
+}
+
+// AdminGetXPubsIter returns an iterator over all xpubs matching the conditions (admin), walking through every page of AdminGetXPubs
+func (wc *WalletClient) AdminGetXPubsIter(
+	ctx context.Context,
+	conditions *filter.XpubFilter,
+	metadata map[string]any,
+	opts *PaginationOptions,
+) iter.Seq2[*models.Xpub, error] {
+	return Paginate(ctx, func(ctx context.Context, queryParams *filter.QueryParams) ([]*models.Xpub, error) {
+		return wc.AdminGetXPubs(ctx, conditions, metadata, queryParams)
+	}, opts)
+}
+
+// AdminGetContactsIter returns an iterator over all contacts matching the conditions (admin), walking through every page of AdminGetContacts
+func (wc *WalletClient) AdminGetContactsIter(
+	ctx context.Context,
+	conditions *filter.ContactFilter,
+	metadata map[string]any,
+	opts *PaginationOptions,
+) iter.Seq2[*models.Contact, error] {
+	return Paginate(ctx, func(ctx context.Context, queryParams *filter.QueryParams) ([]*models.Contact, error) {
+		resp, err := wc.AdminGetContacts(ctx, conditions, metadata, queryParams)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Content, nil
+	}, opts)
+}
@@ -0,0 +1,149 @@
+package walletclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bitcoin-sv/spv-wallet-go-client/fixtures"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/bitcoin-sv/spv-wallet/models/filter"
+	"github.com/stretchr/testify/require"
+)
+
+// numbersFetcher serves the numbers 0..total-1 page by page and records the requested pages
+func numbersFetcher(total int, pages *[]int) PageFetcher[int] {
+	return func(ctx context.Context, queryParams *filter.QueryParams) ([]int, error) {
+		*pages = append(*pages, queryParams.Page)
+		start := (queryParams.Page - 1) * queryParams.PageSize
+		items := make([]int, 0, queryParams.PageSize)
+		for i := start; i < total && i < start+queryParams.PageSize; i++ {
+			items = append(items, i)
+		}
+		return items, nil
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name          string
+		total         int
+		prefetch      bool
+		expectedPages []int
+	}{
+		{"Should stop on a short page", 5, false, []int{1, 2, 3}},
+		{"Should stop on an empty page", 6, false, []int{1, 2, 3, 4}},
+		{"Should prefetch the next page", 5, true, []int{1, 2, 3}},
+		{"Should handle no results", 0, true, []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			var pages []int
+			opts := &PaginationOptions{PageSize: 2, Prefetch: tt.prefetch}
+
+			// when
+			var result []int
+			for item, err := range Paginate(context.Background(), numbersFetcher(tt.total, &pages), opts) {
+				require.NoError(t, err)
+				result = append(result, item)
+			}
+
+			// then
+			require.Len(t, result, tt.total)
+			for i, item := range result {
+				require.Equal(t, i, item)
+			}
+			require.Equal(t, tt.expectedPages, pages)
+		})
+	}
+
+	t.Run("Should stop fetching when the consumer breaks", func(t *testing.T) {
+		// given
+		var pages []int
+
+		// when
+		for item, err := range Paginate(context.Background(), numbersFetcher(100, &pages), &PaginationOptions{PageSize: 10}) {
+			require.NoError(t, err)
+			if item == 15 {
+				break
+			}
+		}
+
+		// then
+		require.Equal(t, []int{1, 2}, pages)
+	})
+
+	t.Run("Should yield the fetcher error", func(t *testing.T) {
+		// given
+		fetchErr := errors.New("fetch failed")
+		fetch := func(ctx context.Context, queryParams *filter.QueryParams) ([]int, error) {
+			return nil, fetchErr
+		}
+
+		// when
+		err := ForEach(Paginate(context.Background(), fetch, nil), func(item int) error {
+			return nil
+		})
+
+		// then
+		require.ErrorIs(t, err, fetchErr)
+	})
+
+	t.Run("Should stop on a cancelled context", func(t *testing.T) {
+		// given
+		var pages []int
+		ctx, cancel := context.WithCancel(context.Background())
+
+		// when
+		count := 0
+		err := ForEach(Paginate(ctx, numbersFetcher(100, &pages), &PaginationOptions{PageSize: 10}), func(item int) error {
+			count++
+			if count == 10 {
+				cancel()
+			}
+			return nil
+		})
+
+		// then
+		require.Error(t, err)
+		require.Equal(t, 10, count)
+		require.Equal(t, []int{1}, pages)
+	})
+}
+
+func TestPaginationIterators(t *testing.T) {
+	utxos := make([]*models.Utxo, 5)
+	for i := range utxos {
+		utxos[i] = &models.Utxo{UtxoPointer: models.UtxoPointer{TransactionID: fixtures.Transaction.ID, OutputIndex: uint32(i)}}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body filter.SearchModel[filter.UtxoFilter]
+		if r.URL.Path != "/v1/utxo/search" || json.NewDecoder(r.Body).Decode(&body) != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		start := min((body.QueryParams.Page-1)*body.QueryParams.PageSize, len(utxos))
+		end := min(start+body.QueryParams.PageSize, len(utxos))
+		_ = json.NewEncoder(w).Encode(utxos[start:end])
+	}))
+	defer server.Close()
+
+	client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+	require.NoError(t, err)
+
+	t.Run("GetUtxosIter", func(t *testing.T) {
+		var result []*models.Utxo
+		err := ForEach(client.GetUtxosIter(context.Background(), nil, nil, &PaginationOptions{PageSize: 2, Prefetch: true}), func(utxo *models.Utxo) error {
+			result = append(result, utxo)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, utxos, result)
+	})
+}
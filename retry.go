@@ -0,0 +1,142 @@
+package walletclient
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy describes how requests sent to the spv-wallet are retried on transient failures.
+// Every attempt is built and signed from scratch, so the auth time and nonce headers stay fresh.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one; values lower than 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the second attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the computed exponential delay; a Retry-After header sent by the server is honored as is.
+	MaxBackoff time.Duration
+	// Multiplier is the factor by which the delay grows after every attempt.
+	Multiplier float64
+	// Jitter is the fraction (0..1) of the delay which is randomized to avoid synchronized retries.
+	Jitter float64
+	// RetryableStatusCodes lists the response status codes which are considered transient.
+	RetryableStatusCodes []int
+	// RetryNonIdempotent allows retrying all methods, not only the idempotent ones and calls marked with WithRetry.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns a retry policy with sensible defaults: 3 attempts, exponential backoff starting at 200ms
+// and retries on 429, 502, 503 and 504 responses.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+type retryableCtxKey struct{}
+
+// WithRetry marks the calls made with the returned context as safe to retry,
+// even if they use a non-idempotent HTTP method (e.g. search requests sent with POST).
+func WithRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryableCtxKey{}, true)
+}
+
+func isMarkedRetryable(ctx context.Context) bool {
+	marked, _ := ctx.Value(retryableCtxKey{}).(bool)
+	return marked
+}
+
+// allows reports whether a call with the given method may be retried at all
+func (p *RetryPolicy) allows(ctx context.Context, method string) bool {
+	if p == nil || p.MaxAttempts < 2 {
+		return false
+	}
+	return p.RetryNonIdempotent || isIdempotent(method) || isMarkedRetryable(ctx)
+}
+
+// shouldRetry reports whether the outcome of an attempt is a transient failure
+func (p *RetryPolicy) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return slices.Contains(p.RetryableStatusCodes, resp.StatusCode)
+}
+
+// backoff returns the delay to wait after the given (1-based) failed attempt
+func (p *RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if delay, ok := retryAfter(resp); ok {
+		return delay
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		delay -= delay * jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+// retryAfter parses the Retry-After header which may hold either delay seconds or an HTTP date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// sleepContext waits for the given duration or until the context is done
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,179 @@
+package walletclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitcoin-sv/spv-wallet-go-client/fixtures"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer fails the first `failures` requests with the given status and then responds with the fixtures xpub
+func flakyServer(failures int, status int, header http.Header) (*httptest.Server, *[]*http.Request) {
+	var mu sync.Mutex
+	var requests []*http.Request
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r)
+		count := len(requests)
+		mu.Unlock()
+
+		if count <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(models.ResponseError{Code: "error-unavailable", Message: "unavailable"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(fixtures.Xpub)
+	}))
+
+	return server, &requests
+}
+
+func testRetryPolicy() *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Run("Should retry transient failures and re-sign every attempt", func(t *testing.T) {
+		// given
+		server, requests := flakyServer(2, http.StatusServiceUnavailable, nil)
+		defer server.Close()
+
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		client.SetRetryPolicy(testRetryPolicy())
+
+		// when
+		xpub, err := client.GetXPub(context.Background())
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, fixtures.Xpub, xpub)
+		require.Len(t, *requests, 3)
+		nonces := map[string]bool{}
+		for _, r := range *requests {
+			nonces[r.Header.Get(models.AuthHeaderNonce)] = true
+		}
+		require.Len(t, nonces, 3)
+	})
+
+	t.Run("Should return the last error when attempts are exhausted", func(t *testing.T) {
+		// given
+		server, requests := flakyServer(5, http.StatusBadGateway, nil)
+		defer server.Close()
+
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		client.SetRetryPolicy(testRetryPolicy())
+
+		// when
+		_, err = client.GetXPub(context.Background())
+
+		// then
+		var spvErr models.SPVError
+		require.ErrorAs(t, err, &spvErr)
+		require.Equal(t, http.StatusBadGateway, spvErr.StatusCode)
+		require.Len(t, *requests, 3)
+	})
+
+	t.Run("Should not retry non-idempotent calls", func(t *testing.T) {
+		// given
+		server, requests := flakyServer(1, http.StatusServiceUnavailable, nil)
+		defer server.Close()
+
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		client.SetRetryPolicy(testRetryPolicy())
+
+		// when
+		_, err = client.UpdateXPubMetadata(context.Background(), fixtures.TestMetadata)
+
+		// then
+		require.Error(t, err)
+		require.Len(t, *requests, 1)
+	})
+
+	t.Run("Should retry non-idempotent calls marked as retryable", func(t *testing.T) {
+		// given
+		server, requests := flakyServer(1, http.StatusServiceUnavailable, nil)
+		defer server.Close()
+
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		client.SetRetryPolicy(testRetryPolicy())
+
+		// when
+		_, err = client.UpdateXPubMetadata(WithRetry(context.Background()), fixtures.TestMetadata)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, *requests, 2)
+	})
+
+	t.Run("Should not retry without a policy", func(t *testing.T) {
+		// given
+		server, requests := flakyServer(1, http.StatusServiceUnavailable, nil)
+		defer server.Close()
+
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+
+		// when
+		_, err = client.GetXPub(context.Background())
+
+		// then
+		require.Error(t, err)
+		require.Len(t, *requests, 1)
+	})
+
+	t.Run("Should honor Retry-After header", func(t *testing.T) {
+		// given
+		server, requests := flakyServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"1"}})
+		defer server.Close()
+
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		client.SetRetryPolicy(testRetryPolicy())
+
+		// when
+		start := time.Now()
+		_, err = client.GetXPub(context.Background())
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, *requests, 2)
+		require.GreaterOrEqual(t, time.Since(start), time.Second)
+	})
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     300 * time.Millisecond,
+		Multiplier:     2,
+	}
+
+	require.Equal(t, 100*time.Millisecond, policy.backoff(1, nil))
+	require.Equal(t, 200*time.Millisecond, policy.backoff(2, nil))
+	require.Equal(t, 300*time.Millisecond, policy.backoff(3, nil))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		delay := policy.backoff(2, nil)
+		require.GreaterOrEqual(t, delay, 100*time.Millisecond)
+		require.LessOrEqual(t, delay, 200*time.Millisecond)
+	}
+}
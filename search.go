@@ -34,7 +34,8 @@ func Search[TFilter any, TResp any](
 		return resp, WrapError(err)
 	}
 
-	if err := requester(ctx, method, path, jsonStr, xPriv, true, &resp); err != nil {
+	// search requests are read-only, so they are safe to retry even though they are sent with POST
+	if err := requester(WithRetry(ctx), method, path, jsonStr, xPriv, true, &resp); err != nil {
 		return resp, err
 	}
 
@@ -59,7 +60,7 @@ func Count[TFilter any](
 		return 0, WrapError(err)
 	}
 	var count int64
-	if err := requester(ctx, method, path, jsonStr, xPriv, true, &count); err != nil {
+	if err := requester(WithRetry(ctx), method, path, jsonStr, xPriv, true, &count); err != nil {
 		return 0, err
 	}
 
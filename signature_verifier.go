@@ -0,0 +1,207 @@
+package walletclient
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	bip32 "github.com/bitcoin-sv/go-sdk/compat/bip32"
+	bsm "github.com/bitcoin-sv/go-sdk/compat/bsm"
+	script "github.com/bitcoin-sv/go-sdk/script"
+
+	"github.com/bitcoin-sv/spv-wallet-go-client/utils"
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// NonceCache remembers the auth nonces of the verified requests, so that a captured request cannot be replayed
+type NonceCache interface {
+	// Store records the nonce until expiresAt; it returns false if the nonce is already recorded.
+	Store(nonce string, expiresAt time.Time) bool
+}
+
+// MemoryNonceCache is a NonceCache keeping the nonces in memory; the expired nonces are pruned while storing new ones
+type MemoryNonceCache struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	nextPrune time.Time
+}
+
+// NewMemoryNonceCache creates an empty MemoryNonceCache; now returns the current time and defaults to time.Now
+func NewMemoryNonceCache(now func() time.Time) *MemoryNonceCache {
+	if now == nil {
+		now = time.Now
+	}
+	return &MemoryNonceCache{now: now, nonces: make(map[string]time.Time)}
+}
+
+// Store records the nonce until expiresAt; it returns false if the nonce is already recorded and has not expired
+func (c *MemoryNonceCache) Store(nonce string, expiresAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if now.After(c.nextPrune) {
+		for stored, expiry := range c.nonces {
+			if now.After(expiry) {
+				delete(c.nonces, stored)
+			}
+		}
+		c.nextPrune = now.Add(models.AuthSignatureTTL)
+	}
+
+	if expiry, ok := c.nonces[nonce]; ok && !now.After(expiry) {
+		return false
+	}
+	c.nonces[nonce] = expiresAt
+	return true
+}
+
+// SignatureVerifier verifies the auth headers set by the client, the same way the spv-wallet server does.
+// It is the counterpart of the signing done by the WalletClient, meant for proxies and tests.
+type SignatureVerifier struct {
+	window time.Duration
+	now    func() time.Time
+	nonces NonceCache
+}
+
+// VerifierOption is a functional option of the SignatureVerifier
+type VerifierOption func(*SignatureVerifier)
+
+// WithAuthTimeWindow sets how far the auth time of a request can be from the current time, models.AuthSignatureTTL by default
+func WithAuthTimeWindow(window time.Duration) VerifierOption {
+	return func(v *SignatureVerifier) {
+		v.window = window
+	}
+}
+
+// WithVerifierClock sets the function returning the current time, time.Now by default
+func WithVerifierClock(now func() time.Time) VerifierOption {
+	return func(v *SignatureVerifier) {
+		v.now = now
+	}
+}
+
+// WithNonceCache sets the cache used to reject the replayed nonces; nil disables the replay protection
+func WithNonceCache(cache NonceCache) VerifierOption {
+	return func(v *SignatureVerifier) {
+		v.nonces = cache
+	}
+}
+
+// NewSignatureVerifier creates a SignatureVerifier; unless WithNonceCache is passed, the nonces are remembered in a MemoryNonceCache
+func NewSignatureVerifier(opts ...VerifierOption) *SignatureVerifier {
+	v := &SignatureVerifier{window: models.AuthSignatureTTL, now: time.Now}
+	v.nonces = NewMemoryNonceCache(func() time.Time { return v.now() })
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// VerifyRequest verifies the auth headers of the request against its body and returns the verified auth payload,
+// with either the XPub or the AccessKey set
+func (v *SignatureVerifier) VerifyRequest(req *http.Request, body []byte) (*models.AuthPayload, error) {
+	payload, err := authPayloadFromHeader(req.Header)
+	if err != nil {
+		return nil, err
+	}
+	if err = v.Verify(payload, body); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// Verify checks the auth hash against the body, the auth time against the window, the BSM signature of the signing message
+// and, when a nonce cache is set, that the nonce has not been used before
+func (v *SignatureVerifier) Verify(payload *models.AuthPayload, body []byte) error {
+	if payload.Signature == "" {
+		return ErrMissingSignature
+	}
+
+	if payload.AuthHash != utils.Hash(string(body)) {
+		return ErrAuthHashMismatch
+	}
+
+	authTime := time.UnixMilli(payload.AuthTime)
+	if age := v.now().Sub(authTime); age > v.window || age < -v.window {
+		return ErrSignatureExpired
+	}
+
+	if payload.AuthNonce == "" {
+		return ErrInvalidSignature.Wrap(fmt.Errorf("missing auth nonce"))
+	}
+
+	key, address, err := signingKeyAddress(payload)
+	if err != nil {
+		return err
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(payload.Signature)
+	if err != nil {
+		return ErrInvalidSignature.Wrap(err)
+	}
+	if err = bsm.VerifyMessage(address, signature, getSigningMessage(key, payload)); err != nil {
+		return ErrInvalidSignature.Wrap(err)
+	}
+
+	// the nonce is remembered only once the signature is verified, so forged requests cannot burn the nonces
+	if v.nonces != nil && !v.nonces.Store(key+payload.AuthNonce, authTime.Add(v.window)) {
+		return ErrNonceReplayed
+	}
+	return nil
+}
+
+// authPayloadFromHeader reads the auth payload from the headers set by setSignature or SetSignatureFromAccessKey
+func authPayloadFromHeader(header http.Header) (*models.AuthPayload, error) {
+	payload := &models.AuthPayload{
+		XPub:      header.Get(models.AuthHeader),
+		AccessKey: header.Get(models.AuthAccessKey),
+		AuthHash:  header.Get(models.AuthHeaderHash),
+		AuthNonce: header.Get(models.AuthHeaderNonce),
+		Signature: header.Get(models.AuthSignature),
+	}
+	if payload.XPub == "" && payload.AccessKey == "" {
+		return nil, ErrMissingAuthHeader
+	}
+	if payload.Signature == "" {
+		return nil, ErrMissingSignature
+	}
+
+	authTime, err := strconv.ParseInt(header.Get(models.AuthHeaderTime), 10, 64)
+	if err != nil {
+		return nil, ErrSignatureExpired.Wrap(err)
+	}
+	payload.AuthTime = authTime
+	return payload, nil
+}
+
+// signingKeyAddress returns the key of the signing message and the address of the key which signed it:
+// the child of the xpub derived from the nonce, or the access key itself
+func signingKeyAddress(payload *models.AuthPayload) (string, string, error) {
+	if payload.XPub != "" {
+		xPub, err := bip32.GetHDKeyFromExtendedPublicKey(payload.XPub)
+		if err != nil {
+			return "", "", ErrInvalidXpub.Wrap(err)
+		}
+		key, err := utils.DeriveChildKeyFromHex(xPub, payload.AuthNonce)
+		if err != nil {
+			return "", "", ErrInvalidSignature.Wrap(err)
+		}
+		address, err := bip32.GetAddressStringFromHDKey(key)
+		if err != nil {
+			return "", "", ErrInvalidSignature.Wrap(err)
+		}
+		return payload.XPub, address, nil
+	}
+
+	address, err := script.NewAddressFromPublicKeyString(payload.AccessKey, true)
+	if err != nil {
+		return "", "", ErrInvalidAccessKey.Wrap(err)
+	}
+	return payload.AccessKey, address.AddressString, nil
+}
@@ -0,0 +1,132 @@
+package walletclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	bip32 "github.com/bitcoin-sv/go-sdk/compat/bip32"
+	"github.com/bitcoin-sv/spv-wallet-go-client/fixtures"
+	"github.com/bitcoin-sv/spv-wallet-go-client/xpriv"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+const signedBody = `{"metadata":{"note":"signed"}}`
+
+func signedXPubRequest(t *testing.T) *http.Request {
+	xPriv, err := bip32.GenerateHDKeyFromString(fixtures.XPrivString)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, fixtures.ServerURL, nil)
+	require.NoError(t, err)
+	require.NoError(t, setSignature(&req.Header, xPriv, signedBody))
+	return req
+}
+
+func signedAccessKeyRequest(t *testing.T) *http.Request {
+	req, err := http.NewRequest(http.MethodPost, fixtures.ServerURL, nil)
+	require.NoError(t, err)
+	require.NoError(t, SetSignatureFromAccessKey(&req.Header, fixtures.AccessKeyString, signedBody))
+	return req
+}
+
+func TestSignatureVerifier_VerifyRequest(t *testing.T) {
+	t.Run("xpub signature", func(t *testing.T) {
+		payload, err := NewSignatureVerifier().VerifyRequest(signedXPubRequest(t), []byte(signedBody))
+		require.NoError(t, err)
+		require.Equal(t, fixtures.XPubString, payload.XPub)
+		require.Empty(t, payload.AccessKey)
+	})
+
+	t.Run("access key signature", func(t *testing.T) {
+		payload, err := NewSignatureVerifier().VerifyRequest(signedAccessKeyRequest(t), []byte(signedBody))
+		require.NoError(t, err)
+		require.NotEmpty(t, payload.AccessKey)
+		require.Empty(t, payload.XPub)
+	})
+
+	t.Run("missing auth header", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, fixtures.ServerURL, nil)
+		require.NoError(t, err)
+
+		_, err = NewSignatureVerifier().VerifyRequest(req, nil)
+		require.ErrorIs(t, err, ErrMissingAuthHeader)
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		req := signedXPubRequest(t)
+		req.Header.Del(models.AuthSignature)
+
+		_, err := NewSignatureVerifier().VerifyRequest(req, []byte(signedBody))
+		require.ErrorIs(t, err, ErrMissingSignature)
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		_, err := NewSignatureVerifier().VerifyRequest(signedXPubRequest(t), []byte(`{"metadata":{"note":"tampered"}}`))
+		require.ErrorIs(t, err, ErrAuthHashMismatch)
+	})
+
+	t.Run("signed by another xpub", func(t *testing.T) {
+		keys, err := xpriv.Generate()
+		require.NoError(t, err)
+
+		req := signedXPubRequest(t)
+		req.Header.Set(models.AuthHeader, keys.XPub().String())
+
+		_, err = NewSignatureVerifier().VerifyRequest(req, []byte(signedBody))
+		require.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("auth time outside of the window", func(t *testing.T) {
+		verifier := NewSignatureVerifier(WithVerifierClock(func() time.Time {
+			return time.Now().Add(models.AuthSignatureTTL + time.Second)
+		}))
+
+		_, err := verifier.VerifyRequest(signedXPubRequest(t), []byte(signedBody))
+		require.ErrorIs(t, err, ErrSignatureExpired)
+	})
+
+	t.Run("custom auth time window", func(t *testing.T) {
+		verifier := NewSignatureVerifier(
+			WithAuthTimeWindow(time.Minute),
+			WithVerifierClock(func() time.Time {
+				return time.Now().Add(models.AuthSignatureTTL + time.Second)
+			}),
+		)
+
+		_, err := verifier.VerifyRequest(signedXPubRequest(t), []byte(signedBody))
+		require.NoError(t, err)
+	})
+
+	t.Run("replayed nonce", func(t *testing.T) {
+		verifier := NewSignatureVerifier()
+		req := signedAccessKeyRequest(t)
+
+		_, err := verifier.VerifyRequest(req, []byte(signedBody))
+		require.NoError(t, err)
+		_, err = verifier.VerifyRequest(req, []byte(signedBody))
+		require.ErrorIs(t, err, ErrNonceReplayed)
+	})
+
+	t.Run("replay protection disabled", func(t *testing.T) {
+		verifier := NewSignatureVerifier(WithNonceCache(nil))
+		req := signedAccessKeyRequest(t)
+
+		_, err := verifier.VerifyRequest(req, []byte(signedBody))
+		require.NoError(t, err)
+		_, err = verifier.VerifyRequest(req, []byte(signedBody))
+		require.NoError(t, err)
+	})
+}
+
+func TestMemoryNonceCache_Store(t *testing.T) {
+	now := time.Now()
+	cache := NewMemoryNonceCache(func() time.Time { return now })
+
+	require.True(t, cache.Store("nonce", now.Add(time.Second)))
+	require.False(t, cache.Store("nonce", now.Add(time.Second)))
+
+	now = now.Add(2 * time.Second)
+	require.True(t, cache.Store("nonce", now.Add(time.Second)))
+}
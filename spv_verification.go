@@ -0,0 +1,190 @@
+package walletclient
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	"github.com/bitcoin-sv/go-sdk/script/interpreter"
+	trx "github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/spv-wallet/models"
+)
+
+// beefVersionHex is the hex of the BRC-62 version which starts every BEEF transaction
+const beefVersionHex = "0100beef"
+
+// SPVVerifier verifies locally that transactions are mined, by checking their merkle paths
+// against the merkle roots synced with SyncMerkleRoots, so the spv-wallet does not have to be trusted.
+// It also implements the ChainTracker of the go-sdk.
+type SPVVerifier struct {
+	repo ReorgAwareMerkleRootsRepository
+}
+
+// NewSPVVerifier creates a verifier checking the merkle paths against the roots of the repository
+func NewSPVVerifier(repo ReorgAwareMerkleRootsRepository) *SPVVerifier {
+	return &SPVVerifier{repo: repo}
+}
+
+// IsValidRootForHeight checks if the merkle root is the synced one at the block height
+func (v *SPVVerifier) IsValidRootForHeight(root *chainhash.Hash, height uint32) bool {
+	return root != nil && v.repo.GetMerkleRootAt(int(height)) == root.String()
+}
+
+// VerifyTransaction verifies a transaction returned by GetTransaction. Its hex is either a BEEF transaction,
+// or a raw transaction whose merkle path (BUMP hex) must be passed as merklePathHex.
+func (v *SPVVerifier) VerifyTransaction(transaction *models.Transaction, merklePathHex string) error {
+	var tx *trx.Transaction
+	var err error
+	if strings.HasPrefix(strings.ToLower(transaction.Hex), beefVersionHex) {
+		tx, err = v.VerifyBEEF(transaction.Hex)
+	} else {
+		tx, err = v.VerifyMerklePath(transaction.Hex, merklePathHex)
+	}
+	if err != nil {
+		return err
+	}
+
+	if transaction.ID != "" && transaction.ID != tx.TxID().String() {
+		return ErrInvalidTransactionHex.Wrap(fmt.Errorf("transaction id %s does not match the hex", transaction.ID))
+	}
+	if tx.MerklePath != nil && transaction.BlockHeight != 0 && transaction.BlockHeight != uint64(tx.MerklePath.BlockHeight) {
+		return ErrInvalidMerklePath.Wrap(fmt.Errorf("merkle path is at height %d, the transaction at height %d", tx.MerklePath.BlockHeight, transaction.BlockHeight))
+	}
+	return nil
+}
+
+// VerifyBEEF verifies a BEEF (BRC-62) transaction: its merkle path, or when it is not mined yet,
+// the merkle paths of its ancestors up to the mined ones. The unmined transactions must also unlock
+// the outputs they spend and must not spend more than their inputs hold. It returns the decoded transaction.
+func (v *SPVVerifier) VerifyBEEF(beefHex string) (*trx.Transaction, error) {
+	tx, err := decodeBEEF(beefHex)
+	if err != nil {
+		return nil, err
+	}
+	if err = v.verifyAncestry(tx, make(map[string]bool)); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// VerifyMerklePath verifies that the raw transaction is mined, with its merkle path (BUMP hex). It returns the decoded transaction.
+func (v *SPVVerifier) VerifyMerklePath(txHex, merklePathHex string) (*trx.Transaction, error) {
+	tx, err := trx.NewTransactionFromHex(txHex)
+	if err != nil {
+		return nil, ErrInvalidTransactionHex.Wrap(err)
+	}
+	if merklePathHex == "" {
+		return nil, ErrMissingMerklePath
+	}
+	if tx.MerklePath, err = trx.NewMerklePathFromHex(merklePathHex); err != nil {
+		return nil, ErrInvalidMerklePath.Wrap(err)
+	}
+	if err = v.verifyMerklePath(tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// verifyAncestry verifies the merkle path of the transaction, or the scripts, amounts and ancestry of its inputs if it is not mined
+func (v *SPVVerifier) verifyAncestry(tx *trx.Transaction, verified map[string]bool) error {
+	txID := tx.TxID().String()
+	if verified[txID] {
+		return nil
+	}
+	if tx.MerklePath != nil {
+		if err := v.verifyMerklePath(tx); err != nil {
+			return err
+		}
+		verified[txID] = true
+		return nil
+	}
+
+	if len(tx.Inputs) == 0 {
+		return ErrMissingMerklePath.Wrap(fmt.Errorf("transaction %s", txID))
+	}
+	for _, input := range tx.Inputs {
+		if input.SourceTransaction == nil {
+			return ErrMissingMerklePath.Wrap(fmt.Errorf("transaction %s spends %s which is not in the BEEF", txID, input.SourceTXID))
+		}
+		if err := v.verifyAncestry(input.SourceTransaction, verified); err != nil {
+			return err
+		}
+	}
+	if err := verifyInputs(tx); err != nil {
+		return err
+	}
+	verified[txID] = true
+	return nil
+}
+
+// verifyInputs runs the unlocking script of every input against the locking script of the output it spends,
+// and checks that the inputs cover the outputs
+func verifyInputs(tx *trx.Transaction) error {
+	var inputs uint64
+	for i, input := range tx.Inputs {
+		if int(input.SourceTxOutIndex) >= len(input.SourceTransaction.Outputs) {
+			return ErrInvalidBEEF.Wrap(fmt.Errorf("transaction %s spends output %d of %s which does not exist", tx.TxID(), input.SourceTxOutIndex, input.SourceTXID))
+		}
+		output := input.SourceTransaction.Outputs[input.SourceTxOutIndex]
+		if err := executeInput(tx, i, output); err != nil {
+			return ErrInvalidUnlockingScript.Wrap(fmt.Errorf("input %d of transaction %s: %w", i, tx.TxID(), err))
+		}
+		inputs += output.Satoshis
+	}
+
+	if outputs := tx.TotalOutputSatoshis(); outputs > inputs {
+		return ErrOutputsExceedInputs.Wrap(fmt.Errorf("transaction %s spends %d satoshis from inputs of %d", tx.TxID(), outputs, inputs))
+	}
+	return nil
+}
+
+// executeInput runs the script interpreter on the input; the go-sdk panics on some malformed scripts, which is reported as an error
+func executeInput(tx *trx.Transaction, inputIndex int, output *trx.TransactionOutput) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%v", recovered)
+		}
+	}()
+	return interpreter.NewEngine().Execute(
+		interpreter.WithTx(tx, inputIndex, output),
+		interpreter.WithForkID(),
+		interpreter.WithAfterGenesis(),
+	)
+}
+
+// verifyMerklePath computes the merkle root of the transaction and compares it with the synced one
+func (v *SPVVerifier) verifyMerklePath(tx *trx.Transaction) error {
+	root, err := tx.MerklePath.ComputeRoot(tx.TxID())
+	if err != nil {
+		return ErrInvalidMerklePath.Wrap(err)
+	}
+
+	height := tx.MerklePath.BlockHeight
+	synced := v.repo.GetMerkleRootAt(int(height))
+	if synced == "" {
+		return ErrMerkleRootNotFound.Wrap(fmt.Errorf("block height %d", height))
+	}
+	if synced != root.String() {
+		return ErrMerkleRootMismatch.Wrap(fmt.Errorf("transaction %s at block height %d has the merkle root %s, the synced one is %s", tx.TxID(), height, root, synced))
+	}
+	return nil
+}
+
+// decodeBEEF decodes the BEEF transaction; the go-sdk panics on some malformed ones, which is reported as an error
+func decodeBEEF(beefHex string) (tx *trx.Transaction, err error) {
+	beef, err := hex.DecodeString(beefHex)
+	if err != nil {
+		return nil, ErrInvalidBEEF.Wrap(err)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			tx, err = nil, ErrInvalidBEEF.Wrap(fmt.Errorf("%v", recovered))
+		}
+	}()
+	if tx, err = trx.NewTransactionFromBEEF(beef); err != nil {
+		return nil, ErrInvalidBEEF.Wrap(err)
+	}
+	return tx, nil
+}
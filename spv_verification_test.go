@@ -0,0 +1,211 @@
+package walletclient
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitcoin-sv/go-sdk/chainhash"
+	ec "github.com/bitcoin-sv/go-sdk/primitives/ec"
+	script "github.com/bitcoin-sv/go-sdk/script"
+	trx "github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/chaintracker"
+	"github.com/bitcoin-sv/go-sdk/transaction/template/p2pkh"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+const verifiedBlockHeight = 840000
+
+// spenderKey locks the first output of the mined transactions
+func spenderKey(t *testing.T) *ec.PrivateKey {
+	key, err := ec.PrivateKeyFromHex(strings.Repeat("01", 32))
+	require.NoError(t, err)
+	return key
+}
+
+// minedTransaction creates a transaction with a merkle path at verifiedBlockHeight, in a block of two transactions.
+// Its first output of 1000 satoshis is locked to the spenderKey.
+func minedTransaction(t *testing.T, data string) (*trx.Transaction, string) {
+	address, err := script.NewAddressFromPublicKey(spenderKey(t).PubKey(), true)
+	require.NoError(t, err)
+	lockingScript, err := p2pkh.Lock(address)
+	require.NoError(t, err)
+
+	tx := trx.NewTransaction()
+	require.NoError(t, tx.AddInputFrom(strings.Repeat("ab", 32), 0, "76a914"+strings.Repeat("00", 20)+"88ac", 2000, nil))
+	tx.Inputs[0].UnlockingScript = &script.Script{}
+	tx.AddOutput(&trx.TransactionOutput{Satoshis: 1000, LockingScript: lockingScript})
+	require.NoError(t, tx.AddOpReturnOutput([]byte(data)))
+
+	isTxID := true
+	sibling, err := chainhash.NewHashFromHex(strings.Repeat("cd", 32))
+	require.NoError(t, err)
+	tx.MerklePath = trx.NewMerklePath(verifiedBlockHeight, [][]*trx.PathElement{{
+		{Offset: 0, Hash: tx.TxID(), Txid: &isTxID},
+		{Offset: 1, Hash: sibling},
+	}})
+
+	root, err := tx.MerklePath.ComputeRoot(tx.TxID())
+	require.NoError(t, err)
+	return tx, root.String()
+}
+
+// spendingTransaction creates an unmined transaction spending the first output of the parent with the key, to an output of the satoshis
+func spendingTransaction(t *testing.T, parent *trx.Transaction, key *ec.PrivateKey, satoshis uint64) *trx.Transaction {
+	unlocker, err := p2pkh.Unlock(key, nil)
+	require.NoError(t, err)
+
+	tx := trx.NewTransaction()
+	tx.AddInputFromTx(parent, 0, unlocker)
+	tx.AddOutput(&trx.TransactionOutput{Satoshis: satoshis, LockingScript: script.NewFromBytes([]byte{script.OpTRUE})})
+	require.NoError(t, tx.Sign())
+	return tx
+}
+
+func syncedRepository(root string) *MemoryMerkleRootsRepository {
+	return NewMemoryMerkleRootsRepository(
+		models.MerkleRoot{MerkleRoot: strings.Repeat("11", 32), BlockHeight: verifiedBlockHeight - 1},
+		models.MerkleRoot{MerkleRoot: root, BlockHeight: verifiedBlockHeight},
+	)
+}
+
+func TestSPVVerifier(t *testing.T) {
+	var _ chaintracker.ChainTracker = (*SPVVerifier)(nil)
+
+	t.Run("Should verify a raw transaction with its merkle path", func(t *testing.T) {
+		tx, root := minedTransaction(t, "raw")
+		verifier := NewSPVVerifier(syncedRepository(root))
+
+		err := verifier.VerifyTransaction(&models.Transaction{ID: tx.TxID().String(), Hex: tx.Hex(), BlockHeight: verifiedBlockHeight}, tx.MerklePath.Hex())
+
+		require.NoError(t, err)
+	})
+
+	t.Run("Should verify a mined BEEF transaction", func(t *testing.T) {
+		tx, root := minedTransaction(t, "beef")
+		beef, err := tx.BEEFHex()
+		require.NoError(t, err)
+		verifier := NewSPVVerifier(syncedRepository(root))
+
+		verified, err := verifier.VerifyBEEF(beef)
+
+		require.NoError(t, err)
+		require.Equal(t, tx.TxID().String(), verified.TxID().String())
+	})
+
+	t.Run("Should verify an unmined BEEF transaction through its mined ancestors", func(t *testing.T) {
+		parent, root := minedTransaction(t, "parent")
+		child := spendingTransaction(t, parent, spenderKey(t), 900)
+		beef, err := child.BEEFHex()
+		require.NoError(t, err)
+		verifier := NewSPVVerifier(syncedRepository(root))
+
+		err = verifier.VerifyTransaction(&models.Transaction{ID: child.TxID().String(), Hex: beef}, "")
+
+		require.NoError(t, err)
+	})
+
+	t.Run("Should reject an unmined BEEF transaction with an empty unlocking script", func(t *testing.T) {
+		parent, root := minedTransaction(t, "parent")
+		child := spendingTransaction(t, parent, spenderKey(t), 900)
+		child.Inputs[0].UnlockingScript = &script.Script{}
+		beef, err := child.BEEFHex()
+		require.NoError(t, err)
+		verifier := NewSPVVerifier(syncedRepository(root))
+
+		_, err = verifier.VerifyBEEF(beef)
+
+		require.ErrorIs(t, err, ErrInvalidUnlockingScript)
+	})
+
+	t.Run("Should reject an unmined BEEF transaction signed with another key", func(t *testing.T) {
+		parent, root := minedTransaction(t, "parent")
+		otherKey, err := ec.PrivateKeyFromHex(strings.Repeat("02", 32))
+		require.NoError(t, err)
+		child := spendingTransaction(t, parent, otherKey, 900)
+		beef, err := child.BEEFHex()
+		require.NoError(t, err)
+		verifier := NewSPVVerifier(syncedRepository(root))
+
+		_, err = verifier.VerifyBEEF(beef)
+
+		require.ErrorIs(t, err, ErrInvalidUnlockingScript)
+	})
+
+	t.Run("Should reject an unmined BEEF transaction spending more than its inputs", func(t *testing.T) {
+		parent, root := minedTransaction(t, "parent")
+		child := spendingTransaction(t, parent, spenderKey(t), 1001)
+		beef, err := child.BEEFHex()
+		require.NoError(t, err)
+		verifier := NewSPVVerifier(syncedRepository(root))
+
+		_, err = verifier.VerifyBEEF(beef)
+
+		require.ErrorIs(t, err, ErrOutputsExceedInputs)
+	})
+
+	t.Run("Should reject a merkle path whose root differs from the synced one", func(t *testing.T) {
+		tx, _ := minedTransaction(t, "forked")
+		verifier := NewSPVVerifier(syncedRepository(strings.Repeat("22", 32)))
+
+		_, err := verifier.VerifyMerklePath(tx.Hex(), tx.MerklePath.Hex())
+
+		require.ErrorIs(t, err, ErrMerkleRootMismatch)
+	})
+
+	t.Run("Should reject a merkle path at a height which is not synced", func(t *testing.T) {
+		tx, root := minedTransaction(t, "unsynced")
+		tx.MerklePath.BlockHeight = verifiedBlockHeight + 1
+		verifier := NewSPVVerifier(syncedRepository(root))
+
+		_, err := verifier.VerifyMerklePath(tx.Hex(), tx.MerklePath.Hex())
+
+		require.ErrorIs(t, err, ErrMerkleRootNotFound)
+	})
+
+	t.Run("Should reject a merkle path of another transaction", func(t *testing.T) {
+		tx, root := minedTransaction(t, "proved")
+		other, _ := minedTransaction(t, "other")
+		verifier := NewSPVVerifier(syncedRepository(root))
+
+		_, err := verifier.VerifyMerklePath(other.Hex(), tx.MerklePath.Hex())
+
+		require.ErrorIs(t, err, ErrInvalidMerklePath)
+	})
+
+	t.Run("Should reject a raw transaction without a merkle path", func(t *testing.T) {
+		tx, root := minedTransaction(t, "no path")
+		verifier := NewSPVVerifier(syncedRepository(root))
+
+		err := verifier.VerifyTransaction(&models.Transaction{Hex: tx.Hex()}, "")
+
+		require.ErrorIs(t, err, ErrMissingMerklePath)
+	})
+
+	t.Run("Should reject a transaction whose id does not match the hex", func(t *testing.T) {
+		tx, root := minedTransaction(t, "id")
+		verifier := NewSPVVerifier(syncedRepository(root))
+
+		err := verifier.VerifyTransaction(&models.Transaction{ID: strings.Repeat("ef", 32), Hex: tx.Hex()}, tx.MerklePath.Hex())
+
+		require.ErrorIs(t, err, ErrInvalidTransactionHex)
+	})
+
+	t.Run("Should reject a malformed BEEF", func(t *testing.T) {
+		verifier := NewSPVVerifier(NewMemoryMerkleRootsRepository())
+
+		_, err := verifier.VerifyBEEF(beefVersionHex + "00")
+
+		require.ErrorIs(t, err, ErrInvalidBEEF)
+	})
+
+	t.Run("Should act as a chain tracker", func(t *testing.T) {
+		tx, root := minedTransaction(t, "tracker")
+		verifier := NewSPVVerifier(syncedRepository(root))
+
+		valid, err := tx.MerklePath.Verify(tx.TxID(), verifier)
+
+		require.NoError(t, err)
+		require.True(t, valid)
+	})
+}
@@ -2,9 +2,11 @@ package walletclient
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"log/slog"
 	"net/http"
-	"strings"
+	"net/url"
+	"strconv"
 
 	"github.com/bitcoin-sv/spv-wallet/models"
 )
@@ -18,55 +20,296 @@ type MerkleRootsRepository interface {
 	SaveMerkleRoots(syncedMerkleRoots []models.MerkleRoot) error
 }
 
+// MerkleRootsCheckpoint persists the last merkle root saved by a sync, from which an interrupted sync is resumed
+type MerkleRootsCheckpoint interface {
+	// LoadCheckpoint should return the last saved merkle root, or nil if none is saved.
+	LoadCheckpoint() (*models.MerkleRoot, error)
+	// SaveCheckpoint should store the merkle root, replacing the previous one.
+	SaveCheckpoint(root models.MerkleRoot) error
+}
+
+// SyncMerkleRootsOptions configures SyncMerkleRootsWithOptions
+type SyncMerkleRootsOptions struct {
+	// PageSize is the number of merkle roots requested from the spv-wallet at once; the spv-wallet default when zero.
+	PageSize int
+	// BatchSize is the number of merkle roots passed to SaveMerkleRoots at once: the pages are accumulated until it is reached.
+	// Every page is saved on its own when zero.
+	BatchSize int
+	// OnProgress is called after every saved batch.
+	OnProgress func(progress SyncMerkleRootsProgress)
+	// Checkpoint, when set, is saved after every saved batch, and the sync resumes from it instead of the last root of the repo;
+	// the roots saved after the checkpoint, if any, are then passed to SaveMerkleRoots again.
+	Checkpoint MerkleRootsCheckpoint
+	// CountBlockHeaders estimates the remaining roots with AdminGetBlockHeadersCount, which requires an admin key;
+	// otherwise the total reported by the spv-wallet with every page is used.
+	CountBlockHeaders bool
+}
+
+// SyncMerkleRootsProgress reports how far a sync got
+type SyncMerkleRootsProgress struct {
+	// Pages is the number of pages fetched from the spv-wallet.
+	Pages int
+	// Saved is the number of merkle roots passed to SaveMerkleRoots.
+	Saved int
+	// LastMerkleRoot is the last saved merkle root; its BlockHeight is -1 while unknown.
+	LastMerkleRoot models.MerkleRoot
+	// Total is the number of merkle roots known to the spv-wallet, or of its block headers with CountBlockHeaders.
+	Total int
+	// Remaining is the estimated number of merkle roots left to sync.
+	Remaining int
+}
+
 // SyncMerkleRoots syncs merkleroots known to spv-wallet with the client database
 // If timeout is needed pass context.WithTimeout() as ctx param
+// If the repo is a ReorgAwareMerkleRootsRepository, chain reorganisations are detected and the roots above the fork point are synced again
 func (wc *WalletClient) SyncMerkleRoots(ctx context.Context, repo MerkleRootsRepository) error {
+	_, err := wc.SyncMerkleRootsWithOptions(ctx, repo, nil)
+	return err
+}
+
+// SyncMerkleRootsWithOptions syncs merkleroots like SyncMerkleRoots, in batches, reporting the progress;
+// the progress is returned also when the sync fails, the synced roots fetched before the failure are saved
+func (wc *WalletClient) SyncMerkleRootsWithOptions(ctx context.Context, repo MerkleRootsRepository, opts *SyncMerkleRootsOptions) (*SyncMerkleRootsProgress, error) {
+	ctx = withOperation(ctx, "SyncMerkleRootsWithOptions")
+	if opts == nil {
+		opts = &SyncMerkleRootsOptions{}
+	}
+	syncer := &merkleRootsSync{wc: wc, repo: repo, options: opts}
+	syncer.reorgRepo, syncer.detectReorgs = repo.(ReorgAwareMerkleRootsRepository)
+
 	lastEvaluatedKey := repo.GetLastMerkleRoot()
-	requestPath := "merkleroots"
-	lastEvaluatedKeyQuery := ""
+	lastEvaluatedHeight := -1
+	if syncer.detectReorgs {
+		lastEvaluatedHeight = syncer.reorgRepo.GetLastMerkleRootHeight()
+	}
+	if opts.Checkpoint != nil {
+		checkpoint, err := opts.Checkpoint.LoadCheckpoint()
+		if err != nil {
+			return &syncer.progress, WrapError(err)
+		}
+		if checkpoint != nil {
+			lastEvaluatedKey, lastEvaluatedHeight = checkpoint.MerkleRoot, checkpoint.BlockHeight
+		}
+	}
+	syncer.progress.LastMerkleRoot = models.MerkleRoot{MerkleRoot: lastEvaluatedKey, BlockHeight: lastEvaluatedHeight}
+	if opts.CountBlockHeaders {
+		count, err := wc.AdminGetBlockHeadersCount(ctx, nil, nil)
+		if err != nil {
+			wc.logger.WarnContext(ctx, "cannot count block headers", slog.String("error", err.Error()))
+		}
+		syncer.blockHeadersCount = int(count)
+	}
 	previousLastEvaluatedKey := lastEvaluatedKey
 
-	if lastEvaluatedKey != "" {
-		lastEvaluatedKeyQuery = fmt.Sprintf("?lastEvaluatedKey=%s", lastEvaluatedKey)
-	}
+	// while the fork point is searched, the stored roots above lastEvaluatedHeight are not deleted yet
+	forkSearchStep := 0
+	reorgTip := -1
 
 	for {
 		select {
 		case <-ctx.Done():
-			return ErrSyncMerkleRootsTimeout
+			return syncer.abort(ctx, syncContextError(ctx.Err()))
 		default:
-			url := fmt.Sprintf("/%s%s", requestPath, lastEvaluatedKeyQuery)
-
-			var merkleRootsResponse models.ExclusiveStartKeyPage[[]models.MerkleRoot]
-
-			err := wc.doHTTPRequest(ctx, http.MethodGet, url, nil, wc.xPriv, true, &merkleRootsResponse)
+			merkleRootsResponse, err := syncer.fetch(ctx, previousLastEvaluatedKey)
 
 			if err != nil {
 				// In case if the context deadline exceeds its limit during http request, httpClient
-				// cancels the request wrapping it as spverror, so we need to check if the message
-				// is the same as context deadline exceeded error
-				if strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
-					return ErrSyncMerkleRootsTimeout
+				// cancels the request, which is kept as the cause of the returned error
+				if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+					return syncer.abort(ctx, syncContextError(err))
+				}
+				if !syncer.detectReorgs || previousLastEvaluatedKey == "" || !isUnknownMerkleRootError(err) {
+					return syncer.abort(ctx, WrapError(err))
+				}
+			}
+
+			// the spv-wallet does not know our last root, or its next root is not at the next height: the chain was reorganised
+			if syncer.detectReorgs && previousLastEvaluatedKey != "" && (err != nil || !linksToHeight(merkleRootsResponse.Content, lastEvaluatedHeight)) {
+				if forkSearchStep == 0 {
+					reorgTip = syncer.reorgRepo.GetLastMerkleRootHeight()
+				}
+				// the roots not saved yet may be on the abandoned branch, they are fetched again
+				syncer.pending = nil
+				forkSearchStep = max(2*forkSearchStep, 1)
+				previousLastEvaluatedKey, lastEvaluatedHeight = forkPointCandidate(syncer.reorgRepo, lastEvaluatedHeight-forkSearchStep)
+				continue
+			}
+
+			if forkSearchStep > 0 {
+				// the search may step back below the fork point; the roots which are still in the chain are kept
+				content := merkleRootsResponse.Content
+				for len(content) > 0 && syncer.reorgRepo.GetMerkleRootAt(content[0].BlockHeight) == content[0].MerkleRoot {
+					lastEvaluatedHeight = content[0].BlockHeight
+					content = content[1:]
+				}
+				merkleRootsResponse.Content = content
+
+				if len(content) > 0 || merkleRootsResponse.Page.LastEvaluatedKey == "" {
+					wc.logger.WarnContext(ctx, "chain reorganisation detected",
+						slog.Int("forkHeight", lastEvaluatedHeight),
+						slog.Int("previousTip", reorgTip),
+					)
+					if err = syncer.rollBack(ctx, lastEvaluatedHeight); err != nil {
+						wc.logger.ErrorContext(ctx, "cannot roll back merkle roots", slog.String("error", err.Error()))
+						return &syncer.progress, err
+					}
+					forkSearchStep = 0
 				}
-				return WrapError(err)
 			}
 
 			lastEvaluatedKey = merkleRootsResponse.Page.LastEvaluatedKey
 			if lastEvaluatedKey != "" && previousLastEvaluatedKey == lastEvaluatedKey {
-				return ErrStaleLastEvaluatedKey
+				return syncer.abort(ctx, WrapError(ErrStaleLastEvaluatedKey))
 			}
 
-			err = repo.SaveMerkleRoots(merkleRootsResponse.Content)
-			if err != nil {
-				return err
+			syncer.pending = append(syncer.pending, merkleRootsResponse.Content...)
+			if lastEvaluatedKey == "" || len(syncer.pending) >= opts.BatchSize {
+				if err = syncer.flush(ctx); err != nil {
+					return &syncer.progress, err
+				}
 			}
+			wc.logger.DebugContext(ctx, "synced merkle roots page",
+				slog.Int("count", len(merkleRootsResponse.Content)),
+				slog.Int("total", merkleRootsResponse.Page.TotalElements),
+				slog.String("lastEvaluatedKey", lastEvaluatedKey),
+			)
 
 			previousLastEvaluatedKey = lastEvaluatedKey
 			if previousLastEvaluatedKey == "" {
-				return nil
+				syncer.progress.Remaining = 0
+				wc.logger.InfoContext(ctx, "merkle roots synced", slog.String("lastMerkleRoot", repo.GetLastMerkleRoot()))
+				return &syncer.progress, nil
 			}
+			if content := merkleRootsResponse.Content; len(content) > 0 {
+				lastEvaluatedHeight = content[len(content)-1].BlockHeight
+			}
+		}
+	}
+}
+
+// merkleRootsSync holds the state of a sync of the merkle roots
+type merkleRootsSync struct {
+	wc           *WalletClient
+	repo         MerkleRootsRepository
+	reorgRepo    ReorgAwareMerkleRootsRepository
+	detectReorgs bool
+	options      *SyncMerkleRootsOptions
+
+	// pending are the fetched roots which are not saved yet
+	pending           []models.MerkleRoot
+	progress          SyncMerkleRootsProgress
+	blockHeadersCount int
+}
+
+// fetch gets the page of the merkle roots after the key
+func (s *merkleRootsSync) fetch(ctx context.Context, lastEvaluatedKey string) (*models.ExclusiveStartKeyPage[[]models.MerkleRoot], error) {
+	query := url.Values{}
+	if lastEvaluatedKey != "" {
+		query.Set("lastEvaluatedKey", lastEvaluatedKey)
+	}
+	if s.options.PageSize > 0 {
+		query.Set("batchSize", strconv.Itoa(s.options.PageSize))
+	}
+	path := "/merkleroots"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var merkleRootsResponse models.ExclusiveStartKeyPage[[]models.MerkleRoot]
+	err := s.wc.doHTTPRequest(ctx, http.MethodGet, path, nil, s.wc.xPriv, true, &merkleRootsResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	s.progress.Pages++
+	s.progress.Total = merkleRootsResponse.Page.TotalElements
+	if s.blockHeadersCount > 0 {
+		s.progress.Total = s.blockHeadersCount
+	}
+	return &merkleRootsResponse, nil
+}
+
+// flush saves the pending roots, moves the checkpoint and reports the progress
+func (s *merkleRootsSync) flush(ctx context.Context) error {
+	if len(s.pending) > 0 {
+		err := s.repo.SaveMerkleRoots(s.pending)
+		if err != nil {
+			s.wc.logger.ErrorContext(ctx, "cannot save synced merkle roots", slog.String("error", err.Error()))
+			return WrapError(err)
+		}
+		s.progress.Saved += len(s.pending)
+		s.progress.LastMerkleRoot = s.pending[len(s.pending)-1]
+		s.pending = nil
+
+		if err = s.saveCheckpoint(ctx); err != nil {
+			return err
+		}
+	}
+
+	s.progress.Remaining = max(s.progress.Total-s.progress.LastMerkleRoot.BlockHeight-1, 0)
+	if s.options.OnProgress != nil {
+		s.options.OnProgress(s.progress)
+	}
+	return nil
+}
+
+// rollBack deletes the roots above the fork point, and moves the checkpoint to it
+func (s *merkleRootsSync) rollBack(ctx context.Context, forkHeight int) error {
+	if err := s.reorgRepo.DeleteMerkleRootsAbove(forkHeight); err != nil {
+		return WrapError(err)
+	}
+	s.progress.LastMerkleRoot = models.MerkleRoot{MerkleRoot: s.reorgRepo.GetMerkleRootAt(forkHeight), BlockHeight: forkHeight}
+	return s.saveCheckpoint(ctx)
+}
+
+func (s *merkleRootsSync) saveCheckpoint(ctx context.Context) error {
+	if s.options.Checkpoint == nil {
+		return nil
+	}
+	if err := s.options.Checkpoint.SaveCheckpoint(s.progress.LastMerkleRoot); err != nil {
+		s.wc.logger.ErrorContext(ctx, "cannot save merkle roots checkpoint", slog.String("error", err.Error()))
+		return WrapError(err)
+	}
+	return nil
+}
+
+// abort saves the roots fetched before the sync failed with err
+func (s *merkleRootsSync) abort(ctx context.Context, err error) (*SyncMerkleRootsProgress, error) {
+	if flushErr := s.flush(ctx); flushErr != nil {
+		return &s.progress, flushErr
+	}
+	return &s.progress, err
+}
+
+// isUnknownMerkleRootError checks if the spv-wallet rejected the last evaluated key, e.g. because the root is no longer in its chain
+func isUnknownMerkleRootError(err error) bool {
+	var spvErr models.SPVError
+	if !errors.As(err, &spvErr) {
+		return false
+	}
+	return spvErr.StatusCode == http.StatusBadRequest || spvErr.StatusCode == http.StatusNotFound
+}
+
+// syncContextError returns the typed error of the sync stopped by its context, keeping the context error as the cause
+func syncContextError(cause error) error {
+	if errors.Is(cause, context.DeadlineExceeded) {
+		return newCauseError(ErrSyncMerkleRootsTimeout, cause)
+	}
+	return newCauseError(ErrSyncMerkleRootsCanceled, cause)
+}
+
+// linksToHeight checks if the synced roots continue the chain right after the height
+func linksToHeight(merkleRoots []models.MerkleRoot, height int) bool {
+	return len(merkleRoots) == 0 || merkleRoots[0].BlockHeight == height+1
+}
 
-			lastEvaluatedKeyQuery = fmt.Sprintf("?lastEvaluatedKey=%s", previousLastEvaluatedKey)
+// forkPointCandidate returns the stored root at the height, or the closest one below it;
+// an empty key and the height -1 mean that the roots are synced from the start
+func forkPointCandidate(repo ReorgAwareMerkleRootsRepository, height int) (string, int) {
+	for ; height >= 0; height-- {
+		if root := repo.GetMerkleRootAt(height); root != "" {
+			return root, height
 		}
 	}
+	return "", -1
 }
@@ -2,6 +2,9 @@ package walletclient
 
 import (
 	"context"
+	"errors"
+	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
@@ -81,6 +84,33 @@ func TestSyncMerkleRoots(t *testing.T) {
 
 		// then
 		require.ErrorIs(t, err, ErrSyncMerkleRootsTimeout)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		var timeoutErr *TimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+	})
+
+	t.Run("Should fail sync merkleroots when the context is canceled", func(t *testing.T) {
+		// setup
+		server := fixtures.MockMerkleRootsAPIResponseDelayed()
+		defer server.Close()
+
+		// given
+		repo := fixtures.CreateRepository([]models.MerkleRoot{})
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(60*time.Millisecond, cancel)
+
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+
+		// when
+		err = client.SyncMerkleRoots(ctx, repo)
+
+		// then
+		require.ErrorIs(t, err, ErrSyncMerkleRootsCanceled)
+		require.ErrorIs(t, err, context.Canceled)
+		require.NotErrorIs(t, err, ErrSyncMerkleRootsTimeout)
+		var canceledErr *CanceledError
+		require.ErrorAs(t, err, &canceledErr)
 	})
 
 	t.Run("Should fail sync merkleroots due to last evaluated key being the same in the response", func(t *testing.T) {
@@ -98,5 +128,269 @@ func TestSyncMerkleRoots(t *testing.T) {
 
 		// then
 		require.ErrorIs(t, err, ErrStaleLastEvaluatedKey)
+		var serverErr *ServerError
+		require.ErrorAs(t, err, &serverErr)
+	})
+
+	t.Run("Should roll back to the fork point when the last root is unknown to the server", func(t *testing.T) {
+		// setup
+		chain := fixtures.ReorgedMockedSPVWalletData(10, 16)
+		server := fixtures.MockMerkleRootsAPIResponseChain(fixtures.NewMerkleRootsChain(chain), 3)
+		defer server.Close()
+
+		// given
+		repo := NewMemoryMerkleRootsRepository(fixtures.MockedSPVWalletData...)
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+
+		// when
+		err = client.SyncMerkleRoots(context.Background(), repo)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, chain, repo.MerkleRoots())
+	})
+
+	t.Run("Should roll back to the fork point when the server roots do not link to the last root", func(t *testing.T) {
+		// setup
+		server := fixtures.MockMerkleRootsAPIResponseNormal()
+		defer server.Close()
+
+		// given
+		repo := NewMemoryMerkleRootsRepository(fixtures.ReorgedMockedSPVWalletData(11, 13)...)
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+
+		// when
+		err = client.SyncMerkleRoots(context.Background(), repo)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, fixtures.MockedSPVWalletData, repo.MerkleRoots())
+	})
+
+	t.Run("Should sync from the start when no stored root is in the server chain", func(t *testing.T) {
+		// setup
+		chain := fixtures.ReorgedMockedSPVWalletData(2, 8)
+		server := fixtures.MockMerkleRootsAPIResponseChain(fixtures.NewMerkleRootsChain(chain), 4)
+		defer server.Close()
+
+		// given
+		repo := NewMemoryMerkleRootsRepository(fixtures.MockedSPVWalletData[5:]...)
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+
+		// when
+		err = client.SyncMerkleRoots(context.Background(), repo)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, chain, repo.MerkleRoots())
+	})
+
+	t.Run("Should not roll back roots of a repository without heights", func(t *testing.T) {
+		// setup
+		server := fixtures.MockMerkleRootsAPIResponseChain(fixtures.NewMerkleRootsChain(fixtures.ReorgedMockedSPVWalletData(10, 16)), 3)
+		defer server.Close()
+
+		// given
+		repo := fixtures.CreateRepository(slices.Clone(fixtures.MockedSPVWalletData))
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+
+		// when
+		err = client.SyncMerkleRoots(context.Background(), repo)
+
+		// then
+		require.Error(t, err)
+		require.Equal(t, fixtures.MockedSPVWalletData, repo.MerkleRoots)
+	})
+}
+
+// progressRecorder records the progress reported by a sync
+type progressRecorder struct {
+	reported []SyncMerkleRootsProgress
+}
+
+func (r *progressRecorder) record(progress SyncMerkleRootsProgress) {
+	r.reported = append(r.reported, progress)
+}
+
+func TestSyncMerkleRootsWithOptions(t *testing.T) {
+	t.Run("Should save the roots in batches and report the progress", func(t *testing.T) {
+		// setup
+		chain := fixtures.NewMerkleRootsChain(fixtures.MockedSPVWalletData)
+		server := fixtures.MockMerkleRootsAPIResponseChain(chain, 100)
+		defer server.Close()
+
+		// given
+		repo := NewMemoryMerkleRootsRepository()
+		recorder := &progressRecorder{}
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+
+		// when
+		progress, err := client.SyncMerkleRootsWithOptions(context.Background(), repo, &SyncMerkleRootsOptions{
+			PageSize:   2,
+			BatchSize:  5,
+			OnProgress: recorder.record,
+		})
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, fixtures.MockedSPVWalletData, repo.MerkleRoots())
+		require.Equal(t, 8, chain.Requests())
+		require.Equal(t, []SyncMerkleRootsProgress{
+			{Pages: 3, Saved: 6, LastMerkleRoot: fixtures.MockedSPVWalletData[5], Total: 15, Remaining: 9},
+			{Pages: 6, Saved: 12, LastMerkleRoot: fixtures.MockedSPVWalletData[11], Total: 15, Remaining: 3},
+			{Pages: 8, Saved: 15, LastMerkleRoot: fixtures.MockedSPVWalletData[14], Total: 15, Remaining: 0},
+		}, recorder.reported)
+		require.Equal(t, recorder.reported[2], *progress)
 	})
+
+	t.Run("Should save the fetched roots when the sync fails", func(t *testing.T) {
+		// setup
+		chain := fixtures.NewMerkleRootsChain(fixtures.MockedSPVWalletData)
+		chain.Fail(2, 1)
+		server := fixtures.MockMerkleRootsAPIResponseChain(chain, 3)
+		defer server.Close()
+
+		// given
+		repo := NewMemoryMerkleRootsRepository()
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+
+		// when
+		progress, err := client.SyncMerkleRootsWithOptions(context.Background(), repo, &SyncMerkleRootsOptions{BatchSize: 100})
+
+		// then
+		require.Error(t, err)
+		require.Equal(t, fixtures.MockedSPVWalletData[:6], repo.MerkleRoots())
+		require.Equal(t, 6, progress.Saved)
+		require.Equal(t, 9, progress.Remaining)
+	})
+
+	t.Run("Should return the typed errors of the repository and the checkpoint", func(t *testing.T) {
+		// setup
+		chain := fixtures.NewMerkleRootsChain(fixtures.MockedSPVWalletData)
+		server := fixtures.MockMerkleRootsAPIResponseChain(chain, 100)
+		defer server.Close()
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		storageErr := errors.New("storage unavailable")
+
+		tests := map[string]struct {
+			repo       MerkleRootsRepository
+			checkpoint MerkleRootsCheckpoint
+		}{
+			"failed save of the roots": {
+				repo: &failingMerkleRootsRepository{MemoryMerkleRootsRepository: NewMemoryMerkleRootsRepository(), err: storageErr},
+			},
+			"failed load of the checkpoint": {
+				repo:       NewMemoryMerkleRootsRepository(),
+				checkpoint: &failingMerkleRootsCheckpoint{loadErr: storageErr},
+			},
+			"failed save of the checkpoint": {
+				repo:       NewMemoryMerkleRootsRepository(),
+				checkpoint: &failingMerkleRootsCheckpoint{saveErr: storageErr},
+			},
+		}
+		for name, tc := range tests {
+			t.Run(name, func(t *testing.T) {
+				// when
+				_, err := client.SyncMerkleRootsWithOptions(context.Background(), tc.repo, &SyncMerkleRootsOptions{Checkpoint: tc.checkpoint})
+
+				// then
+				require.ErrorIs(t, err, storageErr)
+				var clientErr *ClientError
+				require.ErrorAs(t, err, &clientErr)
+			})
+		}
+	})
+
+	t.Run("Should resume an interrupted sync from the checkpoint", func(t *testing.T) {
+		// setup
+		chain := fixtures.NewMerkleRootsChain(fixtures.MockedSPVWalletData)
+		server := fixtures.MockMerkleRootsAPIResponseChain(chain, 2)
+		defer server.Close()
+
+		// given
+		checkpoint := NewFileMerkleRootsCheckpoint(filepath.Join(t.TempDir(), "checkpoint.jsonl"))
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+		interrupted := fixtures.CreateRepository([]models.MerkleRoot{})
+		failed := false
+		_, err = client.SyncMerkleRootsWithOptions(context.Background(), interrupted, &SyncMerkleRootsOptions{
+			BatchSize:  4,
+			Checkpoint: checkpoint,
+			OnProgress: func(progress SyncMerkleRootsProgress) {
+				if !failed {
+					chain.Fail(0, 1)
+					failed = true
+				}
+			},
+		})
+		require.Error(t, err)
+		require.Len(t, interrupted.MerkleRoots, 4)
+
+		// when
+		resumed := fixtures.CreateRepository([]models.MerkleRoot{})
+		progress, err := client.SyncMerkleRootsWithOptions(context.Background(), resumed, &SyncMerkleRootsOptions{Checkpoint: checkpoint})
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, fixtures.MockedSPVWalletData[4:], resumed.MerkleRoots)
+		require.Equal(t, fixtures.LastMockedMerkleRoot(), progress.LastMerkleRoot)
+		saved, err := checkpoint.LoadCheckpoint()
+		require.NoError(t, err)
+		require.Equal(t, fixtures.LastMockedMerkleRoot(), *saved)
+	})
+
+	t.Run("Should move the checkpoint back to the fork point of a chain reorganisation", func(t *testing.T) {
+		// setup
+		chain := fixtures.ReorgedMockedSPVWalletData(10, 11)
+		server := fixtures.MockMerkleRootsAPIResponseChain(fixtures.NewMerkleRootsChain(chain), 100)
+		defer server.Close()
+
+		// given
+		checkpoint := NewFileMerkleRootsCheckpoint(filepath.Join(t.TempDir(), "checkpoint.jsonl"))
+		require.NoError(t, checkpoint.SaveCheckpoint(fixtures.LastMockedMerkleRoot()))
+		repo := NewMemoryMerkleRootsRepository(fixtures.MockedSPVWalletData...)
+		client, err := NewWithXPriv(server.URL, fixtures.XPrivString)
+		require.NoError(t, err)
+
+		// when
+		_, err = client.SyncMerkleRootsWithOptions(context.Background(), repo, &SyncMerkleRootsOptions{Checkpoint: checkpoint})
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, chain, repo.MerkleRoots())
+		saved, err := checkpoint.LoadCheckpoint()
+		require.NoError(t, err)
+		require.Equal(t, chain[11], *saved)
+	})
+}
+
+// failingMerkleRootsRepository fails to save the merkle roots
+type failingMerkleRootsRepository struct {
+	*MemoryMerkleRootsRepository
+	err error
+}
+
+func (r *failingMerkleRootsRepository) SaveMerkleRoots([]models.MerkleRoot) error {
+	return r.err
+}
+
+// failingMerkleRootsCheckpoint fails to load or to save the checkpoint
+type failingMerkleRootsCheckpoint struct {
+	loadErr error
+	saveErr error
+}
+
+func (c *failingMerkleRootsCheckpoint) LoadCheckpoint() (*models.MerkleRoot, error) {
+	return nil, c.loadErr
+}
+
+func (c *failingMerkleRootsCheckpoint) SaveCheckpoint(models.MerkleRoot) error {
+	return c.saveErr
 }
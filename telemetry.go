@@ -0,0 +1,135 @@
+package walletclient
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer and meter of the WalletClient
+const instrumentationName = "github.com/bitcoin-sv/spv-wallet-go-client"
+
+// Attributes recorded on the spans and metrics of the calls to the spv-wallet
+const (
+	AttributeOperation  = attribute.Key("spv_wallet.operation")
+	AttributeMethod     = attribute.Key("http.request.method")
+	AttributePath       = attribute.Key("url.path")
+	AttributeStatusCode = attribute.Key("http.response.status_code")
+	AttributeErrorCode  = attribute.Key("spv_wallet.error.code")
+	AttributeRetries    = attribute.Key("spv_wallet.retries")
+)
+
+// TelemetryOptions configures the OpenTelemetry instrumentation of the WalletClient; nil fields fall back to the global providers
+type TelemetryOptions struct {
+	// TracerProvider creates the tracer of the spans, one per call named after the operation (e.g. "GetUtxos").
+	TracerProvider trace.TracerProvider
+	// MeterProvider creates the meter of the request count and duration instruments.
+	MeterProvider metric.MeterProvider
+	// Propagator injects the trace context into the headers of the requests.
+	Propagator propagation.TextMapPropagator
+}
+
+// WithTelemetry instruments the client with OpenTelemetry: every call gets a client span and is recorded
+// in the spv_wallet.client.requests counter and the spv_wallet.client.duration histogram
+func WithTelemetry(opts *TelemetryOptions) Option {
+	return &telemetryConf{Options: opts}
+}
+
+// telemetryConf registers the OpenTelemetry interceptor on a WalletClient
+type telemetryConf struct {
+	Options *TelemetryOptions
+}
+
+func (w *telemetryConf) Configure(c *WalletClient) error {
+	interceptor, err := NewTelemetryInterceptor(w.Options)
+	if err != nil {
+		return err
+	}
+	c.Use(interceptor)
+	return nil
+}
+
+// NewTelemetryInterceptor creates the interceptor which traces and measures the calls to the spv-wallet
+func NewTelemetryInterceptor(opts *TelemetryOptions) (*Interceptor, error) {
+	if opts == nil {
+		opts = &TelemetryOptions{}
+	}
+	tracerProvider, meterProvider, propagator := opts.TracerProvider, opts.MeterProvider, opts.Propagator
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+
+	tracer := tracerProvider.Tracer(instrumentationName)
+	meter := meterProvider.Meter(instrumentationName)
+	requests, err := meter.Int64Counter("spv_wallet.client.requests",
+		metric.WithDescription("Number of calls made to the spv-wallet"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, WrapError(err)
+	}
+	duration, err := meter.Float64Histogram("spv_wallet.client.duration",
+		metric.WithDescription("Duration of the calls made to the spv-wallet, retries included"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, WrapError(err)
+	}
+
+	return &Interceptor{
+		BeforeCall: func(ctx context.Context, call *CallInfo) context.Context {
+			ctx, _ = tracer.Start(ctx, spanName(call),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					AttributeOperation.String(call.Operation),
+					AttributeMethod.String(call.Method),
+					AttributePath.String(call.Path),
+				),
+			)
+			return ctx
+		},
+		BeforeSign: func(req *http.Request, _ int) error {
+			propagator.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+			return nil
+		},
+		AfterResponse: func(ctx context.Context, call *CallInfo) {
+			attributes := []attribute.KeyValue{
+				AttributeOperation.String(call.Operation),
+				AttributeMethod.String(call.Method),
+				AttributeStatusCode.Int(call.StatusCode),
+				AttributeErrorCode.String(call.ErrorCode),
+			}
+			requests.Add(ctx, 1, metric.WithAttributes(attributes...))
+			duration.Record(ctx, call.Latency.Seconds(), metric.WithAttributes(attributes...))
+
+			span := trace.SpanFromContext(ctx)
+			span.SetAttributes(AttributeStatusCode.Int(call.StatusCode), AttributeRetries.Int(call.Retries))
+			if call.Err != nil {
+				span.SetAttributes(AttributeErrorCode.String(call.ErrorCode))
+				span.RecordError(call.Err)
+				span.SetStatus(codes.Error, call.Err.Error())
+			}
+			span.End()
+		},
+	}, nil
+}
+
+// spanName returns the operation of the call, or its method and path when it is not made by a WalletClient method
+func spanName(call *CallInfo) string {
+	if call.Operation != "" {
+		return call.Operation
+	}
+	return call.Method + " " + call.Path
+}
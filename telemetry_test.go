@@ -0,0 +1,83 @@
+package walletclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/bitcoin-sv/spv-wallet-go-client/fixtures"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTelemetry(t *testing.T) {
+	newInstrumentedClient := func(t *testing.T, serverURL string) (*WalletClient, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+		spans := tracetest.NewSpanRecorder()
+		reader := sdkmetric.NewManualReader()
+		client, err := New(serverURL,
+			WithXPriv(fixtures.XPrivString),
+			WithTelemetry(&TelemetryOptions{
+				TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+				MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+				Propagator:     propagation.TraceContext{},
+			}),
+		)
+		require.NoError(t, err)
+		return client, spans, reader
+	}
+
+	t.Run("Should trace the call as its operation and propagate the trace context", func(t *testing.T) {
+		// given
+		server, requests := flakyServer(0, http.StatusOK, nil)
+		defer server.Close()
+		client, spans, reader := newInstrumentedClient(t, server.URL)
+
+		// when
+		_, err := client.GetUtxos(context.Background(), nil, nil, nil)
+
+		// then
+		require.Error(t, err) // the fixtures xpub is not a list of utxos
+		ended := spans.Ended()
+		require.Len(t, ended, 1)
+		span := ended[0]
+		require.Equal(t, "GetUtxos", span.Name())
+		require.Contains(t, span.Attributes(), AttributePath.String("/utxo/search"))
+		require.Contains(t, span.Attributes(), AttributeStatusCode.Int(http.StatusOK))
+		require.Equal(t, codes.Error, span.Status().Code)
+
+		traceparent := (*requests)[0].Header.Get("traceparent")
+		require.Contains(t, traceparent, span.SpanContext().TraceID().String())
+
+		var metrics metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &metrics))
+		require.Len(t, metrics.ScopeMetrics, 1)
+		names := make([]string, 0)
+		for _, m := range metrics.ScopeMetrics[0].Metrics {
+			names = append(names, m.Name)
+		}
+		require.ElementsMatch(t, []string{"spv_wallet.client.requests", "spv_wallet.client.duration"}, names)
+	})
+
+	t.Run("Should record the SPVError code of a failed call", func(t *testing.T) {
+		// given
+		server, _ := flakyServer(1, http.StatusServiceUnavailable, nil)
+		defer server.Close()
+		client, spans, _ := newInstrumentedClient(t, server.URL)
+
+		// when
+		_, err := client.GetXPub(context.Background())
+
+		// then
+		require.Error(t, err)
+		span := spans.Ended()[0]
+		require.Equal(t, "GetXPub", span.Name())
+		require.Contains(t, span.Attributes(), AttributeErrorCode.String("error-unavailable"))
+		require.Contains(t, span.Attributes(), attribute.Int(string(AttributeStatusCode), http.StatusServiceUnavailable))
+	})
+}
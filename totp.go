@@ -78,22 +78,22 @@ func getTotpOpts(period, digits uint) *totp.ValidateOpts {
 
 func getSharedSecretFactors(b *WalletClient, c *models.Contact) (*ec.PrivateKey, *ec.PublicKey, error) {
 	if b.xPriv == nil {
-		return nil, nil, ErrMissingXpriv
+		return nil, nil, WrapError(ErrMissingXpriv)
 	}
 
 	xpriv, err := deriveXprivForPki(b.xPriv)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, WrapError(err)
 	}
 
 	privKey, err := xpriv.ECPrivKey()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, WrapError(err)
 	}
 
 	pubKey, err := convertPubKey(c.PubKey)
 	if err != nil {
-		return nil, nil, ErrContactPubKeyInvalid
+		return nil, nil, WrapError(ErrContactPubKeyInvalid.Wrap(err))
 	}
 
 	return privKey, pubKey, nil
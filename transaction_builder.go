@@ -0,0 +1,310 @@
+package walletclient
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	bip32 "github.com/bitcoin-sv/go-sdk/compat/bip32"
+	script "github.com/bitcoin-sv/go-sdk/script"
+	trx "github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/template/p2pkh"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/bitcoin-sv/spv-wallet/models/filter"
+)
+
+const (
+	// p2pkhInputSize is the estimated size of a signed P2PKH input: outpoint (36), script length (1), unlocking script (107) and sequence (4)
+	p2pkhInputSize = 148
+
+	// p2pkhLockingScriptSize is the size of a P2PKH locking script
+	p2pkhLockingScriptSize = 25
+
+	// transactionOverheadSize is the size of the version and lock time fields
+	transactionOverheadSize = 8
+)
+
+// DefaultFeeUnit is the fee unit used to build transactions locally when none is provided
+var DefaultFeeUnit = models.FeeUnit{Satoshis: 1, Bytes: 1000}
+
+// LocalTransactionConfig is the configuration of a transaction built and signed on the client side
+type LocalTransactionConfig struct {
+	// Recipients are the outputs of the transaction; only addresses and OP_RETURN data can be used offline.
+	Recipients []*Recipients
+	// Utxos are the candidates for the inputs; when nil, all spendable utxos are fetched with GetUtxos.
+	Utxos []*models.Utxo
+	// Strategy is the coin selection strategy, CoinSelectionLargestFirst by default.
+	Strategy CoinSelectionStrategy
+	// FeeUnit is the fee rate, e.g. the one from a server draft configuration; DefaultFeeUnit when nil.
+	FeeUnit *models.FeeUnit
+	// ChangeDestination receives the change; when nil, a new destination is created with NewDestination.
+	ChangeDestination *models.Destination
+	// ChangeMinimumSatoshis is the minimal amount of a change output, smaller leftovers are paid as a fee.
+	ChangeMinimumSatoshis uint64
+	// Destinations are the known destinations of the utxos; the missing ones are fetched with GetDestinationByLockingScript.
+	Destinations []*models.Destination
+}
+
+// LocalTransaction is a transaction built and signed on the client side, ready for RecordTransaction
+type LocalTransaction struct {
+	// Hex is the signed transaction hex.
+	Hex string
+	// Inputs are the spent utxos with their destinations.
+	Inputs []*models.TransactionInput
+	// Outputs are the outputs of the transaction, including the change output (if any) as the last one.
+	Outputs []*models.TransactionOutput
+	// Fee is the fee paid by the transaction.
+	Fee uint64
+	// ChangeSatoshis is the amount returned to the change destination.
+	ChangeSatoshis uint64
+	// ChangeDestination is the destination of the change output, nil when there is no change.
+	ChangeDestination *models.Destination
+}
+
+// BuildTransaction selects the utxos, computes the fee and the change and signs the transaction locally,
+// without creating a draft transaction on the server. The result can be compared with a server draft
+// or recorded with RecordTransaction.
+func (wc *WalletClient) BuildTransaction(ctx context.Context, config *LocalTransactionConfig) (*LocalTransaction, error) {
+	ctx = withOperation(ctx, "BuildTransaction")
+	if wc.xPriv == nil {
+		return nil, WrapError(ErrMissingXpriv)
+	}
+	if config == nil || len(config.Recipients) == 0 {
+		return nil, WrapError(ErrMissingRecipients)
+	}
+
+	outputs, err := recipientsToOutputs(config.Recipients)
+	if err != nil {
+		return nil, WrapError(err)
+	}
+
+	utxos := config.Utxos
+	if utxos == nil {
+		if utxos, err = wc.spendableUtxos(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	feeUnit := config.FeeUnit
+	if feeUnit == nil {
+		feeUnit = &DefaultFeeUnit
+	}
+
+	var target uint64
+	for _, output := range outputs {
+		target += output.Satoshis
+	}
+
+	selection, err := SelectCoins(config.Strategy, utxos, target, NewFeeEstimator(feeUnit, outputs), config.ChangeMinimumSatoshis)
+	if err != nil {
+		return nil, WrapError(err)
+	}
+
+	result := &LocalTransaction{Fee: selection.Fee}
+	if selection.Change > 0 {
+		changeDestination := config.ChangeDestination
+		if changeDestination == nil {
+			if changeDestination, err = wc.NewDestination(ctx, nil); err != nil {
+				return nil, err
+			}
+		}
+		changeScript, err := prepareLockingScript(changeDestination)
+		if err != nil {
+			return nil, WrapError(err)
+		}
+		outputs = append(outputs, &trx.TransactionOutput{
+			Satoshis:      selection.Change,
+			LockingScript: changeScript,
+			Change:        true,
+		})
+		result.ChangeSatoshis = selection.Change
+		result.ChangeDestination = changeDestination
+	}
+
+	if result.Inputs, err = wc.resolveInputs(ctx, selection.Utxos, config.Destinations); err != nil {
+		return nil, err
+	}
+
+	if result.Hex, err = signLocalTransaction(wc.xPriv, result.Inputs, outputs); err != nil {
+		return nil, WrapError(err)
+	}
+
+	for _, output := range outputs {
+		result.Outputs = append(result.Outputs, &models.TransactionOutput{
+			Satoshis:     output.Satoshis,
+			Script:       output.LockingScript.String(),
+			UseForChange: output.Change,
+		})
+	}
+
+	return result, nil
+}
+
+// NewFeeEstimator returns a FeeEstimator for a transaction with the given (non-change) outputs and P2PKH inputs
+func NewFeeEstimator(feeUnit *models.FeeUnit, outputs []*trx.TransactionOutput) FeeEstimator {
+	outputsSize := 0
+	for _, output := range outputs {
+		outputsSize += outputSize(len(output.LockingScript.Bytes()))
+	}
+
+	return func(inputs int, withChange bool) uint64 {
+		outputCount := len(outputs)
+		size := outputsSize
+		if withChange {
+			outputCount++
+			size += outputSize(p2pkhLockingScriptSize)
+		}
+		size += transactionOverheadSize + inputs*p2pkhInputSize
+		size += len(trx.VarInt(uint64(inputs)).Bytes()) + len(trx.VarInt(uint64(outputCount)).Bytes())
+
+		return calculateFee(feeUnit, size)
+	}
+}
+
+// calculateFee returns the fee for a transaction of the given size, rounded up to a whole satoshi
+func calculateFee(feeUnit *models.FeeUnit, size int) uint64 {
+	if feeUnit.Bytes <= 0 || feeUnit.Satoshis <= 0 {
+		return 0
+	}
+	return (uint64(size)*uint64(feeUnit.Satoshis) + uint64(feeUnit.Bytes) - 1) / uint64(feeUnit.Bytes)
+}
+
+// outputSize returns the size of an output with a locking script of the given length: satoshis (8), script length and script
+func outputSize(scriptLength int) int {
+	return 8 + len(trx.VarInt(uint64(scriptLength)).Bytes()) + scriptLength
+}
+
+// recipientsToOutputs converts the recipients into transaction outputs
+func recipientsToOutputs(recipients []*Recipients) ([]*trx.TransactionOutput, error) {
+	outputs := make([]*trx.TransactionOutput, 0, len(recipients))
+	for _, recipient := range recipients {
+		lockingScript, err := recipientLockingScript(recipient)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, &trx.TransactionOutput{
+			Satoshis:      recipient.Satoshis,
+			LockingScript: lockingScript,
+		})
+	}
+	return outputs, nil
+}
+
+// recipientLockingScript returns the locking script of an address or OP_RETURN recipient
+func recipientLockingScript(recipient *Recipients) (*script.Script, error) {
+	if recipient.OpReturn != nil {
+		return opReturnLockingScript(recipient.OpReturn)
+	}
+	if strings.Contains(recipient.To, "@") {
+		return nil, ErrPaymailRecipientNotSupported
+	}
+
+	address, err := script.NewAddressFromString(recipient.To)
+	if err != nil {
+		return nil, ErrInvalidRecipient.Wrap(err)
+	}
+	return p2pkh.Lock(address)
+}
+
+// opReturnLockingScript builds the OP_FALSE OP_RETURN locking script of the given data
+func opReturnLockingScript(opReturn *models.OpReturn) (*script.Script, error) {
+	switch {
+	case opReturn.Hex != "":
+		lockingScript, err := script.NewFromHex(opReturn.Hex)
+		if err != nil {
+			return nil, ErrInvalidRecipient.Wrap(err)
+		}
+		return lockingScript, nil
+	case len(opReturn.HexParts) > 0:
+		parts := make([][]byte, 0, len(opReturn.HexParts))
+		for _, part := range opReturn.HexParts {
+			data, err := hex.DecodeString(part)
+			if err != nil {
+				return nil, ErrInvalidRecipient.Wrap(err)
+			}
+			parts = append(parts, data)
+		}
+		return opReturnOutputScript(parts)
+	case len(opReturn.StringParts) > 0:
+		parts := make([][]byte, 0, len(opReturn.StringParts))
+		for _, part := range opReturn.StringParts {
+			parts = append(parts, []byte(part))
+		}
+		return opReturnOutputScript(parts)
+	default:
+		return nil, ErrInvalidRecipient.Wrap(fmt.Errorf("unsupported op_return data"))
+	}
+}
+
+func opReturnOutputScript(parts [][]byte) (*script.Script, error) {
+	output, err := trx.CreateOpReturnOutput(parts)
+	if err != nil {
+		return nil, ErrInvalidRecipient.Wrap(err)
+	}
+	return output.LockingScript, nil
+}
+
+// spendableUtxos fetches all the P2PKH utxos which are neither spent nor reserved by a draft
+func (wc *WalletClient) spendableUtxos(ctx context.Context) ([]*models.Utxo, error) {
+	conditions := &filter.UtxoFilter{Type: Optional("pubkeyhash")}
+
+	var utxos []*models.Utxo
+	err := ForEach(wc.GetUtxosIter(ctx, conditions, nil, nil), func(utxo *models.Utxo) error {
+		if utxo.SpendingTxID == "" && utxo.DraftID == "" {
+			utxos = append(utxos, utxo)
+		}
+		return nil
+	})
+	return utxos, err
+}
+
+// resolveInputs pairs the selected utxos with their destinations, which hold the derivation path of the signing key
+func (wc *WalletClient) resolveInputs(ctx context.Context, utxos []*models.Utxo, known []*models.Destination) ([]*models.TransactionInput, error) {
+	destinations := make(map[string]*models.Destination, len(known))
+	for _, destination := range known {
+		destinations[destination.LockingScript] = destination
+	}
+
+	inputs := make([]*models.TransactionInput, 0, len(utxos))
+	for _, utxo := range utxos {
+		destination, ok := destinations[utxo.ScriptPubKey]
+		if !ok {
+			var err error
+			if destination, err = wc.GetDestinationByLockingScript(ctx, utxo.ScriptPubKey); err != nil {
+				return nil, err
+			}
+		}
+		inputs = append(inputs, &models.TransactionInput{Utxo: *utxo, Destination: *destination})
+	}
+	return inputs, nil
+}
+
+// signLocalTransaction builds the transaction from the inputs and outputs and signs all the inputs with the xPriv
+func signLocalTransaction(xPriv *bip32.ExtendedKey, inputs []*models.TransactionInput, outputs []*trx.TransactionOutput) (string, error) {
+	tx := trx.NewTransaction()
+	for _, input := range inputs {
+		lockingScript, err := prepareLockingScript(&input.Destination)
+		if err != nil {
+			return "", err
+		}
+
+		unlockScript, err := prepareUnlockingScript(xPriv, &input.Destination)
+		if err != nil {
+			return "", err
+		}
+
+		if err = tx.AddInputFrom(input.TransactionID, input.OutputIndex, lockingScript.String(), input.Satoshis, unlockScript); err != nil {
+			return "", err
+		}
+	}
+
+	for _, output := range outputs {
+		tx.AddOutput(output)
+	}
+
+	if err := tx.Sign(); err != nil {
+		return "", err
+	}
+	return tx.String(), nil
+}
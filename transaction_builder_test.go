@@ -0,0 +1,114 @@
+package walletclient
+
+import (
+	"context"
+	"testing"
+
+	bip32 "github.com/bitcoin-sv/go-sdk/compat/bip32"
+	script "github.com/bitcoin-sv/go-sdk/script"
+	"github.com/bitcoin-sv/go-sdk/script/interpreter"
+	trx "github.com/bitcoin-sv/go-sdk/transaction"
+	"github.com/bitcoin-sv/go-sdk/transaction/template/p2pkh"
+	"github.com/bitcoin-sv/spv-wallet-go-client/fixtures"
+	"github.com/bitcoin-sv/spv-wallet/models"
+	"github.com/stretchr/testify/require"
+)
+
+// testDestination derives the destination of the fixtures xPriv at the given chain and num
+func testDestination(t *testing.T, chain, num uint32) *models.Destination {
+	xPriv, err := bip32.GenerateHDKeyFromString(fixtures.XPrivString)
+	require.NoError(t, err)
+	key, err := bip32.GetHDKeyByPath(xPriv, chain, num)
+	require.NoError(t, err)
+	address, err := bip32.GetAddressFromHDKey(key)
+	require.NoError(t, err)
+	lockingScript, err := p2pkh.Lock(address)
+	require.NoError(t, err)
+
+	return &models.Destination{
+		LockingScript: lockingScript.String(),
+		Type:          "pubkeyhash",
+		Chain:         chain,
+		Num:           num,
+		Address:       address.AddressString,
+	}
+}
+
+func TestBuildTransaction(t *testing.T) {
+	client, err := NewWithXPriv(fixtures.ServerURL, fixtures.XPrivString)
+	require.NoError(t, err)
+
+	inputDestination := testDestination(t, 0, 0)
+	changeDestination := testDestination(t, 1, 0)
+	recipient := testDestination(t, 0, 1)
+	utxos := []*models.Utxo{
+		{UtxoPointer: models.UtxoPointer{TransactionID: fixtures.Transaction.ID, OutputIndex: 0}, Satoshis: 1000, ScriptPubKey: inputDestination.LockingScript},
+		{UtxoPointer: models.UtxoPointer{TransactionID: fixtures.Transaction.ID, OutputIndex: 1}, Satoshis: 5000, ScriptPubKey: inputDestination.LockingScript},
+	}
+
+	t.Run("Should build and sign a transaction offline", func(t *testing.T) {
+		// when
+		result, err := client.BuildTransaction(context.Background(), &LocalTransactionConfig{
+			Recipients: []*Recipients{
+				{To: recipient.Address, Satoshis: 3000},
+				{OpReturn: &models.OpReturn{StringParts: []string{"hello"}}},
+			},
+			Utxos:             utxos,
+			FeeUnit:           &models.FeeUnit{Satoshis: 50, Bytes: 1000},
+			ChangeDestination: changeDestination,
+			Destinations:      []*models.Destination{inputDestination},
+		})
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, result.Inputs, 1)
+		require.Equal(t, uint64(5000), result.Inputs[0].Satoshis)
+		require.Equal(t, changeDestination, result.ChangeDestination)
+		require.Equal(t, uint64(5000-3000), result.Fee+result.ChangeSatoshis)
+
+		tx, err := trx.NewTransactionFromHex(result.Hex)
+		require.NoError(t, err)
+		require.Len(t, tx.Outputs, 3)
+		require.Equal(t, recipient.LockingScript, tx.Outputs[0].LockingScript.String())
+		require.True(t, tx.Outputs[1].LockingScript.IsData())
+		require.Equal(t, changeDestination.LockingScript, tx.Outputs[2].LockingScript.String())
+		require.Equal(t, result.ChangeSatoshis, tx.Outputs[2].Satoshis)
+
+		// the fee matches the rate of the signed transaction
+		require.Equal(t, calculateFee(&models.FeeUnit{Satoshis: 50, Bytes: 1000}, tx.Size()), result.Fee)
+
+		prevOutput := &trx.TransactionOutput{Satoshis: 5000, LockingScript: mustLockingScript(t, inputDestination)}
+		require.NoError(t, interpreter.NewEngine().Execute(
+			interpreter.WithTx(tx, 0, prevOutput),
+			interpreter.WithForkID(),
+			interpreter.WithAfterGenesis(),
+		))
+	})
+
+	t.Run("Should reject paymail recipients", func(t *testing.T) {
+		_, err := client.BuildTransaction(context.Background(), &LocalTransactionConfig{
+			Recipients: []*Recipients{{To: fixtures.PaymailAddress, Satoshis: 100}},
+			Utxos:      utxos,
+		})
+		require.ErrorIs(t, err, ErrPaymailRecipientNotSupported)
+	})
+
+	t.Run("Should fail on insufficient funds", func(t *testing.T) {
+		_, err := client.BuildTransaction(context.Background(), &LocalTransactionConfig{
+			Recipients: []*Recipients{{To: recipient.Address, Satoshis: 6000}},
+			Utxos:      utxos,
+		})
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+	})
+
+	t.Run("Should require recipients", func(t *testing.T) {
+		_, err := client.BuildTransaction(context.Background(), &LocalTransactionConfig{Utxos: utxos})
+		require.ErrorIs(t, err, ErrMissingRecipients)
+	})
+}
+
+func mustLockingScript(t *testing.T, destination *models.Destination) *script.Script {
+	lockingScript, err := prepareLockingScript(destination)
+	require.NoError(t, err)
+	return lockingScript
+}
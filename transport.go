@@ -0,0 +1,29 @@
+package walletclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+// handlerTransport is an http.RoundTripper which serves the requests with an http.Handler in memory
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t *handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	served := req
+	if served.Body == nil {
+		served = req.Clone(req.Context())
+		served.Body = http.NoBody
+	}
+
+	recorder := httptest.NewRecorder()
+	t.handler.ServeHTTP(recorder, served)
+
+	resp := recorder.Result()
+	resp.Request = req
+	return resp, nil
+}
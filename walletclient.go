@@ -1,6 +1,7 @@
 package walletclient
 
 import (
+	"log/slog"
 	"net/http"
 
 	bip32 "github.com/bitcoin-sv/go-sdk/compat/bip32"
@@ -10,12 +11,34 @@ import (
 // WalletClient is the spv wallet Go client representation.
 type WalletClient struct {
 	signRequest bool
+	transport   TransportType
 	server      string
 	httpClient  *http.Client
 	accessKey   *ec.PrivateKey
 	adminXPriv  *bip32.ExtendedKey
 	xPriv       *bip32.ExtendedKey
 	xPub        *bip32.ExtendedKey
+	retryPolicy *RetryPolicy
+
+	defaultHeaders    http.Header
+	draftVerification *DraftVerificationOptions
+	interceptors      []*Interceptor
+	logger            *slog.Logger
+}
+
+// New creates a new WalletClient instance configured with the given options.
+// The options are applied in order, after the server URL; requests are signed unless WithSignRequest(false) is passed.
+// - `serverURL`: The URL of the server the client will interact with. ex. https://hostname:3003
+func New(serverURL string, opts ...Option) (*WalletClient, error) {
+	configurators := []configurator{
+		&httpConf{ServerURL: serverURL},
+		&signRequest{Sign: true},
+	}
+	for _, opt := range opts {
+		configurators = append(configurators, opt)
+	}
+
+	return makeClient(configurators...)
 }
 
 // NewWithXPriv creates a new WalletClient instance using a private key (xPriv).
@@ -23,11 +46,7 @@ type WalletClient struct {
 // - `xPriv`: The extended private key used for cryptographic operations.
 // - `serverURL`: The URL of the server the client will interact with. ex. https://hostname:3003
 func NewWithXPriv(serverURL, xPriv string) (*WalletClient, error) {
-	return makeClient(
-		&xPrivConf{XPrivString: xPriv},
-		&httpConf{ServerURL: serverURL},
-		&signRequest{Sign: true},
-	)
+	return New(serverURL, WithXPriv(xPriv), WithSignRequest(true))
 }
 
 // NewWithXPub creates a new WalletClient instance using a public key (xPub).
@@ -35,11 +54,7 @@ func NewWithXPriv(serverURL, xPriv string) (*WalletClient, error) {
 // - `xPub`: The extended public key used for cryptographic verification and other public operations.
 // - `serverURL`: The URL of the server the client will interact with. ex. https://hostname:3003
 func NewWithXPub(serverURL, xPub string) (*WalletClient, error) {
-	return makeClient(
-		&xPubConf{XPubString: xPub},
-		&httpConf{ServerURL: serverURL},
-		&signRequest{Sign: false},
-	)
+	return New(serverURL, WithXPub(xPub), WithSignRequest(false))
 }
 
 // NewWithAdminKey creates a new WalletClient using an administrative key for advanced operations.
@@ -47,11 +62,7 @@ func NewWithXPub(serverURL, xPub string) (*WalletClient, error) {
 // - `adminKey`: The extended private key used for administrative operations.
 // - `serverURL`: The URL of the server the client will interact with. ex. https://hostname:3003
 func NewWithAdminKey(serverURL, adminKey string) (*WalletClient, error) {
-	return makeClient(
-		&adminKeyConf{AdminKeyString: adminKey},
-		&httpConf{ServerURL: serverURL},
-		&signRequest{Sign: true},
-	)
+	return New(serverURL, WithAdminKey(adminKey), WithSignRequest(true))
 }
 
 // NewWithAccessKey creates a new WalletClient configured with an access key for API authentication.
@@ -59,16 +70,12 @@ func NewWithAdminKey(serverURL, adminKey string) (*WalletClient, error) {
 // - `accessKey`: The access key used for API authentication.
 // - `serverURL`: The URL of the server the client will interact with. ex. https://hostname:3003
 func NewWithAccessKey(serverURL, accessKey string) (*WalletClient, error) {
-	return makeClient(
-		&accessKeyConf{AccessKeyString: accessKey},
-		&httpConf{ServerURL: serverURL},
-		&signRequest{Sign: true},
-	)
+	return New(serverURL, WithAccessKey(accessKey), WithSignRequest(true))
 }
 
 // makeClient creates a new WalletClient using the provided configuration options.
 func makeClient(configurators ...configurator) (*WalletClient, error) {
-	client := &WalletClient{}
+	client := &WalletClient{logger: discardLogger()}
 
 	var err error
 	for _, configurator := range configurators {